@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/Alexander272/Pinger/internal/config"
+	"github.com/Alexander272/Pinger/internal/metrics"
 	"github.com/Alexander272/Pinger/internal/migrate"
+	"github.com/Alexander272/Pinger/internal/models"
 	"github.com/Alexander272/Pinger/internal/repo"
+	"github.com/Alexander272/Pinger/internal/server"
 	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/Alexander272/Pinger/internal/transport/command"
+	transport "github.com/Alexander272/Pinger/internal/transport/http"
 	"github.com/Alexander272/Pinger/internal/transport/socket"
 	"github.com/Alexander272/Pinger/pkg/database/postgres"
 	"github.com/Alexander272/Pinger/pkg/logger"
@@ -18,12 +27,14 @@ import (
 	"github.com/subosito/gotenv"
 )
 
+const configPath = "configs/config.yaml"
+
 func main() {
 	if err := gotenv.Load(".env"); err != nil {
 		log.Fatalf("failed to load env variables. error: %s", err.Error())
 	}
 
-	conf, err := config.Init("configs/config.yaml")
+	conf, err := config.Init(configPath)
 	if err != nil {
 		log.Fatalf("failed to init configs. error: %s", err.Error())
 	}
@@ -63,6 +74,10 @@ func main() {
 	}
 	logger.Debug("me", logger.AnyAttr("bot", bot))
 
+	if conf.Bot.Actions.Secret == "" {
+		conf.Bot.Actions.Secret = conf.Bot.Token
+	}
+
 	//* Services, Repos & API Handlers
 	repos := repo.NewRepository(db)
 
@@ -70,27 +85,90 @@ func main() {
 		Repo:      repos,
 		Client:    mostClient,
 		ChannelID: conf.Bot.ChannelId,
+		Admins:    conf.Bot.Admins,
+		Scheduler: schedulerDefaults(conf),
+		Heartbeat: &models.Heartbeat{
+			Interval:        conf.Heartbeat.Interval,
+			MissedIntervals: conf.Heartbeat.MissedIntervals,
+			URL:             conf.Heartbeat.URL,
+			Timeout:         conf.Heartbeat.Timeout,
+		},
+		StatusPage: &models.StatusPageConf{
+			Enabled:  conf.StatusPage.Enabled,
+			Title:    conf.StatusPage.Title,
+			Days:     conf.StatusPage.Days,
+			CacheTTL: conf.StatusPage.CacheTTL,
+		},
+		Retention: conf.Measurements.Retention,
+		Command: &models.SlashCommand{
+			Trigger: conf.Bot.Command.Trigger,
+			Token:   conf.Bot.Command.Token,
+			TeamID:  conf.Bot.Command.TeamID,
+			URL:     conf.Bot.Command.URL,
+		},
+		Alerts: &models.AlertsConf{
+			URL:             conf.Bot.Actions.URL,
+			Secret:          conf.Bot.Actions.Secret,
+			SilenceDuration: conf.Bot.Actions.Silence,
+		},
+		Dialogs: &models.DialogConf{
+			URL:        conf.Bot.Actions.DialogURL,
+			ActionsURL: conf.Bot.Actions.URL,
+			Secret:     conf.Bot.Actions.Secret,
+		},
+		Channels: channelsConf(conf),
+		Transfer: &models.TransferConf{
+			URL:    conf.Bot.Actions.URL,
+			Secret: conf.Bot.Actions.Secret,
+		},
+		Defaults:         addressDefaults(conf),
+		DeletedRetention: conf.Pinger.DeletedRetention,
 	}
 	services := services.NewServices(servicesDeps)
-	// handlers := transport.NewHandler(services)
-	socHandler := socket.NewHandler(&socket.Deps{Socket: mostClient.Socket, User: bot, Services: services})
+	metrics.Register(services.Ping)
+	router := command.NewRouter(services)
+	handlers := transport.NewHandler(services, router)
+	socHandler := socket.NewHandler(&socket.Deps{Socket: mostClient.Socket, User: bot, Router: router})
+	if err := services.Command.Register(); err != nil {
+		logger.Error("failed to register slash command.", logger.ErrAttr(err))
+	}
 
+	if err := reconcileAddresses(services.Address, conf); err != nil {
+		logger.Error("failed to reconcile addresses from config.", logger.ErrAttr(err))
+	}
+
+	services.Notifier.Start()
 	if err := services.Scheduler.Start(); err != nil {
 		log.Fatalf("failed to start scheduler. error: %s\n", err.Error())
 	}
+	services.Heartbeat.Start()
 
 	//* HTTP Server
-	// srv := server.NewServer(conf, handlers.Init(conf))
-	// go func() {
-	// 	if err := srv.Run(); !errors.Is(err, http.ErrServerClosed) {
-	// 		logger.Fatalf("error occurred while running http server: %s\n", err.Error())
-	// 	}
-	// }()
-	// logger.Infof("Application started on port: %s", conf.Http.Port)
+	srv := server.NewServer(conf, handlers.Init(conf))
+	go func() {
+		if err := srv.Run(); !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("error occurred while running http server: %s\n", err.Error())
+		}
+	}()
+	logger.Info("Application started", logger.StringAttr("port", conf.Http.Port))
+
+	if conf.Bot.Listen {
+		go func() {
+			// TODO при ошибке приложение падает
+			socHandler.Listen()
+		}()
+	}
 
+	reloader := &configReloader{path: configPath, services: services, conf: conf}
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	config.Watch(watchCtx, configPath, configCheckInterval, reloader.Reload)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
 	go func() {
-		// TODO при ошибке приложение падает
-		socHandler.Listen()
+		for range hup {
+			reloader.Reload()
+		}
 	}()
 
 	quit := make(chan os.Signal, 1)
@@ -98,17 +176,22 @@ func main() {
 
 	<-quit
 
+	stopWatch()
+	signal.Stop(hup)
+
+	services.Heartbeat.Stop()
 	if err := services.Scheduler.Stop(); err != nil {
 		logger.Error("failed to stop sending notification.", logger.ErrAttr(err))
 	}
+	services.Notifier.Stop()
 
-	// const timeout = 5 * time.Second
-	// ctx, shutdown := context.WithTimeout(context.Background(), timeout)
-	// defer shutdown()
+	const timeout = 5 * time.Second
+	ctx, shutdown := context.WithTimeout(context.Background(), timeout)
+	defer shutdown()
 
 	socHandler.Close()
 
-	// if err := srv.Stop(ctx); err != nil {
-	// 	logger.Errorf("failed to stop server. error: %s", err.Error())
-	// }
+	if err := srv.Stop(ctx); err != nil {
+		logger.Error("failed to stop server.", logger.ErrAttr(err))
+	}
 }
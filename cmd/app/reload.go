@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/config"
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/Alexander272/Pinger/pkg/logger"
+)
+
+// период проверки изменений файла конфигурации
+const configCheckInterval = 10 * time.Second
+
+// configReloader перечитывает файл конфигурации и применяет параметры, которые можно изменить без перезапуска:
+// уровень логирования, параметры проверки новых адресов, настройки планировщика по умолчанию, каналы команд
+// и адреса из конфигурации (вместе с их расписанием проверок). Остальные параметры применяются после перезапуска.
+// Некорректная конфигурация отклоняется целиком
+type configReloader struct {
+	mx       sync.Mutex
+	path     string
+	services *services.Services
+	// действующая конфигурация
+	conf *config.Config
+}
+
+func (r *configReloader) Reload() {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	conf, err := config.Init(r.path)
+	if err != nil {
+		r.reject(err)
+		return
+	}
+
+	prev := r.services.Address.Defaults()
+	r.services.Address.SetDefaults(addressDefaults(conf))
+	if err := reconcileAddresses(r.services.Address, conf); err != nil {
+		r.services.Address.SetDefaults(prev)
+		r.reject(err)
+		return
+	}
+
+	if err := logger.SetLevel(conf.LogLevel); err != nil {
+		logger.Error("failed to set log level.", logger.ErrAttr(err))
+	}
+	r.services.Scheduler.SetDefaults(schedulerDefaults(conf))
+	r.services.Channel.SetConf(channelsConf(conf))
+	r.conf = conf
+	logger.Info("config reloaded")
+}
+
+// reject сообщает об ошибке администраторам, продолжает действовать предыдущая конфигурация.
+// Сообщение отправляется в канал администраторов, если он задан, иначе каждому администратору в личные сообщения
+func (r *configReloader) reject(err error) {
+	logger.Error("failed to reload config.", logger.ErrAttr(err))
+
+	message := fmt.Sprintf("#### Ошибка.\nНе удалось применить файл конфигурации, продолжает действовать предыдущая.\n```\n%s\n```", err.Error())
+	if r.conf.Bot.AdminChannelId != "" {
+		if err := r.services.Post.Send(&models.Post{ChannelID: r.conf.Bot.AdminChannelId, Message: message}); err != nil {
+			logger.Error("failed to send config error.", logger.ErrAttr(err))
+		}
+		return
+	}
+	if len(r.conf.Bot.Admins) == 0 {
+		logger.Warn("admin channel and admins are not configured. config error is not sent")
+		return
+	}
+	for _, admin := range r.conf.Bot.Admins {
+		if err := r.services.Post.SendDirect(admin, &models.Post{Message: message}); err != nil {
+			logger.Error("failed to send config error.", logger.StringAttr("user", admin), logger.ErrAttr(err))
+		}
+	}
+}
+
+func schedulerDefaults(conf *config.Config) *models.Scheduler {
+	return &models.Scheduler{
+		Interval:     conf.Scheduler.Interval,
+		MaxCount:     conf.Scheduler.MaxCount,
+		CycleTimeout: conf.Scheduler.CycleTimeout,
+		StartDelay:   conf.Scheduler.StartDelay,
+	}
+}
+
+func addressDefaults(conf *config.Config) *models.AddressDefaults {
+	return &models.AddressDefaults{
+		MaxRTT:   conf.Pinger.Rtt,
+		Count:    conf.Pinger.Count,
+		Interval: conf.Pinger.Interval,
+		Timeout:  conf.Pinger.Timeout,
+	}
+}
+
+func channelsConf(conf *config.Config) *models.ChannelsConf {
+	channels := &models.ChannelsConf{Default: conf.Bot.ChannelId, Direct: conf.Bot.Direct}
+	for _, channel := range conf.Bot.Channels {
+		channels.Channels = append(channels.Channels, &models.ChannelConf{ID: channel.ID, Access: channel.Mode})
+	}
+	return channels
+}
+
+// reconcileAddresses приводит адреса в базе к списку из конфигурации. При ошибке адреса не изменяются
+func reconcileAddresses(addresses services.Address, conf *config.Config) error {
+	list := []*models.AddressConf{}
+	for _, group := range conf.Pinger.Addresses {
+		if group == nil {
+			continue
+		}
+		for _, a := range group.List {
+			address := &models.AddressConf{
+				IP:            a.Ip,
+				Name:          a.Name,
+				Groups:        models.ParseGroups(strings.Join(append(slices.Clone(group.Groups), a.Groups...), ",")),
+				MaxRTT:        a.Rtt,
+				Count:         a.Count,
+				Interval:      a.Interval,
+				Timeout:       a.Timeout,
+				Notification:  a.Notification,
+				Period:        a.Period,
+				TimeZone:      a.TimeZone,
+				SkipHolidays:  a.Holidays,
+				CheckInterval: a.Every,
+				Cron:          a.Schedule,
+				Enabled:       a.Enabled,
+			}
+			if address.CheckInterval == 0 {
+				address.CheckInterval = group.Interval
+			}
+			list = append(list, address)
+		}
+	}
+
+	plan, err := addresses.Reconcile(context.Background(), list)
+	if err != nil {
+		return err
+	}
+	if !plan.Empty() {
+		logger.Info("addresses reconciled from config", logger.IntAttr("create", len(plan.Create)),
+			logger.IntAttr("update", len(plan.Update)), logger.IntAttr("delete", len(plan.Delete)))
+	}
+	return nil
+}
@@ -1,22 +1,34 @@
 package config
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/robfig/cron/v3"
 )
 
 type (
 	Config struct {
-		Environment string       `yaml:"environment" env:"APP_ENV" env-default:"dev"`
-		LogLevel    string       `yaml:"log_level" env-default:"info"`
-		LogSource   bool         `yaml:"log_source" env-default:"false"`
-		Http        HttpConfig   `yaml:"http"`
-		Pinger      PingerConfig `yaml:"pinger"`
-		Bot         BotConfig    `yaml:"bot"`
-		Postgres    PostgresConfig
-		Redis       RedisConfig
+		Environment  string             `yaml:"environment" env:"APP_ENV" env-default:"dev"`
+		LogLevel     string             `yaml:"log_level" env-default:"info"`
+		LogSource    bool               `yaml:"log_source" env-default:"false"`
+		Http         HttpConfig         `yaml:"http"`
+		Limiter      LimiterConfig      `yaml:"limiter"`
+		Pinger       PingerConfig       `yaml:"pinger"`
+		Scheduler    SchedulerConfig    `yaml:"scheduler"`
+		Heartbeat    HeartbeatConfig    `yaml:"heartbeat"`
+		StatusPage   StatusPageConfig   `yaml:"status_page"`
+		Measurements MeasurementsConfig `yaml:"measurements"`
+		Bot          BotConfig          `yaml:"bot"`
+		Postgres     PostgresConfig
+		Redis        RedisConfig
 	}
 
 	HttpConfig struct {
@@ -27,29 +39,119 @@ type (
 		MaxHeaderBytes int           `yaml:"max_header_bytes" env-default:"1"`
 	}
 
+	LimiterConfig struct {
+		RPS   int           `yaml:"rps" env:"LIMITER_RPS" env-default:"10"`
+		Burst int           `yaml:"burst" env:"LIMITER_BURST" env-default:"20"`
+		TTL   time.Duration `yaml:"ttl" env:"LIMITER_TTL" env-default:"10m"`
+	}
+
+	// PingerConfig count, interval, timeout и rtt - параметры проверки новых адресов по умолчанию
 	PingerConfig struct {
-		Count     int                `yaml:"count" env-default:"5"`
-		Interval  time.Duration      `yaml:"interval" env-default:"0.1s"`
-		Timeout   time.Duration      `yaml:"timeout" env-default:"1s"`
-		IP        string             `yaml:"ip" env:"IP"`
-		Rtt       time.Duration      `yaml:"rtt" env-default:"50ms"`
+		Count    int           `yaml:"count" env-default:"5"`
+		Interval time.Duration `yaml:"interval" env-default:"0.1s"`
+		Timeout  time.Duration `yaml:"timeout" env-default:"1s"`
+		IP       string        `yaml:"ip" env:"IP"`
+		Rtt      time.Duration `yaml:"rtt" env-default:"50ms"`
+		// адреса, которые задаются только в конфигурации. Изменить или удалить их из чата нельзя
 		Addresses []*AddressesConfig `yaml:"addresses"`
+		// срок, в течении которого удаленный адрес можно восстановить, затем он удаляется окончательно
+		DeletedRetention time.Duration `yaml:"deleted_retention" env:"DELETED_RETENTION" env-default:"720h"`
+	}
+
+	SchedulerConfig struct {
+		Interval     time.Duration `yaml:"interval" env-default:"1m"`
+		MaxCount     int           `yaml:"max_count" env-default:"20"`
+		CycleTimeout time.Duration `yaml:"cycle_timeout" env-default:"50s"`
+		StartDelay   time.Duration `yaml:"start_delay" env-default:"1m"`
+	}
+
+	HeartbeatConfig struct {
+		Interval        time.Duration `yaml:"interval" env:"HEARTBEAT_INTERVAL" env-default:"1m"`
+		MissedIntervals int           `yaml:"missed_intervals" env:"HEARTBEAT_MISSED" env-default:"3"`
+		URL             string        `yaml:"url" env:"HEARTBEAT_URL"`
+		Timeout         time.Duration `yaml:"timeout" env:"HEARTBEAT_TIMEOUT" env-default:"10s"`
 	}
 
+	StatusPageConfig struct {
+		Enabled  bool          `yaml:"enabled" env:"STATUS_PAGE_ENABLED" env-default:"false"`
+		Title    string        `yaml:"title" env:"STATUS_PAGE_TITLE" env-default:"Статус сервисов"`
+		Days     int           `yaml:"days" env-default:"90"`
+		CacheTTL time.Duration `yaml:"cache_ttl" env-default:"1m"`
+	}
+
+	MeasurementsConfig struct {
+		// срок хранения результатов проверок для графиков
+		Retention time.Duration `yaml:"retention" env:"MEASUREMENTS_RETENTION" env-default:"720h"`
+	}
+
+	// AddressesConfig список адресов с общими интервалом проверок и группами
 	AddressesConfig struct {
 		Interval time.Duration `yaml:"interval"`
+		Groups   []string      `yaml:"groups"`
 		List     []*Address    `yaml:"list"`
 	}
+	// Address адрес из конфигурации. Названия параметров совпадают с флагами команды add
 	Address struct {
 		Ip   string        `yaml:"ip"`
 		Name string        `yaml:"name"`
 		Rtt  time.Duration `yaml:"rtt"`
+		// группы добавляются к группам списка
+		Groups   []string      `yaml:"groups"`
+		Count    int           `yaml:"count"`
+		Interval time.Duration `yaml:"interval"`
+		Timeout  time.Duration `yaml:"timeout"`
+		// количество уведомлений, не задано - значение по умолчанию
+		Notification *int   `yaml:"notification"`
+		Period       string `yaml:"period"`
+		TimeZone     string `yaml:"timezone"`
+		Holidays     bool   `yaml:"holidays"`
+		// интервал проверок адреса, по умолчанию интервал списка
+		Every    time.Duration `yaml:"every"`
+		Schedule string        `yaml:"schedule"`
+		// не задано - проверка включена
+		Enabled *bool `yaml:"enabled"`
 	}
 
 	BotConfig struct {
-		Server    string `env:"MOST_SERVER"`
-		Token     string `env:"MOST_TOKEN"`
-		ChannelId string `env:"MOST_CHANNEL_ID" yaml:"channel_id"`
+		Server    string   `env:"MOST_SERVER"`
+		Token     string   `env:"MOST_TOKEN"`
+		ChannelId string   `env:"MOST_CHANNEL_ID" yaml:"channel_id"`
+		Admins    []string `env:"MOST_ADMINS" yaml:"admins" env-separator:","`
+		// канал для служебных сообщений администраторам (например, об ошибке в файле конфигурации).
+		// Не задан - сообщения отправляются администраторам в личные сообщения
+		AdminChannelId string `env:"MOST_ADMIN_CHANNEL_ID" yaml:"admin_channel_id"`
+		// разбирать команды из сообщений в каналах. При использовании slash-команды можно отключить
+		Listen  bool          `env:"MOST_LISTEN" yaml:"listen" env-default:"true"`
+		Command CommandConfig `yaml:"command"`
+		Actions ActionsConfig `yaml:"actions"`
+		// каналы, в которых бот принимает команды. Если список пуст, команды принимаются только в канале уведомлений
+		Channels []ChannelConfig `yaml:"channels"`
+		// принимать команды в личных сообщениях боту
+		Direct bool `env:"MOST_DIRECT" yaml:"direct" env-default:"true"`
+	}
+
+	ChannelConfig struct {
+		ID string `yaml:"id"`
+		// read - только команды просмотра, full - все команды (по умолчанию)
+		Mode string `yaml:"mode"`
+	}
+
+	ActionsConfig struct {
+		// адрес, на который Mattermost отправляет нажатия кнопок (https://<адрес бота>/api/mattermost/actions).
+		// Без него уведомления отправляются без кнопок
+		URL string `yaml:"url" env:"MOST_ACTIONS_URL"`
+		// адрес, на который Mattermost отправляет заполненные формы (https://<адрес бота>/api/mattermost/dialogs)
+		DialogURL string `yaml:"dialog_url" env:"MOST_DIALOG_URL"`
+		// ключ подписи кнопок, по умолчанию используется токен бота
+		Secret  string        `env:"MOST_ACTIONS_SECRET"`
+		Silence time.Duration `yaml:"silence" env-default:"1h"`
+	}
+
+	CommandConfig struct {
+		Trigger string `yaml:"trigger" env:"MOST_COMMAND_TRIGGER" env-default:"pinger"`
+		Token   string `env:"MOST_COMMAND_TOKEN"`
+		TeamID  string `yaml:"team_id" env:"MOST_TEAM_ID"`
+		URL     string `yaml:"url" env:"MOST_COMMAND_URL"`
 	}
 
 	PostgresConfig struct {
@@ -75,27 +177,128 @@ func Init(path string) (*Config, error) {
 	if err := cleanenv.ReadConfig(path, &conf); err != nil {
 		return nil, fmt.Errorf("failed to read config file. error: %w", err)
 	}
-
-	// if err := cleanenv.ReadEnv(&conf); err != nil {
-	// 	return nil, fmt.Errorf("failed to read env file. error: %w", err)
-	// }
+	if err := conf.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config. error: %w", err)
+	}
 
 	return &conf, nil
 }
 
-// TODO дописать получение новых данных для конфига
-// func (c *Config) Update() error {
-// 	out, err := yaml.Marshal(&c)
-// 	if err != nil {
-// 		// logger.Errorf("failed marshal yaml. error: %s", err.Error())
-// 		return fmt.Errorf("failed marshal yaml. error: %w", err)
-// 	}
-
-// 	err = os.WriteFile("modified.yaml", out, 0777)
-// 	if err != nil {
-// 		logger.Errorf("Problem updating file: %s", err)
-// 		return fmt.Errorf("problem updating file: %w", err)
-// 	}
-
-// 	return nil
-// }
+// Validate проверяет параметры, которые можно изменить без перезапуска, в том числе адреса. Некорректный файл не применяется
+func (c *Config) Validate() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return fmt.Errorf("unknown log_level %q", c.LogLevel)
+	}
+
+	if c.Pinger.Count < 1 {
+		return errors.New("pinger.count must be at least 1")
+	}
+	if c.Pinger.Interval <= 0 || c.Pinger.Timeout <= 0 {
+		return errors.New("pinger.interval and pinger.timeout must be positive")
+	}
+	if c.Pinger.Rtt < 0 {
+		return errors.New("pinger.rtt must not be negative")
+	}
+	if c.Scheduler.Interval < time.Second {
+		return errors.New("scheduler.interval must be at least 1s")
+	}
+	if c.Scheduler.MaxCount < 1 {
+		return errors.New("scheduler.max_count must be at least 1")
+	}
+	if c.Scheduler.CycleTimeout < 0 || c.Scheduler.StartDelay < 0 {
+		return errors.New("scheduler.cycle_timeout and scheduler.start_delay must not be negative")
+	}
+	for i, group := range c.Pinger.Addresses {
+		if group == nil {
+			continue
+		}
+		if err := validateEvery(group.Interval); err != nil {
+			return fmt.Errorf("pinger.addresses[%d]: interval %w", i, err)
+		}
+		for j, address := range group.List {
+			if address == nil || address.Ip == "" {
+				return fmt.Errorf("pinger.addresses[%d].list[%d]: ip is required", i, j)
+			}
+			if err := address.Validate(); err != nil {
+				return fmt.Errorf("pinger.addresses[%d].list[%d]: %w", i, j, err)
+			}
+		}
+	}
+
+	for i, channel := range c.Bot.Channels {
+		if channel.ID == "" {
+			return fmt.Errorf("bot.channels[%d]: id is required", i)
+		}
+		if channel.Mode != "" && channel.Mode != "read" && channel.Mode != "full" {
+			return fmt.Errorf("bot.channels[%d]: unknown mode %q, expected read or full", i, channel.Mode)
+		}
+	}
+	return nil
+}
+
+// Validate проверяет параметры адреса, которые иначе попадут в планировщик и проверку без изменений.
+// Нулевые значения означают значения по умолчанию
+func (a *Address) Validate() error {
+	if a.Rtt < 0 || a.Count < 0 || a.Interval < 0 || a.Timeout < 0 {
+		return errors.New("rtt, count, interval and timeout must not be negative")
+	}
+	if a.Notification != nil && *a.Notification < 0 {
+		return errors.New("notification must not be negative")
+	}
+	if err := validateEvery(a.Every); err != nil {
+		return fmt.Errorf("every %w", err)
+	}
+	if _, err := time.LoadLocation(a.TimeZone); err != nil {
+		return fmt.Errorf("unknown timezone %q", a.TimeZone)
+	}
+	if a.Schedule != "" {
+		if _, err := cron.ParseStandard(a.Schedule); err != nil {
+			return fmt.Errorf("invalid schedule %q", a.Schedule)
+		}
+	}
+	return nil
+}
+
+// validateEvery интервал проверок адреса: 0 - по умолчанию, иначе не меньше секунды, как и общий интервал
+func validateEvery(every time.Duration) error {
+	if every != 0 && every < time.Second {
+		return errors.New("must be 0 or at least 1s")
+	}
+	return nil
+}
+
+// Watch проверяет файл конфигурации с заданным периодом и вызывает onChange, когда меняется его содержимое.
+// Сравнивается содержимое, а не время изменения, чтобы замечать замену файла (например, ConfigMap в Kubernetes)
+func Watch(ctx context.Context, path string, interval time.Duration, onChange func()) {
+	hash := fileHash(path)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				current := fileHash(path)
+				if current == "" || current == hash {
+					continue
+				}
+				hash = current
+				onChange()
+			}
+		}
+	}()
+}
+
+// fileHash возвращает хэш содержимого файла или пустую строку, если файл не удалось прочитать
+func fileHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Source источник данных о проверках (сервис пинга)
+type Source interface {
+	Stats() *models.CheckStats
+	States() []*models.CheckState
+}
+
+// Collector отдает состояние адресов и пула воркеров на момент запроса метрик,
+// поэтому данные удаленных адресов пропадают без дополнительной очистки
+type Collector struct {
+	source Source
+
+	up          *prometheus.Desc
+	rttMin      *prometheus.Desc
+	rttAvg      *prometheus.Desc
+	rttMax      *prometheus.Desc
+	jitter      *prometheus.Desc
+	packetLoss  *prometheus.Desc
+	sinceChange *prometheus.Desc
+	lastCheck   *prometheus.Desc
+
+	workers    *prometheus.Desc
+	inFlight   *prometheus.Desc
+	queueDepth *prometheus.Desc
+	skipped    *prometheus.Desc
+	dropped    *prometheus.Desc
+}
+
+func NewCollector(source Source) *Collector {
+	address := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "address", name), help, addressLabels, nil)
+	}
+	checks := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "checks", name), help, nil, nil)
+	}
+
+	return &Collector{
+		source: source,
+
+		up:          address("up", "Whether the address answered the last check (1) or not (0)."),
+		rttMin:      address("rtt_min_seconds", "Minimum round-trip time of the last check."),
+		rttAvg:      address("rtt_avg_seconds", "Average round-trip time of the last check."),
+		rttMax:      address("rtt_max_seconds", "Maximum round-trip time of the last check."),
+		jitter:      address("jitter_seconds", "Standard deviation of round-trip times of the last check."),
+		packetLoss:  address("packet_loss_ratio", "Packet loss of the last check from 0 to 1."),
+		sinceChange: address("state_change_age_seconds", "Time since the availability of the address last changed."),
+		lastCheck:   address("last_check_timestamp_seconds", "Unix time of the last check."),
+
+		workers:    checks("workers", "Number of workers checking addresses concurrently."),
+		inFlight:   checks("in_flight", "Number of checks currently running."),
+		queueDepth: checks("queue_depth", "Number of checks waiting for a free worker."),
+		skipped:    checks("skipped_total", "Number of checks skipped because the previous check of the address was still running."),
+		dropped:    checks("dropped_total", "Number of checks interrupted or dropped by the check deadline."),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		c.up, c.rttMin, c.rttAvg, c.rttMax, c.jitter, c.packetLoss, c.sinceChange, c.lastCheck,
+		c.workers, c.inFlight, c.queueDepth, c.skipped, c.dropped,
+	} {
+		ch <- desc
+	}
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	now := time.Now()
+	for _, state := range c.source.States() {
+		labels := AddressLabels(state.IP, state.Name, state.Groups)
+		up := 1.0
+		if state.IsFailed {
+			up = 0
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, up, labels...)
+		ch <- prometheus.MustNewConstMetric(c.rttMin, prometheus.GaugeValue, state.MinRtt.Seconds(), labels...)
+		ch <- prometheus.MustNewConstMetric(c.rttAvg, prometheus.GaugeValue, state.AvgRtt.Seconds(), labels...)
+		ch <- prometheus.MustNewConstMetric(c.rttMax, prometheus.GaugeValue, state.MaxRtt.Seconds(), labels...)
+		ch <- prometheus.MustNewConstMetric(c.jitter, prometheus.GaugeValue, state.StdDevRtt.Seconds(), labels...)
+		ch <- prometheus.MustNewConstMetric(c.packetLoss, prometheus.GaugeValue, state.PacketLoss/100, labels...)
+		ch <- prometheus.MustNewConstMetric(c.sinceChange, prometheus.GaugeValue, now.Sub(state.ChangedAt).Seconds(), labels...)
+		ch <- prometheus.MustNewConstMetric(c.lastCheck, prometheus.GaugeValue, float64(state.CheckedAt.Unix()), labels...)
+	}
+
+	stats := c.source.Stats()
+	ch <- prometheus.MustNewConstMetric(c.workers, prometheus.GaugeValue, float64(stats.Workers))
+	ch <- prometheus.MustNewConstMetric(c.inFlight, prometheus.GaugeValue, float64(stats.InFlight))
+	ch <- prometheus.MustNewConstMetric(c.queueDepth, prometheus.GaugeValue, float64(stats.QueueDepth))
+	ch <- prometheus.MustNewConstMetric(c.skipped, prometheus.CounterValue, float64(stats.Skipped))
+	ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(stats.Dropped))
+}
@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pinger"
+
+// метки метрик адреса
+var addressLabels = []string{"ip", "name", "groups"}
+
+var (
+	CheckDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "check",
+		Name:      "duration_seconds",
+		Help:      "Duration of a single address check including the time spent in the worker queue.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+	})
+	Rtt = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "address",
+		Name:      "rtt_seconds",
+		Help:      "Average round-trip time of address checks.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, addressLabels)
+
+	MattermostErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mattermost",
+		Name:      "send_errors_total",
+		Help:      "Number of messages that failed to be sent to Mattermost.",
+	})
+	HeartbeatErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "heartbeat",
+		Name:      "errors_total",
+		Help:      "Number of heartbeats that failed to be pushed to the external URL.",
+	})
+	DBErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "errors_total",
+		Help:      "Number of failed database queries.",
+	})
+)
+
+// AddressLabels возвращает значения меток адреса
+func AddressLabels(ip, name string, groups []string) []string {
+	return []string{ip, name, strings.Join(groups, ",")}
+}
+
+// DeleteAddress удаляет метрики адреса, чтобы не отдавать данные по удаленным или переименованным адресам
+func DeleteAddress(ip string) {
+	Rtt.DeletePartialMatch(prometheus.Labels{"ip": ip})
+}
+
+// Register регистрирует сборщик состояния проверок
+func Register(source Source) {
+	prometheus.MustRegister(NewCollector(source))
+}
+
+// Handler отдает метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE IF EXISTS public.addresses
+    ADD COLUMN IF NOT EXISTS check_interval integer DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cron text COLLATE pg_catalog."default" DEFAULT ''::text;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE IF EXISTS public.addresses
+    DROP COLUMN IF EXISTS check_interval,
+    DROP COLUMN IF EXISTS cron;
+-- +goose StatementEnd
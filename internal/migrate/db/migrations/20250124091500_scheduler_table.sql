@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.scheduler
+(
+    id uuid NOT NULL,
+    interval integer DEFAULT 60,
+    max_count integer DEFAULT 20,
+    cycle_timeout integer DEFAULT 50,
+    start_delay integer DEFAULT 60,
+    quiet_start integer DEFAULT 0,
+    quiet_end integer DEFAULT 0,
+    updated_at timestamp with time zone DEFAULT now(),
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT scheduler_pkey PRIMARY KEY (id)
+)
+TABLESPACE pg_default;
+
+ALTER TABLE IF EXISTS public.scheduler
+    OWNER to postgres;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.scheduler;
+-- +goose StatementEnd
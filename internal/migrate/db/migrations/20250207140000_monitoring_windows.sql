@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE IF EXISTS public.addresses
+    ADD COLUMN IF NOT EXISTS windows jsonb DEFAULT '[]'::jsonb,
+    ADD COLUMN IF NOT EXISTS time_zone text COLLATE pg_catalog."default" DEFAULT ''::text,
+    ADD COLUMN IF NOT EXISTS skip_holidays boolean DEFAULT false;
+
+UPDATE public.addresses
+    SET windows = jsonb_build_array(jsonb_build_object('weekdays', 127, 'start', period_start, 'end', period_end))
+    WHERE period_start <> 0 AND period_end <> 0;
+
+ALTER TABLE IF EXISTS public.addresses
+    DROP COLUMN IF EXISTS period_start,
+    DROP COLUMN IF EXISTS period_end;
+
+CREATE TABLE IF NOT EXISTS public.holidays
+(
+    date date NOT NULL,
+    name text COLLATE pg_catalog."default" DEFAULT ''::text,
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT holidays_pkey PRIMARY KEY (date)
+)
+TABLESPACE pg_default;
+
+ALTER TABLE IF EXISTS public.holidays
+    OWNER to postgres;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.holidays;
+
+ALTER TABLE IF EXISTS public.addresses
+    ADD COLUMN IF NOT EXISTS period_start integer DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS period_end integer DEFAULT 0;
+
+UPDATE public.addresses
+    SET period_start = (windows->0->>'start')::integer, period_end = (windows->0->>'end')::integer
+    WHERE jsonb_array_length(windows) > 0;
+
+ALTER TABLE IF EXISTS public.addresses
+    DROP COLUMN IF EXISTS windows,
+    DROP COLUMN IF EXISTS time_zone,
+    DROP COLUMN IF EXISTS skip_holidays;
+-- +goose StatementEnd
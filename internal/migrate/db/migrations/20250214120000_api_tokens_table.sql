@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.api_tokens
+(
+    id uuid NOT NULL,
+    name text COLLATE pg_catalog."default" NOT NULL,
+    token_hash text COLLATE pg_catalog."default" NOT NULL,
+    scope text COLLATE pg_catalog."default" NOT NULL DEFAULT 'read'::text,
+    created_by text COLLATE pg_catalog."default" DEFAULT ''::text,
+    last_used_at timestamp with time zone,
+    revoked_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT api_tokens_pkey PRIMARY KEY (id),
+    UNIQUE(token_hash)
+)
+TABLESPACE pg_default;
+
+CREATE UNIQUE INDEX IF NOT EXISTS api_tokens_name_idx ON public.api_tokens (name) WHERE revoked_at IS NULL;
+
+ALTER TABLE IF EXISTS public.api_tokens
+    OWNER to postgres;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.api_tokens;
+-- +goose StatementEnd
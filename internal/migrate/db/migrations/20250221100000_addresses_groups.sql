@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE IF EXISTS public.addresses
+    ADD COLUMN IF NOT EXISTS groups text[] NOT NULL DEFAULT '{}'::text[];
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE IF EXISTS public.addresses
+    DROP COLUMN IF EXISTS groups;
+-- +goose StatementEnd
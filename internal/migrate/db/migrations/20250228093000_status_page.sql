@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.status_components
+(
+    id uuid NOT NULL,
+    name text COLLATE pg_catalog."default" NOT NULL,
+    ip text COLLATE pg_catalog."default" NOT NULL DEFAULT ''::text,
+    group_name text COLLATE pg_catalog."default" NOT NULL DEFAULT ''::text,
+    position integer NOT NULL DEFAULT 0,
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT status_components_pkey PRIMARY KEY (id),
+    UNIQUE(name)
+)
+TABLESPACE pg_default;
+
+-- номер инцидента вводится в чате, поэтому вместо uuid используется последовательность
+CREATE TABLE IF NOT EXISTS public.incidents
+(
+    id integer GENERATED BY DEFAULT AS IDENTITY,
+    title text COLLATE pg_catalog."default" NOT NULL,
+    status text COLLATE pg_catalog."default" NOT NULL DEFAULT 'investigating'::text,
+    components text[] NOT NULL DEFAULT '{}'::text[],
+    created_by text COLLATE pg_catalog."default" DEFAULT ''::text,
+    resolved_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT incidents_pkey PRIMARY KEY (id)
+)
+TABLESPACE pg_default;
+
+CREATE TABLE IF NOT EXISTS public.incident_updates
+(
+    id uuid NOT NULL,
+    incident_id integer NOT NULL REFERENCES public.incidents (id) ON DELETE CASCADE,
+    status text COLLATE pg_catalog."default" NOT NULL,
+    message text COLLATE pg_catalog."default" NOT NULL DEFAULT ''::text,
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT incident_updates_pkey PRIMARY KEY (id)
+)
+TABLESPACE pg_default;
+
+ALTER TABLE IF EXISTS public.status_components
+    OWNER to postgres;
+ALTER TABLE IF EXISTS public.incidents
+    OWNER to postgres;
+ALTER TABLE IF EXISTS public.incident_updates
+    OWNER to postgres;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.incident_updates;
+DROP TABLE IF EXISTS public.incidents;
+DROP TABLE IF EXISTS public.status_components;
+-- +goose StatementEnd
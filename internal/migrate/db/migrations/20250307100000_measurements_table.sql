@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.measurements
+(
+    id bigint NOT NULL GENERATED ALWAYS AS IDENTITY,
+    ip text COLLATE pg_catalog."default" NOT NULL,
+    avg_rtt bigint NOT NULL DEFAULT 0,
+    packet_loss double precision NOT NULL DEFAULT 0,
+    is_failed boolean NOT NULL DEFAULT false,
+    checked_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT measurements_pkey PRIMARY KEY (id)
+)
+TABLESPACE pg_default;
+
+CREATE INDEX IF NOT EXISTS measurements_ip_checked_at_idx ON public.measurements (ip, checked_at);
+
+ALTER TABLE IF EXISTS public.measurements
+    OWNER to postgres;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.measurements;
+-- +goose StatementEnd
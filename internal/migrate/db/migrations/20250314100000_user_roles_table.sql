@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.user_roles
+(
+    user_id text COLLATE pg_catalog."default" NOT NULL,
+    username text COLLATE pg_catalog."default" NOT NULL DEFAULT ''::text,
+    role text COLLATE pg_catalog."default" NOT NULL,
+    granted_by text COLLATE pg_catalog."default" DEFAULT ''::text,
+    created_at timestamp with time zone DEFAULT now(),
+    CONSTRAINT user_roles_pkey PRIMARY KEY (user_id)
+)
+TABLESPACE pg_default;
+
+ALTER TABLE IF EXISTS public.user_roles
+    OWNER to postgres;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.user_roles;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE IF EXISTS public.addresses
+    ADD COLUMN IF NOT EXISTS managed boolean NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE IF EXISTS public.addresses
+    DROP COLUMN IF EXISTS managed;
+-- +goose StatementEnd
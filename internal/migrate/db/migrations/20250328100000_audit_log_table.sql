@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS public.audit_log
+(
+    id bigint NOT NULL GENERATED ALWAYS AS IDENTITY,
+    ip text COLLATE pg_catalog."default" NOT NULL,
+    action text COLLATE pg_catalog."default" NOT NULL,
+    user_id text COLLATE pg_catalog."default" NOT NULL DEFAULT ''::text,
+    username text COLLATE pg_catalog."default" NOT NULL DEFAULT ''::text,
+    channel_id text COLLATE pg_catalog."default" NOT NULL DEFAULT ''::text,
+    command text COLLATE pg_catalog."default" NOT NULL DEFAULT ''::text,
+    before jsonb,
+    after jsonb,
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT audit_log_pkey PRIMARY KEY (id)
+)
+TABLESPACE pg_default;
+
+CREATE INDEX IF NOT EXISTS audit_log_ip_created_at_idx ON public.audit_log (ip, created_at);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON public.audit_log (created_at);
+
+ALTER TABLE IF EXISTS public.audit_log
+    OWNER to postgres;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS public.audit_log;
+-- +goose StatementEnd
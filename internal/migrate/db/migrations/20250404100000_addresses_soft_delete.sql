@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE IF EXISTS public.addresses
+    ADD COLUMN IF NOT EXISTS deleted_at timestamp with time zone;
+
+CREATE INDEX IF NOT EXISTS addresses_deleted_at_idx ON public.addresses (deleted_at) WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE IF EXISTS public.audit_log
+    ADD COLUMN IF NOT EXISTS undone boolean NOT NULL DEFAULT false;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE IF EXISTS public.audit_log
+    DROP COLUMN IF EXISTS undone;
+
+DELETE FROM public.addresses WHERE deleted_at IS NOT NULL;
+DROP INDEX IF EXISTS public.addresses_deleted_at_idx;
+ALTER TABLE IF EXISTS public.addresses
+    DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
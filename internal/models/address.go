@@ -1,34 +1,189 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
 
 type Address struct {
 	ID                string        `json:"id" db:"id"`
 	IP                string        `json:"ip" db:"ip"`
 	Name              string        `json:"name" db:"name"`
+	Groups            []string      `json:"groups" db:"groups"` // Группы адреса, используются для фильтрации и меток метрик
 	MaxRTT            time.Duration `json:"maxRtt" db:"max_rtt"`
-	Interval          time.Duration `json:"interval" db:"interval"`           // Интервал - время ожидания между отправкой каждого пакета.
-	Count             int           `json:"count" db:"count"`                 // Count указывает pinger на остановку после отправки (и получения) Count эхо-пакетов
-	Timeout           time.Duration `json:"timeout" db:"timeout"`             // Timeout задает таймаут до завершения ping
-	NotificationCount int           `json:"notificationCount" db:"not_count"` // Количество уведомлений
-	PeriodStart       time.Duration `json:"periodStart" db:"period_start"`
-	PeriodEnd         time.Duration `json:"periodEnd" db:"period_end"`
+	Interval          time.Duration `json:"interval" db:"interval"`            // Интервал - время ожидания между отправкой каждого пакета.
+	Count             int           `json:"count" db:"count"`                  // Count указывает pinger на остановку после отправки (и получения) Count эхо-пакетов
+	Timeout           time.Duration `json:"timeout" db:"timeout"`              // Timeout задает таймаут до завершения ping
+	NotificationCount int           `json:"notificationCount" db:"not_count"`  // Количество уведомлений
+	Windows           []*Window     `json:"windows" db:"windows"`              // Периоды, в течении которых выполняются проверки (пусто - всегда)
+	TimeZone          string        `json:"timeZone" db:"time_zone"`           // Часовой пояс IANA, в котором заданы периоды (пусто - часовой пояс сервера)
+	SkipHolidays      bool          `json:"skipHolidays" db:"skip_holidays"`   // Не проверять адрес в праздничные дни
+	CheckInterval     time.Duration `json:"checkInterval" db:"check_interval"` // Интервал между проверками адреса (0 - интервал по умолчанию)
+	Cron              string        `json:"cron" db:"cron"`                    // Расписание проверок в формате cron, используется вместо интервала
 	Enabled           bool          `json:"enabled" db:"enabled"`
+	Managed           bool          `json:"managed" db:"managed"` // Адрес задан в файле конфигурации, изменения из чата и API запрещены
 	Created           time.Time     `json:"created" db:"created_at"`
+	Deleted           *time.Time    `json:"deleted,omitempty" db:"deleted_at"` // Время удаления, удаленный адрес можно восстановить до окончательной очистки
 }
 
 type AddressDTO struct {
 	ID                string         `json:"id" db:"id"`
 	IP                string         `json:"ip" db:"ip"`
 	Name              *string        `json:"name" db:"name"`
+	Groups            []string       `json:"groups" db:"groups"` // nil - не изменять
 	MaxRTT            *time.Duration `json:"maxRtt" db:"max_rtt"`
 	Interval          *time.Duration `json:"interval" db:"interval"`
 	Count             *int           `json:"count" db:"count"`
 	Timeout           *time.Duration `json:"timeout" db:"timeout"`
 	NotificationCount *int           `json:"notificationCount" db:"not_count"`
-	PeriodStart       *time.Duration `json:"periodStart" db:"period_start"`
-	PeriodEnd         *time.Duration `json:"periodEnd" db:"period_end"`
+	Windows           []*Window      `json:"windows" db:"windows"` // nil - не изменять, пустой список - без ограничений
+	TimeZone          *string        `json:"timeZone" db:"time_zone"`
+	SkipHolidays      *bool          `json:"skipHolidays" db:"skip_holidays"`
+	CheckInterval     *time.Duration `json:"checkInterval" db:"check_interval"`
+	Cron              *string        `json:"cron" db:"cron"`
 	Enabled           *bool          `json:"enabled" db:"enabled"`
+	Managed           *bool          `json:"-" db:"managed"` // nil - не изменять
+}
+
+// Fill заполняет не заданные поля значениями из текущих данных адреса
+func (dto *AddressDTO) Fill(data *Address) {
+	dto.ID = data.ID
+	if dto.Name == nil {
+		dto.Name = &data.Name
+	}
+	if dto.Groups == nil {
+		dto.Groups = data.Groups
+	}
+	if dto.MaxRTT == nil {
+		dto.MaxRTT = &data.MaxRTT
+	}
+	if dto.NotificationCount == nil {
+		dto.NotificationCount = &data.NotificationCount
+	}
+	if dto.Windows == nil {
+		dto.Windows = data.Windows
+	}
+	if dto.TimeZone == nil {
+		dto.TimeZone = &data.TimeZone
+	}
+	if dto.SkipHolidays == nil {
+		dto.SkipHolidays = &data.SkipHolidays
+	}
+	if dto.CheckInterval == nil {
+		dto.CheckInterval = &data.CheckInterval
+	}
+	if dto.Cron == nil {
+		dto.Cron = &data.Cron
+	}
+	if dto.Interval == nil {
+		dto.Interval = &data.Interval
+	}
+	if dto.Count == nil {
+		dto.Count = &data.Count
+	}
+	if dto.Timeout == nil {
+		dto.Timeout = &data.Timeout
+	}
+	if dto.Enabled == nil {
+		dto.Enabled = &data.Enabled
+	}
+}
+
+// SetDefaults заполняет не заданные параметры проверки значениями по умолчанию
+func (dto *AddressDTO) SetDefaults(defaults *AddressDefaults) {
+	if defaults == nil {
+		return
+	}
+	if dto.MaxRTT == nil && defaults.MaxRTT > 0 {
+		value := defaults.MaxRTT
+		dto.MaxRTT = &value
+	}
+	if dto.Count == nil && defaults.Count > 0 {
+		value := defaults.Count
+		dto.Count = &value
+	}
+	if dto.Interval == nil && defaults.Interval > 0 {
+		value := defaults.Interval
+		dto.Interval = &value
+	}
+	if dto.Timeout == nil && defaults.Timeout > 0 {
+		value := defaults.Timeout
+		dto.Timeout = &value
+	}
+}
+
+// Validate проверяет заданные параметры адреса. Правила совпадают с проверками команд и формы добавления адреса
+func (dto *AddressDTO) Validate() error {
+	if dto.MaxRTT != nil && *dto.MaxRTT < 0 {
+		return fmt.Errorf("%w: max rtt must not be negative", ErrInvalidAddress)
+	}
+	if dto.Count != nil && *dto.Count < 1 {
+		return fmt.Errorf("%w: count must be at least 1", ErrInvalidAddress)
+	}
+	if dto.Interval != nil && *dto.Interval <= 0 {
+		return fmt.Errorf("%w: interval must be positive", ErrInvalidAddress)
+	}
+	if dto.Timeout != nil && *dto.Timeout <= 0 {
+		return fmt.Errorf("%w: timeout must be positive", ErrInvalidAddress)
+	}
+	if dto.NotificationCount != nil && *dto.NotificationCount < 0 {
+		return fmt.Errorf("%w: notification count must not be negative", ErrInvalidAddress)
+	}
+	if dto.CheckInterval != nil && *dto.CheckInterval != 0 && *dto.CheckInterval < MinCheckInterval {
+		return fmt.Errorf("%w: check interval must be 0 or at least %s", ErrInvalidAddress, MinCheckInterval)
+	}
+	if dto.TimeZone != nil {
+		if _, err := time.LoadLocation(*dto.TimeZone); err != nil {
+			return fmt.Errorf("%w: unknown time zone %q", ErrInvalidAddress, *dto.TimeZone)
+		}
+	}
+	if dto.Cron != nil && *dto.Cron != "" {
+		if _, err := cron.ParseStandard(*dto.Cron); err != nil {
+			return fmt.Errorf("%w: invalid cron %q", ErrInvalidAddress, *dto.Cron)
+		}
+	}
+	return nil
+}
+
+// AddressDefaults параметры проверки новых адресов, если они не указаны при добавлении. Нулевые значения не используются
+type AddressDefaults struct {
+	MaxRTT   time.Duration
+	Count    int
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// AddressConf адрес из файла конфигурации. Не заданные параметры проверки берутся из значений по умолчанию
+type AddressConf struct {
+	IP            string
+	Name          string
+	Groups        []string
+	MaxRTT        time.Duration
+	Count         int
+	Interval      time.Duration
+	Timeout       time.Duration
+	Notification  *int
+	Period        string
+	TimeZone      string
+	SkipHolidays  bool
+	CheckInterval time.Duration
+	Cron          string
+	Enabled       *bool
+}
+
+// ParseGroups разбирает список групп, разделенных запятыми
+func ParseGroups(value string) []string {
+	groups := []string{}
+	for _, group := range strings.Split(value, ",") {
+		if group = strings.TrimSpace(group); group != "" && !slices.Contains(groups, group) {
+			groups = append(groups, group)
+		}
+	}
+	return groups
 }
 
 type PingStatistic struct {
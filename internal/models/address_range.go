@@ -0,0 +1,105 @@
+package models
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// MaxBulkAddresses ограничивает количество адресов в одной команде, чтобы случайно не затронуть большую подсеть
+const MaxBulkAddresses = 256
+
+// Результат операции над адресом при массовом изменении
+const (
+	BulkDone     = "done"
+	BulkExists   = "exists"
+	BulkNotFound = "not_found"
+	BulkManaged  = "managed"
+	BulkChanged  = "changed" // адрес изменен после отменяемой команды
+)
+
+type BulkResult struct {
+	IP     string `json:"ip"`
+	Status string `json:"status"`
+}
+
+// ExpandAddresses разворачивает список адресов через запятую. Элемент списка - IP адрес, подсеть (10.0.0.0/24)
+// или диапазон (10.0.0.1-10.0.0.20). Для подсетей IPv4 адрес сети и широковещательный адрес пропускаются
+func ExpandAddresses(value string) ([]string, error) {
+	addresses := []string{}
+	seen := make(map[netip.Addr]struct{})
+
+	add := func(addr netip.Addr) error {
+		if _, ok := seen[addr]; ok {
+			return nil
+		}
+		if len(addresses) >= MaxBulkAddresses {
+			return ErrTooManyAddresses
+		}
+		seen[addr] = struct{}{}
+		addresses = append(addresses, addr.String())
+		return nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		var first, last netip.Addr
+		switch {
+		case strings.Contains(entry, "/"):
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, entry)
+			}
+			prefix = prefix.Masked()
+			first, last = prefix.Addr(), lastAddr(prefix)
+			if first.Is4() && prefix.Bits() <= 30 {
+				first, last = first.Next(), last.Prev()
+			}
+
+		case strings.Contains(entry, "-"):
+			from, to, _ := strings.Cut(entry, "-")
+			var err error
+			if first, err = netip.ParseAddr(strings.TrimSpace(from)); err != nil {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, entry)
+			}
+			if last, err = netip.ParseAddr(strings.TrimSpace(to)); err != nil {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, entry)
+			}
+			if first.Is4() != last.Is4() || last.Less(first) {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, entry)
+			}
+
+		default:
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, entry)
+			}
+			first, last = addr, addr
+		}
+
+		for addr := first; addr.IsValid() && !last.Less(addr); addr = addr.Next() {
+			if err := add(addr); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(addresses) == 0 {
+		return nil, ErrInvalidAddress
+	}
+	return addresses, nil
+}
+
+// lastAddr возвращает последний адрес подсети
+func lastAddr(prefix netip.Prefix) netip.Addr {
+	bytes := prefix.Addr().AsSlice()
+	for i := prefix.Bits(); i < len(bytes)*8; i++ {
+		bytes[i/8] |= 1 << (7 - i%8)
+	}
+	addr, _ := netip.AddrFromSlice(bytes)
+	return addr
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Форматы файлов импорта и экспорта адресов
+const (
+	FormatCSV  = "csv"
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+var TransferFormats = []string{FormatCSV, FormatYAML, FormatJSON}
+
+const (
+	ActionImportApply  = "import_apply"  // применить импорт
+	ActionImportCancel = "import_cancel" // отменить импорт
+)
+
+// TransferConf настройки кнопок подтверждения импорта
+type TransferConf struct {
+	// адрес, на который Mattermost отправляет нажатия кнопок. Пустой адрес - подтверждение только командой
+	URL    string
+	Secret string
+}
+
+// AddressColumns колонки файла адресов. Названия совпадают с флагами команды add
+var AddressColumns = []string{
+	"ip", "name", "groups", "rtt", "count", "interval", "timeout", "notification",
+	"period", "timezone", "holidays", "every", "schedule", "enabled",
+}
+
+// AddressRecord адрес в файле экспорта. Время пинга, интервал и таймаут в миллисекундах, интервал проверок в секундах,
+// периоды в формате команды add
+type AddressRecord struct {
+	IP           string   `json:"ip" yaml:"ip"`
+	Name         string   `json:"name" yaml:"name"`
+	Groups       []string `json:"groups" yaml:"groups"`
+	MaxRTT       int64    `json:"rtt" yaml:"rtt"`
+	Count        int      `json:"count" yaml:"count"`
+	Interval     int64    `json:"interval" yaml:"interval"`
+	Timeout      int64    `json:"timeout" yaml:"timeout"`
+	Notification int      `json:"notification" yaml:"notification"`
+	Period       string   `json:"period" yaml:"period"`
+	TimeZone     string   `json:"timezone" yaml:"timezone"`
+	SkipHolidays bool     `json:"holidays" yaml:"holidays"`
+	Every        int64    `json:"every" yaml:"every"`
+	Schedule     string   `json:"schedule" yaml:"schedule"`
+	Enabled      bool     `json:"enabled" yaml:"enabled"`
+}
+
+func NewAddressRecord(a *Address) *AddressRecord {
+	groups := a.Groups
+	if groups == nil {
+		groups = []string{}
+	}
+	return &AddressRecord{
+		IP:           a.IP,
+		Name:         a.Name,
+		Groups:       groups,
+		MaxRTT:       a.MaxRTT.Milliseconds(),
+		Count:        a.Count,
+		Interval:     a.Interval.Milliseconds(),
+		Timeout:      a.Timeout.Milliseconds(),
+		Notification: a.NotificationCount,
+		Period:       FormatWindows(a.Windows),
+		TimeZone:     a.TimeZone,
+		SkipHolidays: a.SkipHolidays,
+		Every:        int64(a.CheckInterval / time.Second),
+		Schedule:     a.Cron,
+		Enabled:      a.Enabled,
+	}
+}
+
+// Values значения записи в порядке AddressColumns
+func (r *AddressRecord) Values() []string {
+	number := func(v int64) string { return strconv.FormatInt(v, 10) }
+	return []string{
+		r.IP, r.Name, strings.Join(r.Groups, ", "), number(r.MaxRTT), number(int64(r.Count)), number(r.Interval),
+		number(r.Timeout), number(int64(r.Notification)), r.Period, r.TimeZone, strconv.FormatBool(r.SkipHolidays),
+		number(r.Every), r.Schedule, strconv.FormatBool(r.Enabled),
+	}
+}
+
+// Apply возвращает копию адреса с заданными в dto полями
+func (dto *AddressDTO) Apply(data *Address) *Address {
+	res := *data
+	if dto.Name != nil {
+		res.Name = *dto.Name
+	}
+	if dto.Groups != nil {
+		res.Groups = dto.Groups
+	}
+	if dto.MaxRTT != nil {
+		res.MaxRTT = *dto.MaxRTT
+	}
+	if dto.Interval != nil {
+		res.Interval = *dto.Interval
+	}
+	if dto.Count != nil {
+		res.Count = *dto.Count
+	}
+	if dto.Timeout != nil {
+		res.Timeout = *dto.Timeout
+	}
+	if dto.NotificationCount != nil {
+		res.NotificationCount = *dto.NotificationCount
+	}
+	if dto.Windows != nil {
+		res.Windows = dto.Windows
+	}
+	if dto.TimeZone != nil {
+		res.TimeZone = *dto.TimeZone
+	}
+	if dto.SkipHolidays != nil {
+		res.SkipHolidays = *dto.SkipHolidays
+	}
+	if dto.CheckInterval != nil {
+		res.CheckInterval = *dto.CheckInterval
+	}
+	if dto.Cron != nil {
+		res.Cron = *dto.Cron
+	}
+	if dto.Enabled != nil {
+		res.Enabled = *dto.Enabled
+	}
+	return &res
+}
+
+// ImportPlan изменения, которые будут выполнены при импорте. Если есть ошибки, импорт не применяется
+type ImportPlan struct {
+	Create    []*AddressDTO    `json:"create"`
+	Update    []*AddressChange `json:"update"`
+	Delete    []string         `json:"delete"`
+	Unchanged int              `json:"unchanged"`
+	Errors    []*ImportError   `json:"errors"`
+}
+
+// Empty нет изменений для применения
+func (p *ImportPlan) Empty() bool {
+	return len(p.Create) == 0 && len(p.Update) == 0 && len(p.Delete) == 0
+}
+
+// IPs адреса, которые затрагивает импорт
+func (p *ImportPlan) IPs() []string {
+	ips := make([]string, 0, len(p.Create)+len(p.Update)+len(p.Delete))
+	for _, dto := range p.Create {
+		ips = append(ips, dto.IP)
+	}
+	for _, change := range p.Update {
+		ips = append(ips, change.IP)
+	}
+	return append(ips, p.Delete...)
+}
+
+// AddressChange изменение существующего адреса
+type AddressChange struct {
+	IP      string         `json:"ip"`
+	Fields  []*FieldChange `json:"fields"`
+	Address *AddressDTO    `json:"-"`
+}
+
+type FieldChange struct {
+	Name string `json:"name"`
+	Old  string `json:"old"`
+	New  string `json:"new"`
+}
+
+// ImportError ошибка в строке файла. Строки нумеруются с 1 без учета заголовка
+type ImportError struct {
+	Row     int    `json:"row"`
+	IP      string `json:"ip,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+const (
+	ActionAck     = "ack"     // подтвердить уведомление
+	ActionSilence = "silence" // отключить уведомления на час
+	ActionDisable = "disable" // отключить проверку адреса
+	ActionHistory = "history" // показать историю недоступности
+)
+
+// Action кнопка под сообщением. При нажатии Mattermost отправляет Context на URL
+type Action struct {
+	ID      string
+	Name    string
+	Style   string
+	URL     string
+	Context map[string]any
+}
+
+// AlertsConf настройки кнопок под уведомлениями
+type AlertsConf struct {
+	// адрес, на который Mattermost отправляет нажатия кнопок. Пустой адрес - уведомления без кнопок
+	URL string
+	// ключ для подписи кнопок, чтобы нельзя было выполнить действие без нажатия на кнопку
+	Secret          string
+	SilenceDuration time.Duration
+}
+
+// AlertState состояние уведомлений по адресу
+type AlertState struct {
+	AckBy         string
+	SilencedBy    string
+	SilencedUntil time.Time
+}
+
+// ActionRequest нажатие кнопки под сообщением
+type ActionRequest struct {
+	UserID    string
+	UserName  string
+	ChannelID string
+	PostID    string
+	TriggerID string
+	Context   map[string]any
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Действия с адресами в журнале изменений
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditEnable  = "enable"
+	AuditDisable = "disable"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditUndo    = "undo" // отмена предыдущей команды пользователя
+)
+
+var AuditTitles = map[string]string{
+	AuditCreate:  "добавлен",
+	AuditUpdate:  "изменен",
+	AuditEnable:  "включен",
+	AuditDisable: "отключен",
+	AuditDelete:  "удален",
+	AuditRestore: "восстановлен",
+	AuditUndo:    "отмена изменения",
+}
+
+// AuditEntry запись журнала изменений адреса. Before пустой у добавленного адреса, After - у удаленного
+type AuditEntry struct {
+	ID        int64  `json:"id" db:"id"`
+	IP        string `json:"ip" db:"ip"`
+	Action    string `json:"action" db:"action"`
+	UserID    string `json:"userId" db:"user_id"`
+	Username  string `json:"username" db:"username"`
+	ChannelID string `json:"channelId" db:"channel_id"`
+	// текст команды, которой выполнено изменение
+	Command string   `json:"command" db:"command"`
+	Before  *Address `json:"before" db:"before"`
+	After   *Address `json:"after" db:"after"`
+	// измененные поля в формате файла экспорта, заполняются при чтении журнала
+	Changes []*FieldChange `json:"changes" db:"-"`
+	// команда отменена через undo, повторная отмена переходит к предыдущей команде
+	Undone  bool      `json:"undone" db:"undone"`
+	Created time.Time `json:"created" db:"created_at"`
+}
+
+// AuditFilter параметры выборки журнала. Пустые поля не ограничивают выборку
+type AuditFilter struct {
+	IP          string
+	UserID      string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	Limit       int
+}
+
+// UndoResult результат отмены последней команды пользователя
+type UndoResult struct {
+	// текст отмененной команды
+	Command string        `json:"command"`
+	Results []*BulkResult `json:"results"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// Доступ к командам бота в канале
+const (
+	ChannelAccessNone = "none" // команды не принимаются
+	ChannelAccessRead = "read" // только команды просмотра
+	ChannelAccessFull = "full" // все команды в пределах роли пользователя
+)
+
+type ChannelConf struct {
+	ID     string
+	Access string
+}
+
+type ChannelsConf struct {
+	// канал уведомлений, в нем команды принимаются, если список каналов пуст
+	Default  string
+	Channels []*ChannelConf
+	// принимать команды в личных сообщениях боту
+	Direct bool
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// CheckState результат последней проверки адреса
+type CheckState struct {
+	IP         string        `json:"ip"`
+	Name       string        `json:"name"`
+	Groups     []string      `json:"groups"`
+	IsFailed   bool          `json:"isFailed"`
+	IsLong     bool          `json:"isLong"`
+	PacketLoss float64       `json:"packetLoss"`
+	MinRtt     time.Duration `json:"minRtt"`
+	AvgRtt     time.Duration `json:"avgRtt"`
+	MaxRtt     time.Duration `json:"maxRtt"`
+	StdDevRtt  time.Duration `json:"stdDevRtt"`
+	CheckedAt  time.Time     `json:"checkedAt"` // время последней проверки
+	ChangedAt  time.Time     `json:"changedAt"` // время последнего изменения доступности
+}
+
+// CheckPoint результат одной проверки для графиков
+type CheckPoint struct {
+	Time       time.Time     `json:"time"`
+	AvgRtt     time.Duration `json:"avgRtt"`
+	PacketLoss float64       `json:"packetLoss"`
+	IsFailed   bool          `json:"isFailed"`
+}
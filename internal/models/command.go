@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// SlashCommand настройки slash-команды бота в Mattermost
+type SlashCommand struct {
+	Trigger string
+	// токен, который Mattermost передает с каждой командой. Если команда регистрируется ботом, токен берется из нее
+	Token string
+	// команда и адрес для регистрации. Если не заданы, команду нужно создать в Mattermost вручную
+	TeamID string
+	URL    string
+}
+
+// CommandInput разобранная команда из сообщения. Значения флагов уже проверены и приведены к типам
+type CommandInput struct {
+	// полное название команды, например "holidays add"
+	Name string
+	// действие команды (например add у holidays), пустое если команда вызвана без действия
+	Action string
+	Args   map[string]string
+	Flags  map[string]any
+	// строки сообщения после первой, например список праздников для импорта
+	Body string
+}
+
+// Arg возвращает позиционный аргумент или пустую строку, если он не указан
+func (i *CommandInput) Arg(name string) string {
+	return i.Args[name]
+}
+
+// Has проверяет указан ли флаг
+func (i *CommandInput) Has(flag string) bool {
+	_, ok := i.Flags[flag]
+	return ok
+}
+
+func (i *CommandInput) String(flag string) string {
+	value, _ := i.Flags[flag].(string)
+	return value
+}
+
+func (i *CommandInput) Int(flag string) int {
+	value, _ := i.Flags[flag].(int)
+	return value
+}
+
+func (i *CommandInput) Bool(flag string) bool {
+	value, _ := i.Flags[flag].(bool)
+	return value
+}
+
+func (i *CommandInput) Duration(flag string) time.Duration {
+	value, _ := i.Flags[flag].(time.Duration)
+	return value
+}
@@ -0,0 +1,53 @@
+package models
+
+const (
+	ActionDialog = "dialog" // открыть форму адреса
+
+	DialogAddress = "address" // форма добавления и изменения адреса
+)
+
+// Dialog форма Mattermost. После отправки заполненные поля отправляются на URL
+type Dialog struct {
+	CallbackID  string
+	URL         string
+	Title       string
+	Intro       string
+	SubmitLabel string
+	State       string
+	Elements    []*DialogElement
+}
+
+type DialogElement struct {
+	DisplayName string
+	Name        string
+	Type        string // text, textarea, select, bool
+	SubType     string // для text: number, email и т.д.
+	Default     string
+	Placeholder string
+	HelpText    string
+	Optional    bool
+}
+
+// DialogSubmission заполненная форма
+type DialogSubmission struct {
+	CallbackID string
+	State      string
+	UserID     string
+	ChannelID  string
+	Values     map[string]any
+}
+
+// DialogErrors ошибки заполнения формы, показываются рядом с полями
+type DialogErrors struct {
+	Error  string
+	Fields map[string]string
+}
+
+// DialogConf настройки форм
+type DialogConf struct {
+	// адрес, на который Mattermost отправляет заполненные формы. Пустой адрес - формы недоступны
+	URL string
+	// адрес для кнопки, открывающей форму
+	ActionsURL string
+	Secret     string
+}
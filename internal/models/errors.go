@@ -7,5 +7,30 @@ var (
 	ErrDuplicate = errors.New("duplicate item")
 	ErrExist     = errors.New("item already exist")
 
+	ErrInvalidSettings = errors.New("invalid settings")
+
+	ErrInvalidAddress   = errors.New("invalid address")
+	ErrTooManyAddresses = errors.New("too many addresses")
+	ErrManaged          = errors.New("address is managed by config")
+
+	ErrUnknownFormat  = errors.New("unknown file format")
+	ErrInvalidImport  = errors.New("import file contains errors")
+	ErrImportNotFound = errors.New("import not found or expired")
+
+	ErrInvalidComponent = errors.New("invalid status page component")
+	ErrInvalidIncident  = errors.New("invalid incident")
+
+	ErrInvalidAction  = errors.New("invalid post action")
+	ErrInvalidDialog  = errors.New("invalid dialog submission")
+	ErrDialogDisabled = errors.New("dialogs are not configured")
+
+	ErrTokenMissing      = errors.New("api token is missing")
+	ErrTokenInvalid      = errors.New("api token is invalid")
+	ErrTokenScope        = errors.New("api token scope is insufficient")
+	ErrTokenInvalidScope = errors.New("unknown api token scope")
+
+	ErrInvalidRole      = errors.New("unknown role")
+	ErrPermissionDenied = errors.New("permission denied")
+
 	ErrSessionEmpty = errors.New("user session not found")
 )
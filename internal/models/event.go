@@ -0,0 +1,48 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	EventCheck  = "check"  // результат проверки
+	EventDown   = "down"   // адрес недоступен
+	EventUp     = "up"     // адрес снова доступен
+	EventSlow   = "slow"   // превышено допустимое время пинга
+	EventNormal = "normal" // время пинга снова в норме
+)
+
+// Event событие проверки адреса
+type Event struct {
+	Type   string      `json:"type"`
+	IP     string      `json:"ip"`
+	Name   string      `json:"name"`
+	Groups []string    `json:"groups"`
+	State  *CheckState `json:"state"`
+	// номер повторного уведомления о том же состоянии, 0 - состояние только что изменилось
+	Repeat int `json:"repeat"`
+	// статистика пинга в текстовом виде для уведомлений
+	Details string    `json:"details,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// EventFilter отбирает события по адресам, группам и типам. Пустой список не ограничивает выборку
+type EventFilter struct {
+	IPs    []string
+	Groups []string
+	Types  []string
+}
+
+func (f *EventFilter) Match(event *Event) bool {
+	if len(f.IPs) > 0 && !slices.Contains(f.IPs, event.IP) {
+		return false
+	}
+	if len(f.Types) > 0 && !slices.Contains(f.Types, event.Type) {
+		return false
+	}
+	if len(f.Groups) > 0 && !slices.ContainsFunc(event.Groups, func(g string) bool { return slices.Contains(f.Groups, g) }) {
+		return false
+	}
+	return true
+}
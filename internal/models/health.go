@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+	StatusIdle = "idle" // подсистема работает, но ей нечего делать (например, нет включенных адресов)
+)
+
+// Health состояние бота и его подсистем
+type Health struct {
+	Status     string                      `json:"status"`
+	Components map[string]*ComponentHealth `json:"components"`
+}
+
+type ComponentHealth struct {
+	Status   string         `json:"status"`
+	Critical bool           `json:"critical"`
+	Error    string         `json:"error,omitempty"`
+	Details  map[string]any `json:"details,omitempty"`
+}
+
+// SchedulerStatus состояние планировщика проверок
+type SchedulerStatus struct {
+	Running    bool          `json:"running"`
+	Interval   time.Duration `json:"interval"`
+	StartDelay time.Duration `json:"startDelay"`
+	StartedAt  time.Time     `json:"startedAt"`
+	// количество адресов, для которых запланированы проверки
+	Addresses int `json:"addresses"`
+	// самый короткий период между проверками среди адресов
+	CheckPeriod time.Duration `json:"checkPeriod"`
+	// время завершения последней проверки адреса
+	LastCheck time.Time `json:"lastCheck"`
+}
+
+// Heartbeat настройки самоконтроля бота
+type Heartbeat struct {
+	Interval        time.Duration // период проверки состояния и отправки сигнала
+	MissedIntervals int           // через сколько пропущенных интервалов планировщик считается зависшим
+	URL             string        // внешний адрес для сигнала (пусто - не отправлять)
+	Timeout         time.Duration
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Measurement сохраненный результат проверки адреса
+type Measurement struct {
+	IP         string        `json:"ip" db:"ip"`
+	AvgRtt     time.Duration `json:"avgRtt" db:"avg_rtt"`
+	PacketLoss float64       `json:"packetLoss" db:"packet_loss"`
+	IsFailed   bool          `json:"isFailed" db:"is_failed"`
+	Time       time.Time     `json:"time" db:"checked_at"`
+}
+
+type GetMeasurementsDTO struct {
+	IP          string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+}
+
+// GraphDTO параметры графика задержки и потерь по адресу
+type GraphDTO struct {
+	IP          string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+}
@@ -3,6 +3,21 @@ package models
 type Post struct {
 	ID        string
 	ChannelID string
-	UserID    string
-	Message   string
+	// тип канала (O, P, D, G), приходит с сообщением из канала
+	ChannelType string
+	UserID      string
+	Message     string
+	FileIDs     []string
+	// сообщение начинается с упоминания бота, упоминание из текста убрано
+	Mention bool
+	// имя автора, если оно известно без запроса к mattermost (имя токена для запросов API)
+	UserName string
+	// адрес для ответа на slash-команду. Если задан, ответы отправляются через него, а не в канал
+	ResponseURL string
+	// идентификатор для открытия формы, приходит вместе со slash-командой и нажатием кнопки
+	TriggerID string
+	// кнопки под сообщением
+	Actions []*Action
+	// разобранная команда, заполняется роутером перед вызовом обработчика
+	Input *CommandInput
 }
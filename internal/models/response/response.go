@@ -3,6 +3,7 @@ package response
 import (
 	"strings"
 
+	"github.com/Alexander272/Pinger/internal/models"
 	"github.com/Alexander272/Pinger/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
@@ -28,7 +29,13 @@ type StatusResponse struct {
 
 func NewErrorResponse(c *gin.Context, statusCode int, err, message string) {
 	code := "U001"
-	if strings.Contains(err, "execute query") {
+	if strings.Contains(err, models.ErrTokenMissing.Error()) {
+		code = "A001"
+	} else if strings.Contains(err, models.ErrTokenInvalid.Error()) {
+		code = "A002"
+	} else if strings.Contains(err, models.ErrTokenScope.Error()) {
+		code = "A003"
+	} else if strings.Contains(err, "execute query") {
 		code = "MD001"
 	} else if strings.Contains(err, "EOF") {
 		code = "E001"
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Роли пользователей бота. Каждая следующая роль включает права предыдущей
+const (
+	RoleViewer   = "viewer"   // просмотр адресов, статистики и графиков
+	RoleOperator = "operator" // добавление, изменение, включение и отключение адресов
+	RoleAdmin    = "admin"    // удаление адресов и изменение настроек бота
+)
+
+var roleLevels = map[string]int{
+	RoleViewer:   0,
+	RoleOperator: 1,
+	RoleAdmin:    2,
+}
+
+var RoleTitles = map[string]string{
+	RoleViewer:   "наблюдатель",
+	RoleOperator: "оператор",
+	RoleAdmin:    "администратор",
+}
+
+// IsRole проверяет существует ли роль
+func IsRole(role string) bool {
+	_, ok := roleLevels[role]
+	return ok
+}
+
+// RoleAllows проверяет достаточно ли роли для выполнения действия, требующего роль required
+func RoleAllows(role, required string) bool {
+	return roleLevels[role] >= roleLevels[required]
+}
+
+// MaxRole возвращает старшую из ролей
+func MaxRole(a, b string) string {
+	if roleLevels[b] > roleLevels[a] {
+		return b
+	}
+	return a
+}
+
+type UserRole struct {
+	UserID    string    `json:"userId" db:"user_id"`
+	Username  string    `json:"username" db:"username"`
+	Role      string    `json:"role" db:"role"`
+	GrantedBy string    `json:"grantedBy" db:"granted_by"`
+	Created   time.Time `json:"created" db:"created_at"`
+}
+
+type UserRoleDTO struct {
+	UserID    string `json:"userId" db:"user_id"`
+	Username  string `json:"username" db:"username"`
+	Role      string `json:"role" db:"role"`
+	GrantedBy string `json:"grantedBy" db:"granted_by"`
+}
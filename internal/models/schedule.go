@@ -0,0 +1,244 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Weekdays битовая маска дней недели, 0 бит - воскресенье (как в time.Weekday)
+type Weekdays uint8
+
+const AllWeekdays Weekdays = 1<<7 - 1
+
+func (w Weekdays) Has(day time.Weekday) bool {
+	return w&(1<<day) != 0
+}
+
+// Window период в течении которого выполняются проверки.
+// Если End меньше Start, период переходит через полночь, если они равны - период длится весь день.
+type Window struct {
+	Weekdays Weekdays      `json:"weekdays"`
+	Start    time.Duration `json:"start"` // от начала суток
+	End      time.Duration `json:"end"`   // от начала суток
+}
+
+// Contains проверяет попадает ли время в период. Время должно быть в часовом поясе адреса
+func (w *Window) Contains(t time.Time) bool {
+	offset := t.Sub(midnight(t))
+
+	if w.Start == w.End {
+		return w.Weekdays.Has(t.Weekday())
+	}
+	if w.Start < w.End {
+		return w.Weekdays.Has(t.Weekday()) && offset >= w.Start && offset < w.End
+	}
+	// часть периода после полуночи относится к предыдущему дню
+	if offset >= w.Start {
+		return w.Weekdays.Has(t.Weekday())
+	}
+	return offset < w.End && w.Weekdays.Has(t.AddDate(0, 0, -1).Weekday())
+}
+
+type Holiday struct {
+	Date time.Time `json:"date" db:"date"`
+	Name string    `json:"name" db:"name"`
+}
+
+type GetHolidaysDTO struct {
+	PeriodStart time.Time `json:"periodStart" db:"period_start"`
+	PeriodEnd   time.Time `json:"periodEnd" db:"period_end"`
+}
+
+// HolidayChecker проверяет является ли день праздничным
+type HolidayChecker interface {
+	IsHoliday(date time.Time) bool
+}
+
+// Location возвращает часовой пояс адреса, по умолчанию часовой пояс сервера
+func (a *Address) Location() *time.Location {
+	if a.TimeZone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(a.TimeZone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// IsActive проверяет нужно ли проверять адрес в указанное время
+func (a *Address) IsActive(now time.Time, holidays HolidayChecker) bool {
+	return a.isActiveAt(now.In(a.Location()), holidays)
+}
+
+func (a *Address) isActiveAt(local time.Time, holidays HolidayChecker) bool {
+	if a.SkipHolidays && holidays != nil && holidays.IsHoliday(local) {
+		return false
+	}
+	if len(a.Windows) == 0 {
+		return true
+	}
+	for _, w := range a.Windows {
+		if w.Contains(local) {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveDuration возвращает время в промежутке [start, end), которое попадает в расписание адреса
+func (a *Address) ActiveDuration(start, end time.Time, holidays HolidayChecker) time.Duration {
+	if !end.After(start) {
+		return 0
+	}
+	if len(a.Windows) == 0 && (!a.SkipHolidays || holidays == nil) {
+		return end.Sub(start)
+	}
+
+	// расписание задается с точностью до минуты, поэтому считаем поминутно
+	loc := a.Location()
+	var total time.Duration
+	for t := start.In(loc); t.Before(end); {
+		next := t.Truncate(time.Minute).Add(time.Minute)
+		if next.After(end) {
+			next = end
+		}
+		if a.isActiveAt(t, holidays) {
+			total += next.Sub(t)
+		}
+		t = next
+	}
+	return total
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"вс": time.Sunday, "пн": time.Monday, "вт": time.Tuesday, "ср": time.Wednesday, "чт": time.Thursday, "пт": time.Friday, "сб": time.Saturday,
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday, "thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+var weekdayShort = [...]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"}
+
+// ParseWindows разбирает расписание вида "пн-пт 09:00-13:00,14:00-18:00; сб 10:00-14:00".
+// Дни недели можно не указывать, тогда период действует каждый день. Пустая строка - без ограничений
+func ParseWindows(value string) ([]*Window, error) {
+	windows := []*Window{}
+
+	for _, entry := range strings.Split(value, ";") {
+		fields := strings.Fields(entry)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidSchedule, entry)
+		}
+
+		days := AllWeekdays
+		ranges := fields[len(fields)-1]
+		if len(fields) == 2 {
+			var err error
+			if days, err = parseWeekdays(fields[0]); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, r := range strings.Split(ranges, ",") {
+			times := strings.Split(r, "-")
+			if len(times) != 2 {
+				return nil, fmt.Errorf("%w: %q", ErrInvalidSchedule, r)
+			}
+			start, err := parseDayTime(times[0])
+			if err != nil {
+				return nil, err
+			}
+			end, err := parseDayTime(times[1])
+			if err != nil {
+				return nil, err
+			}
+			windows = append(windows, &Window{Weekdays: days, Start: start, End: end})
+		}
+	}
+	return windows, nil
+}
+
+// FormatWindows форматирует расписание в вид, который понимает ParseWindows
+func FormatWindows(windows []*Window) string {
+	parts := make([]string, 0, len(windows))
+	for _, w := range windows {
+		r := fmt.Sprintf("%s-%s", formatDayTime(w.Start), formatDayTime(w.End))
+		if w.Weekdays != AllWeekdays {
+			r = formatWeekdays(w.Weekdays) + " " + r
+		}
+		parts = append(parts, r)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func parseWeekdays(value string) (Weekdays, error) {
+	var days Weekdays
+	for _, part := range strings.Split(strings.ToLower(value), ",") {
+		bounds := strings.Split(part, "-")
+		if len(bounds) > 2 {
+			return 0, fmt.Errorf("%w: %q", ErrInvalidSchedule, part)
+		}
+
+		first, ok := weekdayNames[bounds[0]]
+		if !ok {
+			return 0, fmt.Errorf("%w: unknown weekday %q", ErrInvalidSchedule, bounds[0])
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = weekdayNames[bounds[1]]; !ok {
+				return 0, fmt.Errorf("%w: unknown weekday %q", ErrInvalidSchedule, bounds[1])
+			}
+		}
+
+		for d := first; ; d = (d + 1) % 7 {
+			days |= 1 << d
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+func formatWeekdays(days Weekdays) string {
+	parts := []string{}
+	// неделя начинается с понедельника
+	for i := 1; i <= 7; i++ {
+		d := time.Weekday(i % 7)
+		if !days.Has(d) || (i > 1 && days.Has(time.Weekday((i-1)%7))) {
+			continue
+		}
+		last := i
+		for last < 7 && days.Has(time.Weekday((last+1)%7)) {
+			last++
+		}
+		if last == i {
+			parts = append(parts, weekdayShort[d])
+		} else {
+			parts = append(parts, weekdayShort[d]+"-"+weekdayShort[last%7])
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseDayTime(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSchedule, value)
+	}
+	return t.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)), nil
+}
+
+func formatDayTime(d time.Duration) string {
+	return time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC).Add(d).Format("15:04")
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -1,8 +1,63 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MinCheckInterval минимальный интервал проверок, общий и для отдельного адреса
+const MinCheckInterval = time.Second
 
 type Scheduler struct {
-	Interval time.Duration `json:"interval" db:"interval"` // интервал запуска cron
-	// MaxCount int           `json:"max_count" db:"max_count"` // количество одновременных пингов
+	ID           string        `json:"id" db:"id"`
+	Interval     time.Duration `json:"interval" db:"interval"`          // интервал проверок по умолчанию
+	MaxCount     int           `json:"maxCount" db:"max_count"`         // количество одновременных пингов
+	CycleTimeout time.Duration `json:"cycleTimeout" db:"cycle_timeout"` // максимальное время, за которое должна завершиться проверка
+	StartDelay   time.Duration `json:"startDelay" db:"start_delay"`     // задержка перед первым запуском проверок
+	QuietStart   time.Duration `json:"quietStart" db:"quiet_start"`     // начало периода тишины (проверки не выполняются)
+	QuietEnd     time.Duration `json:"quietEnd" db:"quiet_end"`         // окончание периода тишины
+}
+
+type SchedulerDTO struct {
+	ID           string         `json:"id" db:"id"`
+	Interval     *time.Duration `json:"interval" db:"interval"`
+	MaxCount     *int           `json:"maxCount" db:"max_count"`
+	CycleTimeout *time.Duration `json:"cycleTimeout" db:"cycle_timeout"`
+	StartDelay   *time.Duration `json:"startDelay" db:"start_delay"`
+	QuietStart   *time.Duration `json:"quietStart" db:"quiet_start"`
+	QuietEnd     *time.Duration `json:"quietEnd" db:"quiet_end"`
+}
+
+type CheckStats struct {
+	Workers      int           `json:"workers"`      // количество воркеров в пуле
+	QueueDepth   int64         `json:"queueDepth"`   // количество адресов, ожидающих свободного воркера
+	InFlight     int64         `json:"inFlight"`     // количество выполняющихся в данный момент проверок
+	Skipped      int64         `json:"skipped"`      // количество пропущенных проверок (предыдущая проверка адреса не завершилась)
+	Dropped      int64         `json:"dropped"`      // количество проверок, не выполненных до истечения отведенного времени
+	LastCheck    time.Time     `json:"lastCheck"`    // время завершения последней проверки
+	LastDuration time.Duration `json:"lastDuration"` // длительность последней проверки
+}
+
+// ParseQuietPeriod разбирает период тишины в формате <часы>:<минуты>-<часы>:<минуты>. Пустая строка отключает период
+func ParseQuietPeriod(value string) (start, end time.Duration, err error) {
+	if value == "" {
+		return 0, 0, nil
+	}
+
+	from, to, ok := strings.Cut(value, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid quiet period %q", value)
+	}
+	startTime, err := time.Parse("15:04", from)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid quiet period %q", value)
+	}
+	endTime, err := time.Parse("15:04", to)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid quiet period %q", value)
+	}
+
+	midnight := time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC)
+	return startTime.Sub(midnight), endTime.Sub(midnight), nil
 }
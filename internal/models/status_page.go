@@ -0,0 +1,128 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	IncidentInvestigating = "investigating" // выясняем причину
+	IncidentIdentified    = "identified"    // причина найдена
+	IncidentMonitoring    = "monitoring"    // исправлено, наблюдаем
+	IncidentResolved      = "resolved"      // решено
+)
+
+var IncidentStatuses = []string{IncidentInvestigating, IncidentIdentified, IncidentMonitoring, IncidentResolved}
+
+const (
+	ComponentOperational = "operational"
+	ComponentDegraded    = "degraded"
+	ComponentPartial     = "partial_outage"
+	ComponentOutage      = "major_outage"
+	ComponentUnknown     = "unknown"
+)
+
+// StatusPageConf настройки публичной страницы статуса
+type StatusPageConf struct {
+	Enabled  bool
+	Title    string
+	Days     int           // количество дней в истории доступности
+	CacheTTL time.Duration // время, в течении которого страница не пересчитывается
+}
+
+// StatusComponent элемент публичной страницы статуса. Показывается под понятным названием
+// и объединяет один адрес или все адреса группы
+type StatusComponent struct {
+	ID       string    `json:"id" db:"id"`
+	Name     string    `json:"name" db:"name"`
+	IP       string    `json:"ip" db:"ip"`
+	Group    string    `json:"group" db:"group_name"`
+	Position int       `json:"position" db:"position"`
+	Created  time.Time `json:"created" db:"created_at"`
+}
+
+type StatusComponentDTO struct {
+	ID       string `json:"id" db:"id"`
+	Name     string `json:"name" db:"name"`
+	IP       string `json:"ip" db:"ip"`
+	Group    string `json:"group" db:"group_name"`
+	Position int    `json:"position" db:"position"`
+}
+
+type Incident struct {
+	ID         int               `json:"id" db:"id"`
+	Title      string            `json:"title" db:"title"`
+	Status     string            `json:"status" db:"status"`
+	Components []string          `json:"components" db:"components"`
+	CreatedBy  string            `json:"createdBy" db:"created_by"`
+	Resolved   sql.NullTime      `json:"resolved" db:"resolved_at"`
+	Created    time.Time         `json:"created" db:"created_at"`
+	Updates    []*IncidentUpdate `json:"updates" db:"-"`
+}
+
+type IncidentDTO struct {
+	ID         int      `json:"id" db:"id"`
+	Title      string   `json:"title" db:"title"`
+	Status     string   `json:"status" db:"status"`
+	Components []string `json:"components" db:"components"`
+	CreatedBy  string   `json:"createdBy" db:"created_by"`
+	Message    string   `json:"message" db:"-"`
+}
+
+type IncidentUpdate struct {
+	ID         string    `json:"id" db:"id"`
+	IncidentID int       `json:"incidentId" db:"incident_id"`
+	Status     string    `json:"status" db:"status"`
+	Message    string    `json:"message" db:"message"`
+	Created    time.Time `json:"created" db:"created_at"`
+}
+
+type IncidentUpdateDTO struct {
+	ID         string `json:"id" db:"id"`
+	IncidentID int    `json:"incidentId" db:"incident_id"`
+	Status     string `json:"status" db:"status"`
+	Message    string `json:"message" db:"message"`
+}
+
+type GetIncidentsDTO struct {
+	OnlyOpen bool
+	Since    time.Time // закрытые инциденты, решенные после этого времени
+}
+
+type GetOutagesDTO struct {
+	IPs         []string
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+}
+
+// StatusPage данные публичной страницы статуса. IP адреса не раскрываются
+type StatusPage struct {
+	Title      string             `json:"title"`
+	Status     string             `json:"status"`
+	Components []*PublicComponent `json:"components"`
+	Incidents  []*PublicIncident  `json:"incidents"`
+	Updated    time.Time          `json:"updated"`
+}
+
+type PublicComponent struct {
+	Name   string       `json:"name"`
+	Status string       `json:"status"`
+	Uptime *float64     `json:"uptime"` // за весь период, nil - нет данных
+	Days   []*UptimeDay `json:"days"`
+}
+
+type UptimeDay struct {
+	Date     time.Time     `json:"date"`
+	Uptime   *float64      `json:"uptime"` // процент доступности, nil - нет данных
+	Downtime time.Duration `json:"downtime"`
+}
+
+type PublicIncident struct {
+	Title      string            `json:"title"`
+	Status     string            `json:"status"`
+	Components []string          `json:"components"`
+	Automatic  bool              `json:"automatic"` // инцидент создан по данным проверок, а не человеком
+	Started    time.Time         `json:"started"`
+	Resolved   *time.Time        `json:"resolved,omitempty"`
+	Updates    []*IncidentUpdate `json:"updates"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+const (
+	ScopeRead  = "read"  // только чтение
+	ScopeAdmin = "admin" // чтение и изменение
+)
+
+type Token struct {
+	ID        string       `json:"id" db:"id"`
+	Name      string       `json:"name" db:"name"`
+	Hash      string       `json:"-" db:"token_hash"`
+	Scope     string       `json:"scope" db:"scope"`
+	CreatedBy string       `json:"createdBy" db:"created_by"`
+	LastUsed  sql.NullTime `json:"lastUsed" db:"last_used_at"`
+	Created   time.Time    `json:"created" db:"created_at"`
+}
+
+type TokenDTO struct {
+	ID        string `json:"id" db:"id"`
+	Name      string `json:"name" db:"name"`
+	Hash      string `json:"-" db:"token_hash"`
+	Scope     string `json:"scope" db:"scope"`
+	CreatedBy string `json:"createdBy" db:"created_by"`
+}
+
+// Allows проверяет достаточно ли прав у токена для указанной области
+func (t *Token) Allows(scope string) bool {
+	return t.Scope == ScopeAdmin || t.Scope == scope
+}
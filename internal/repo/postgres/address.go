@@ -4,13 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/Alexander272/Pinger/internal/models"
 	"github.com/Alexander272/Pinger/internal/repo/postgres/pq_models"
+	"github.com/goccy/go-json"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type AddressRepo struct {
@@ -26,85 +29,70 @@ type Address interface {
 	GetAll(context.Context) ([]*models.Address, error)
 	GetByIP(context.Context, string) (*models.Address, error)
 	Create(context.Context, *models.AddressDTO) error
+	CreateMany(context.Context, []*models.AddressDTO) ([]*models.BulkResult, error)
 	Update(context.Context, *models.AddressDTO) error
+	UpdateMany(context.Context, []*models.AddressDTO) error
 	Delete(ctx context.Context, ip string) error
+	DeleteMany(ctx context.Context, ips []string) ([]string, error)
+	Import(context.Context, *models.ImportPlan) error
+	GetDeleted(context.Context) ([]*models.Address, error)
+	Restore(ctx context.Context, ip string) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
 }
 
+const addressColumns = `id, ip, name, groups, max_rtt, interval, count, timeout, not_count, windows, time_zone, skip_holidays,
+	check_interval, cron, enabled, managed, created_at, deleted_at`
+
 func (r *AddressRepo) Get(ctx context.Context) ([]*models.Address, error) {
-	query := fmt.Sprintf(`SELECT id, ip, name, max_rtt, interval, count, timeout, not_count, period_start, period_end, created_at 
-		FROM %s WHERE enabled=true ORDER BY created_at`,
-		AddressTable,
-	)
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE enabled=true AND deleted_at IS NULL ORDER BY created_at`, addressColumns, AddressTable)
 	tmp := []*pq_models.Address{}
 	data := []*models.Address{}
 
 	//// если я буду хранить данные не в ns, тогда придется создать структуру в которую будут записываться данные из базы, а затем нужно будет преобразовывать их в time.Duration
 	/* Если хранить это все в int
 	*	max_rtt, interval, timeout число в миллисекундах
-	*	period_start, period_end в минутах
+	*	check_interval в секундах
+	*	windows в jsonb, начало и конец периода в минутах
 	 */
 
 	err := r.db.SelectContext(ctx, &tmp, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query. error: %w", err)
+		return nil, queryError(err)
 	}
 
 	for _, v := range tmp {
-		data = append(data, &models.Address{
-			ID:                v.ID,
-			IP:                v.IP,
-			Name:              v.Name,
-			MaxRTT:            time.Duration(v.MaxRTT) * time.Millisecond,
-			Interval:          time.Duration(v.Interval) * time.Millisecond,
-			Count:             v.Count,
-			Timeout:           time.Duration(v.Timeout) * time.Millisecond,
-			NotificationCount: v.NotificationCount,
-			PeriodStart:       time.Duration(v.PeriodStart) * time.Minute,
-			PeriodEnd:         time.Duration(v.PeriodEnd) * time.Minute,
-			Created:           v.Created,
-		})
+		address, err := r.toModel(v)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, address)
 	}
 
 	return data, nil
 }
 
 func (r *AddressRepo) GetAll(ctx context.Context) ([]*models.Address, error) {
-	query := fmt.Sprintf(`SELECT id, ip, name, max_rtt, interval, count, timeout, not_count, period_start, period_end, enabled, created_at 
-		FROM %s ORDER BY created_at`,
-		AddressTable,
-	)
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE deleted_at IS NULL ORDER BY created_at`, addressColumns, AddressTable)
 	tmp := []*pq_models.Address{}
 	data := []*models.Address{}
 
 	err := r.db.SelectContext(ctx, &tmp, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query. error: %w", err)
+		return nil, queryError(err)
 	}
 
 	for _, v := range tmp {
-		data = append(data, &models.Address{
-			ID:                v.ID,
-			IP:                v.IP,
-			Name:              v.Name,
-			MaxRTT:            time.Duration(v.MaxRTT) * time.Millisecond,
-			Interval:          time.Duration(v.Interval) * time.Millisecond,
-			Count:             v.Count,
-			Timeout:           time.Duration(v.Timeout) * time.Millisecond,
-			NotificationCount: v.NotificationCount,
-			PeriodStart:       time.Duration(v.PeriodStart) * time.Minute,
-			PeriodEnd:         time.Duration(v.PeriodEnd) * time.Minute,
-			Enabled:           v.Enabled,
-			Created:           v.Created,
-		})
+		address, err := r.toModel(v)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, address)
 	}
 	return data, nil
 }
 
 func (r *AddressRepo) GetByIP(ctx context.Context, ip string) (*models.Address, error) {
-	query := fmt.Sprintf(`SELECT id, ip, name, max_rtt, interval, count, timeout, not_count, period_start, period_end, enabled, created_at 
-		FROM %s WHERE ip = $1`,
-		AddressTable,
-	)
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE ip = $1 AND deleted_at IS NULL`, addressColumns, AddressTable)
 	tmp := &pq_models.Address{}
 
 	err := r.db.GetContext(ctx, tmp, query, ip)
@@ -112,29 +100,86 @@ func (r *AddressRepo) GetByIP(ctx context.Context, ip string) (*models.Address,
 		if err == sql.ErrNoRows {
 			return nil, models.ErrNoRows
 		}
-		return nil, fmt.Errorf("failed to execute query. error: %w", err)
+		return nil, queryError(err)
+	}
+
+	return r.toModel(tmp)
+}
+
+// GetDeleted возвращает удаленные адреса, которые еще можно восстановить, начиная с последних удаленных
+func (r *AddressRepo) GetDeleted(ctx context.Context) ([]*models.Address, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC`, addressColumns, AddressTable)
+	tmp := []*pq_models.Address{}
+	data := []*models.Address{}
+
+	err := r.db.SelectContext(ctx, &tmp, query)
+	if err != nil {
+		return nil, queryError(err)
 	}
 
-	address := &models.Address{
-		ID:                tmp.ID,
-		IP:                tmp.IP,
-		Name:              tmp.Name,
-		MaxRTT:            time.Duration(tmp.MaxRTT) * time.Millisecond,
-		Interval:          time.Duration(tmp.Interval) * time.Millisecond,
-		Count:             tmp.Count,
-		Timeout:           time.Duration(tmp.Timeout) * time.Millisecond,
-		NotificationCount: tmp.NotificationCount,
-		PeriodStart:       time.Duration(tmp.PeriodStart) * time.Minute,
-		PeriodEnd:         time.Duration(tmp.PeriodEnd) * time.Minute,
-		Enabled:           tmp.Enabled,
-		Created:           tmp.Created,
+	for _, v := range tmp {
+		address, err := r.toModel(v)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, address)
 	}
-	return address, nil
+	return data, nil
 }
 
 func (r *AddressRepo) Create(ctx context.Context, dto *models.AddressDTO) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction. error: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := r.insert(ctx, tx, dto, false); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction. error: %w", err)
+	}
+	return nil
+}
+
+// CreateMany добавляет адреса в одной транзакции. Уже существующие адреса пропускаются и отмечаются в результате
+func (r *AddressRepo) CreateMany(ctx context.Context, dtos []*models.AddressDTO) ([]*models.BulkResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction. error: %w", err)
+	}
+	defer tx.Rollback()
+
+	results := make([]*models.BulkResult, 0, len(dtos))
+	for _, dto := range dtos {
+		created, err := r.insert(ctx, tx, dto, true)
+		if err != nil {
+			return nil, err
+		}
+		result := &models.BulkResult{IP: dto.IP, Status: models.BulkDone}
+		if !created {
+			result.Status = models.BulkExists
+		}
+		results = append(results, result)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction. error: %w", err)
+	}
+	return results, nil
+}
+
+// поля адреса, которые задаются при добавлении. Не заданные поля получают значения по умолчанию
+var addressFields = []string{"name", "groups", "max_rtt", "interval", "count", "timeout", "not_count", "windows", "time_zone",
+	"skip_holidays", "check_interval", "cron", "enabled", "managed"}
+
+// insert добавляет адрес. При skipExisting существующий адрес не считается ошибкой, а возвращается false.
+// Удаленный адрес с тем же IP возвращается с новыми параметрами, его идентификатор и история сохраняются
+func (r *AddressRepo) insert(ctx context.Context, e sqlx.ExtContext, dto *models.AddressDTO, skipExisting bool) (bool, error) {
 	params := []string{"id", "ip"}
-	times := [5]int64{}
+	times := [4]int64{}
 
 	data := pq_models.AddressDTO{
 		ID:                uuid.NewString(),
@@ -142,12 +187,20 @@ func (r *AddressRepo) Create(ctx context.Context, dto *models.AddressDTO) error
 		Name:              dto.Name,
 		Count:             dto.Count,
 		NotificationCount: dto.NotificationCount,
+		TimeZone:          dto.TimeZone,
+		SkipHolidays:      dto.SkipHolidays,
+		Cron:              dto.Cron,
 		Enabled:           dto.Enabled,
+		Managed:           dto.Managed,
 	}
 
 	if dto.Name != nil {
 		params = append(params, "name")
 	}
+	if dto.Groups != nil {
+		params = append(params, "groups")
+		data.Groups = dto.Groups
+	}
 	if dto.MaxRTT != nil {
 		params = append(params, "max_rtt")
 		times[0] = dto.MaxRTT.Milliseconds()
@@ -169,36 +222,113 @@ func (r *AddressRepo) Create(ctx context.Context, dto *models.AddressDTO) error
 	if dto.NotificationCount != nil {
 		params = append(params, "not_count")
 	}
-	if dto.PeriodStart != nil {
-		params = append(params, "period_start")
-		times[3] = int64(dto.PeriodStart.Minutes())
-		data.PeriodStart = &times[3]
+	if dto.Windows != nil {
+		params = append(params, "windows")
+		windows, err := r.encodeWindows(dto.Windows)
+		if err != nil {
+			return false, err
+		}
+		data.Windows = windows
+	}
+	if dto.TimeZone != nil {
+		params = append(params, "time_zone")
+	}
+	if dto.SkipHolidays != nil {
+		params = append(params, "skip_holidays")
 	}
-	if dto.PeriodEnd != nil {
-		params = append(params, "period_end")
-		times[4] = int64(dto.PeriodEnd.Minutes())
-		data.PeriodEnd = &times[4]
+	if dto.CheckInterval != nil {
+		params = append(params, "check_interval")
+		times[3] = int64(dto.CheckInterval.Seconds())
+		data.CheckInterval = &times[3]
+	}
+	if dto.Cron != nil {
+		params = append(params, "cron")
 	}
 	if dto.Enabled != nil {
 		params = append(params, "enabled")
 	}
+	if dto.Managed != nil {
+		params = append(params, "managed")
+	}
+	revived, err := r.revive(ctx, e, params, data)
+	if err != nil || revived {
+		return revived, err
+	}
+
 	names := ":" + strings.Join(params, ",:")
 
 	query := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s)`, AddressTable, strings.Join(params, ","), names)
+	if skipExisting {
+		query += ` ON CONFLICT (ip) DO NOTHING`
+	}
 
-	_, err := r.db.NamedExecContext(ctx, query, data)
+	res, err := sqlx.NamedExecContext(ctx, e, query, data)
 	if err != nil {
 		if strings.Contains(err.Error(), "duplicate key value") || strings.Contains(err.Error(), "повторяющееся значение ключа") {
-			return models.ErrExist
+			return false, models.ErrExist
 		}
-		return fmt.Errorf("failed to execute query. error: %w", err)
+		return false, queryError(err)
 	}
-	return nil
+	if count, err := res.RowsAffected(); err == nil && count == 0 {
+		return false, nil
+	}
+	return true, nil
+}
+
+// revive возвращает удаленный адрес с тем же IP, как если бы он был добавлен заново: не заданные поля
+// получают значения по умолчанию. Возвращает false, если удаленного адреса нет
+func (r *AddressRepo) revive(ctx context.Context, e sqlx.ExtContext, params []string, data pq_models.AddressDTO) (bool, error) {
+	sets := make([]string, 0, len(addressFields))
+	for _, field := range addressFields {
+		if slices.Contains(params, field) {
+			sets = append(sets, fmt.Sprintf("%s = :%s", field, field))
+		} else {
+			sets = append(sets, field+" = DEFAULT")
+		}
+	}
+	query := fmt.Sprintf(`UPDATE %s SET %s, created_at = now(), deleted_at = NULL WHERE ip = :ip AND deleted_at IS NOT NULL`,
+		AddressTable, strings.Join(sets, ", "),
+	)
+
+	res, err := sqlx.NamedExecContext(ctx, e, query, data)
+	if err != nil {
+		return false, queryError(err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows. error: %w", err)
+	}
+	return count > 0, nil
 }
 
 func (r *AddressRepo) Update(ctx context.Context, dto *models.AddressDTO) error {
-	query := fmt.Sprintf(`UPDATE %s SET name = :name, max_rtt = :max_rtt, interval = :interval, count = :count, timeout = :timeout, 
-		not_count = :not_count, period_start = :period_start, period_end = :period_end, enabled = :enabled WHERE ip = :ip`,
+	return r.update(ctx, r.db, dto)
+}
+
+// UpdateMany изменяет адреса в одной транзакции
+func (r *AddressRepo) UpdateMany(ctx context.Context, dtos []*models.AddressDTO) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction. error: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, dto := range dtos {
+		if err := r.update(ctx, tx, dto); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction. error: %w", err)
+	}
+	return nil
+}
+
+func (r *AddressRepo) update(ctx context.Context, e sqlx.ExtContext, dto *models.AddressDTO) error {
+	query := fmt.Sprintf(`UPDATE %s SET name = :name, groups = :groups, max_rtt = :max_rtt, interval = :interval, count = :count, timeout = :timeout,
+		not_count = :not_count, windows = :windows, time_zone = :time_zone, skip_holidays = :skip_holidays, check_interval = :check_interval,
+		cron = :cron, enabled = :enabled, managed = COALESCE(:managed, managed) WHERE ip = :ip AND deleted_at IS NULL`,
 		AddressTable,
 	)
 
@@ -208,9 +338,17 @@ func (r *AddressRepo) Update(ctx context.Context, dto *models.AddressDTO) error
 		Name:              dto.Name,
 		Count:             dto.Count,
 		NotificationCount: dto.NotificationCount,
+		TimeZone:          dto.TimeZone,
+		SkipHolidays:      dto.SkipHolidays,
+		Cron:              dto.Cron,
 		Enabled:           dto.Enabled,
+		Managed:           dto.Managed,
 	}
-	times := [5]int64{}
+	data.Groups = pq.StringArray(dto.Groups)
+	if data.Groups == nil {
+		data.Groups = pq.StringArray{}
+	}
+	times := [4]int64{}
 	if dto.MaxRTT != nil {
 		times[0] = dto.MaxRTT.Milliseconds()
 		data.MaxRTT = &times[0]
@@ -223,28 +361,175 @@ func (r *AddressRepo) Update(ctx context.Context, dto *models.AddressDTO) error
 		times[2] = dto.Timeout.Milliseconds()
 		data.Timeout = &times[2]
 	}
-	if dto.PeriodStart != nil {
-		times[3] = int64(dto.PeriodStart.Minutes())
-		data.PeriodStart = &times[3]
+	if dto.CheckInterval != nil {
+		times[3] = int64(dto.CheckInterval.Seconds())
+		data.CheckInterval = &times[3]
 	}
-	if dto.PeriodEnd != nil {
-		times[4] = int64(dto.PeriodEnd.Minutes())
-		data.PeriodEnd = &times[4]
+	windows, err := r.encodeWindows(dto.Windows)
+	if err != nil {
+		return err
 	}
+	data.Windows = windows
 
-	_, err := r.db.NamedExecContext(ctx, query, data)
+	_, err = sqlx.NamedExecContext(ctx, e, query, data)
 	if err != nil {
-		return fmt.Errorf("failed to execute query. error: %w", err)
+		return queryError(err)
 	}
 	return nil
 }
 
+// Delete отмечает адрес удаленным. Статистика по адресу сохраняется, сам адрес удаляется окончательно в Purge
 func (r *AddressRepo) Delete(ctx context.Context, ip string) error {
-	query := fmt.Sprintf(`DELETE FROM %s WHERE ip = $1`, AddressTable)
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = now() WHERE ip = $1 AND deleted_at IS NULL`, AddressTable)
+
+	res, err := r.db.ExecContext(ctx, query, ip)
+	if err != nil {
+		return queryError(err)
+	}
+	if count, err := res.RowsAffected(); err == nil && count == 0 {
+		return models.ErrNoRows
+	}
+	return nil
+}
 
-	_, err := r.db.ExecContext(ctx, query, ip)
+// Import применяет изменения импорта в одной транзакции. Если адрес из списка на добавление уже есть, импорт отменяется
+func (r *AddressRepo) Import(ctx context.Context, plan *models.ImportPlan) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to execute query. error: %w", err)
+		return fmt.Errorf("failed to start transaction. error: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, dto := range plan.Create {
+		if _, err := r.insert(ctx, tx, dto, false); err != nil {
+			return err
+		}
+	}
+	for _, change := range plan.Update {
+		if err := r.update(ctx, tx, change.Address); err != nil {
+			return err
+		}
+	}
+	if len(plan.Delete) > 0 {
+		query := fmt.Sprintf(`UPDATE %s SET deleted_at = now() WHERE ip = ANY($1) AND deleted_at IS NULL`, AddressTable)
+		if _, err := tx.ExecContext(ctx, query, pq.Array(plan.Delete)); err != nil {
+			return queryError(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction. error: %w", err)
 	}
 	return nil
 }
+
+// DeleteMany отмечает адреса удаленными одним запросом и возвращает удаленные
+func (r *AddressRepo) DeleteMany(ctx context.Context, ips []string) ([]string, error) {
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = now() WHERE ip = ANY($1) AND deleted_at IS NULL RETURNING ip`, AddressTable)
+	deleted := []string{}
+
+	err := r.db.SelectContext(ctx, &deleted, query, pq.Array(ips))
+	if err != nil {
+		return nil, queryError(err)
+	}
+	return deleted, nil
+}
+
+// Restore восстанавливает удаленный адрес
+func (r *AddressRepo) Restore(ctx context.Context, ip string) error {
+	query := fmt.Sprintf(`UPDATE %s SET deleted_at = NULL WHERE ip = $1 AND deleted_at IS NOT NULL`, AddressTable)
+
+	res, err := r.db.ExecContext(ctx, query, ip)
+	if err != nil {
+		return queryError(err)
+	}
+	if count, err := res.RowsAffected(); err == nil && count == 0 {
+		return models.ErrNoRows
+	}
+	return nil
+}
+
+// Purge окончательно удаляет адреса, удаленные раньше указанного времени, и возвращает их количество
+func (r *AddressRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE deleted_at < $1`, AddressTable)
+
+	res, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, queryError(err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows. error: %w", err)
+	}
+	return count, nil
+}
+
+func (r *AddressRepo) toModel(v *pq_models.Address) (*models.Address, error) {
+	windows, err := r.decodeWindows(v.Windows)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []string(v.Groups)
+	if groups == nil {
+		groups = []string{}
+	}
+
+	return &models.Address{
+		ID:                v.ID,
+		IP:                v.IP,
+		Name:              v.Name,
+		Groups:            groups,
+		MaxRTT:            time.Duration(v.MaxRTT) * time.Millisecond,
+		Interval:          time.Duration(v.Interval) * time.Millisecond,
+		Count:             v.Count,
+		Timeout:           time.Duration(v.Timeout) * time.Millisecond,
+		NotificationCount: v.NotificationCount,
+		Windows:           windows,
+		TimeZone:          v.TimeZone,
+		SkipHolidays:      v.SkipHolidays,
+		CheckInterval:     time.Duration(v.CheckInterval) * time.Second,
+		Cron:              v.Cron,
+		Enabled:           v.Enabled,
+		Managed:           v.Managed,
+		Created:           v.Created,
+		Deleted:           v.Deleted,
+	}, nil
+}
+
+func (r *AddressRepo) encodeWindows(windows []*models.Window) ([]byte, error) {
+	tmp := make([]*pq_models.Window, 0, len(windows))
+	for _, w := range windows {
+		tmp = append(tmp, &pq_models.Window{
+			Weekdays: uint8(w.Weekdays),
+			Start:    int64(w.Start.Minutes()),
+			End:      int64(w.End.Minutes()),
+		})
+	}
+
+	data, err := json.Marshal(tmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal windows. error: %w", err)
+	}
+	return data, nil
+}
+
+func (r *AddressRepo) decodeWindows(data []byte) ([]*models.Window, error) {
+	windows := []*models.Window{}
+	if len(data) == 0 {
+		return windows, nil
+	}
+
+	tmp := []*pq_models.Window{}
+	if err := json.Unmarshal(data, &tmp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal windows. error: %w", err)
+	}
+	for _, w := range tmp {
+		windows = append(windows, &models.Window{
+			Weekdays: models.Weekdays(w.Weekdays),
+			Start:    time.Duration(w.Start) * time.Minute,
+			End:      time.Duration(w.End) * time.Minute,
+		})
+	}
+	return windows, nil
+}
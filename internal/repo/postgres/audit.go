@@ -0,0 +1,167 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/repo/postgres/pq_models"
+	"github.com/goccy/go-json"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type AuditRepo struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepo(db *sqlx.DB) *AuditRepo {
+	return &AuditRepo{db: db}
+}
+
+type Audit interface {
+	Get(context.Context, *models.AuditFilter) ([]*models.AuditEntry, error)
+	GetLast(ctx context.Context, userID string) ([]*models.AuditEntry, error)
+	Create(context.Context, []*models.AuditEntry) error
+	SetUndone(ctx context.Context, ids []int64) error
+}
+
+const auditColumns = `id, ip, action, user_id, username, channel_id, command, before, after, undone, created_at`
+
+// Get возвращает записи журнала, начиная с последних
+func (r *AuditRepo) Get(ctx context.Context, filter *models.AuditFilter) ([]*models.AuditEntry, error) {
+	conditions, args := []string{"true"}, []any{}
+	where := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.IP != "" {
+		where("ip = $%d", filter.IP)
+	}
+	if filter.UserID != "" {
+		where("user_id = $%d", filter.UserID)
+	}
+	if !filter.PeriodStart.IsZero() {
+		where("created_at >= $%d", filter.PeriodStart)
+	}
+	if !filter.PeriodEnd.IsZero() {
+		where("created_at < $%d", filter.PeriodEnd)
+	}
+	limit := ""
+	if filter.Limit > 0 {
+		limit = fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s ORDER BY created_at DESC, id DESC%s`,
+		auditColumns, AuditTable, strings.Join(conditions, " AND "), limit,
+	)
+	tmp := []*pq_models.AuditEntry{}
+
+	if err := r.db.SelectContext(ctx, &tmp, query, args...); err != nil {
+		return nil, queryError(err)
+	}
+	return r.toModels(tmp)
+}
+
+// GetLast возвращает записи последней не отмененной команды пользователя. Записи одной команды сохраняются
+// в одной транзакции и имеют одинаковое время. Записи самой отмены не учитываются
+func (r *AuditRepo) GetLast(ctx context.Context, userID string) ([]*models.AuditEntry, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s WHERE user_id = $1 AND NOT undone AND action <> $2 AND created_at = (
+			SELECT max(created_at) FROM %s WHERE user_id = $1 AND NOT undone AND action <> $2
+		) ORDER BY id`,
+		auditColumns, AuditTable, AuditTable,
+	)
+	tmp := []*pq_models.AuditEntry{}
+
+	if err := r.db.SelectContext(ctx, &tmp, query, userID, models.AuditUndo); err != nil {
+		return nil, queryError(err)
+	}
+	return r.toModels(tmp)
+}
+
+func (r *AuditRepo) toModels(tmp []*pq_models.AuditEntry) ([]*models.AuditEntry, error) {
+	data := make([]*models.AuditEntry, 0, len(tmp))
+	for _, v := range tmp {
+		entry := &models.AuditEntry{
+			ID:        v.ID,
+			IP:        v.IP,
+			Action:    v.Action,
+			UserID:    v.UserID,
+			Username:  v.Username,
+			ChannelID: v.ChannelID,
+			Command:   v.Command,
+			Undone:    v.Undone,
+			Created:   v.Created,
+		}
+		if v.Before != nil {
+			if err := json.Unmarshal(v.Before, &entry.Before); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal audit entry %d. error: %w", v.ID, err)
+			}
+		}
+		if v.After != nil {
+			if err := json.Unmarshal(v.After, &entry.After); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal audit entry %d. error: %w", v.ID, err)
+			}
+		}
+		data = append(data, entry)
+	}
+	return data, nil
+}
+
+// Create сохраняет записи одной транзакцией
+func (r *AuditRepo) Create(ctx context.Context, entries []*models.AuditEntry) error {
+	query := fmt.Sprintf(`INSERT INTO %s (ip, action, user_id, username, channel_id, command, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		AuditTable,
+	)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction. error: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		before, err := auditState(entry.Before)
+		if err != nil {
+			return err
+		}
+		after, err := auditState(entry.After)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, query, entry.IP, entry.Action, entry.UserID, entry.Username, entry.ChannelID, entry.Command, before, after)
+		if err != nil {
+			return queryError(err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction. error: %w", err)
+	}
+	return nil
+}
+
+// SetUndone отмечает записи отмененными
+func (r *AuditRepo) SetUndone(ctx context.Context, ids []int64) error {
+	query := fmt.Sprintf(`UPDATE %s SET undone = true WHERE id = ANY($1)`, AuditTable)
+
+	if _, err := r.db.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+		return queryError(err)
+	}
+	return nil
+}
+
+// auditState возвращает состояние адреса для jsonb или nil, чтобы записать NULL
+func auditState(address *models.Address) (any, error) {
+	if address == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal address. error: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,13 @@
+package postgres
+
+import (
+	"fmt"
+
+	"github.com/Alexander272/Pinger/internal/metrics"
+)
+
+// queryError оборачивает ошибку выполнения запроса и учитывает ее в метриках
+func queryError(err error) error {
+	metrics.DBErrors.Inc()
+	return fmt.Errorf("failed to execute query. error: %w", err)
+}
@@ -0,0 +1,27 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type HealthRepo struct {
+	db *sqlx.DB
+}
+
+func NewHealthRepo(db *sqlx.DB) *HealthRepo {
+	return &HealthRepo{db: db}
+}
+
+type Health interface {
+	Ping(context.Context) error
+}
+
+func (r *HealthRepo) Ping(ctx context.Context) error {
+	if err := r.db.PingContext(ctx); err != nil {
+		return fmt.Errorf("failed to ping database. error: %w", err)
+	}
+	return nil
+}
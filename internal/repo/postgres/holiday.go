@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type HolidayRepo struct {
+	db *sqlx.DB
+}
+
+func NewHolidayRepo(db *sqlx.DB) *HolidayRepo {
+	return &HolidayRepo{db: db}
+}
+
+type Holiday interface {
+	Get(ctx context.Context, req *models.GetHolidaysDTO) ([]*models.Holiday, error)
+	GetAll(ctx context.Context) ([]*models.Holiday, error)
+	Create(ctx context.Context, dto []*models.Holiday) error
+	Delete(ctx context.Context, dto *models.Holiday) error
+}
+
+func (r *HolidayRepo) Get(ctx context.Context, req *models.GetHolidaysDTO) ([]*models.Holiday, error) {
+	query := fmt.Sprintf(`SELECT date, name FROM %s WHERE date >= $1 AND date <= $2 ORDER BY date`, HolidayTable)
+	data := []*models.Holiday{}
+
+	err := r.db.SelectContext(ctx, &data, query, req.PeriodStart, req.PeriodEnd)
+	if err != nil {
+		return nil, queryError(err)
+	}
+	return data, nil
+}
+
+func (r *HolidayRepo) GetAll(ctx context.Context) ([]*models.Holiday, error) {
+	query := fmt.Sprintf(`SELECT date, name FROM %s ORDER BY date`, HolidayTable)
+	data := []*models.Holiday{}
+
+	err := r.db.SelectContext(ctx, &data, query)
+	if err != nil {
+		return nil, queryError(err)
+	}
+	return data, nil
+}
+
+func (r *HolidayRepo) Create(ctx context.Context, dto []*models.Holiday) error {
+	if len(dto) == 0 {
+		return nil
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (date, name) VALUES (:date, :name) ON CONFLICT (date) DO UPDATE SET name = EXCLUDED.name`,
+		HolidayTable,
+	)
+
+	_, err := r.db.NamedExecContext(ctx, query, dto)
+	if err != nil {
+		return queryError(err)
+	}
+	return nil
+}
+
+func (r *HolidayRepo) Delete(ctx context.Context, dto *models.Holiday) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE date = $1`, HolidayTable)
+
+	_, err := r.db.ExecContext(ctx, query, dto.Date)
+	if err != nil {
+		return queryError(err)
+	}
+	return nil
+}
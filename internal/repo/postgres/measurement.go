@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type MeasurementRepo struct {
+	db *sqlx.DB
+}
+
+func NewMeasurementRepo(db *sqlx.DB) *MeasurementRepo {
+	return &MeasurementRepo{db: db}
+}
+
+type Measurement interface {
+	Get(ctx context.Context, req *models.GetMeasurementsDTO) ([]*models.Measurement, error)
+	Create(ctx context.Context, dto *models.Measurement) error
+	DeleteOld(ctx context.Context, before time.Time) (int64, error)
+}
+
+func (r *MeasurementRepo) Get(ctx context.Context, req *models.GetMeasurementsDTO) ([]*models.Measurement, error) {
+	query := fmt.Sprintf(`SELECT ip, avg_rtt, packet_loss, is_failed, checked_at FROM %s
+		WHERE ip = $1 AND checked_at >= $2 AND checked_at <= $3 ORDER BY checked_at`,
+		MeasurementTable,
+	)
+	data := []*models.Measurement{}
+
+	if err := r.db.SelectContext(ctx, &data, query, req.IP, req.PeriodStart, req.PeriodEnd); err != nil {
+		return nil, queryError(err)
+	}
+	return data, nil
+}
+
+func (r *MeasurementRepo) Create(ctx context.Context, dto *models.Measurement) error {
+	query := fmt.Sprintf(`INSERT INTO %s (ip, avg_rtt, packet_loss, is_failed, checked_at) VALUES ($1, $2, $3, $4, $5)`,
+		MeasurementTable,
+	)
+
+	if _, err := r.db.ExecContext(ctx, query, dto.IP, dto.AvgRtt, dto.PacketLoss, dto.IsFailed, dto.Time); err != nil {
+		return queryError(err)
+	}
+	return nil
+}
+
+// DeleteOld удаляет результаты проверок старше указанного времени и возвращает количество удаленных строк
+func (r *MeasurementRepo) DeleteOld(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE checked_at < $1`, MeasurementTable)
+
+	res, err := r.db.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, queryError(err)
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows. error: %w", err)
+	}
+	return count, nil
+}
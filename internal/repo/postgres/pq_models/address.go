@@ -1,32 +1,54 @@
 package pq_models
 
-import "time"
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
 
 type Address struct {
-	ID                string    `db:"id"`
-	IP                string    `db:"ip"`
-	Name              string    `db:"name"`
-	MaxRTT            int64     `db:"max_rtt"`
-	Interval          int64     `db:"interval"`
-	Count             int       `db:"count"`
-	Timeout           int64     `db:"timeout"`
-	NotificationCount int       `db:"not_count"`
-	PeriodStart       int64     `db:"period_start"`
-	PeriodEnd         int64     `db:"period_end"`
-	Enabled           bool      `db:"enabled"`
-	Created           time.Time `json:"created" db:"created_at"`
+	ID                string         `db:"id"`
+	IP                string         `db:"ip"`
+	Name              string         `db:"name"`
+	Groups            pq.StringArray `db:"groups"`
+	MaxRTT            int64          `db:"max_rtt"`
+	Interval          int64          `db:"interval"`
+	Count             int            `db:"count"`
+	Timeout           int64          `db:"timeout"`
+	NotificationCount int            `db:"not_count"`
+	Windows           []byte         `db:"windows"`
+	TimeZone          string         `db:"time_zone"`
+	SkipHolidays      bool           `db:"skip_holidays"`
+	CheckInterval     int64          `db:"check_interval"`
+	Cron              string         `db:"cron"`
+	Enabled           bool           `db:"enabled"`
+	Managed           bool           `db:"managed"`
+	Created           time.Time      `json:"created" db:"created_at"`
+	Deleted           *time.Time     `db:"deleted_at"`
 }
 
 type AddressDTO struct {
-	ID                string  `db:"id"`
-	IP                string  `db:"ip"`
-	Name              *string `db:"name"`
-	MaxRTT            *int64  `db:"max_rtt"`
-	Interval          *int64  `db:"interval"`
-	Count             *int    `db:"count"`
-	Timeout           *int64  `db:"timeout"`
-	NotificationCount *int    `db:"not_count"`
-	PeriodStart       *int64  `db:"period_start"`
-	PeriodEnd         *int64  `db:"period_end"`
-	Enabled           *bool   `db:"enabled"`
+	ID                string         `db:"id"`
+	IP                string         `db:"ip"`
+	Name              *string        `db:"name"`
+	Groups            pq.StringArray `db:"groups"`
+	MaxRTT            *int64         `db:"max_rtt"`
+	Interval          *int64         `db:"interval"`
+	Count             *int           `db:"count"`
+	Timeout           *int64         `db:"timeout"`
+	NotificationCount *int           `db:"not_count"`
+	Windows           []byte         `db:"windows"`
+	TimeZone          *string        `db:"time_zone"`
+	SkipHolidays      *bool          `db:"skip_holidays"`
+	CheckInterval     *int64         `db:"check_interval"`
+	Cron              *string        `db:"cron"`
+	Enabled           *bool          `db:"enabled"`
+	Managed           *bool          `db:"managed"`
+}
+
+// Window период проверок, хранится в jsonb. start, end в минутах от начала суток
+type Window struct {
+	Weekdays uint8 `json:"weekdays"`
+	Start    int64 `json:"start"`
+	End      int64 `json:"end"`
 }
@@ -0,0 +1,18 @@
+package pq_models
+
+import "time"
+
+// AuditEntry запись журнала изменений, состояние адреса до и после изменения хранится в jsonb
+type AuditEntry struct {
+	ID        int64     `db:"id"`
+	IP        string    `db:"ip"`
+	Action    string    `db:"action"`
+	UserID    string    `db:"user_id"`
+	Username  string    `db:"username"`
+	ChannelID string    `db:"channel_id"`
+	Command   string    `db:"command"`
+	Before    []byte    `db:"before"`
+	After     []byte    `db:"after"`
+	Undone    bool      `db:"undone"`
+	Created   time.Time `db:"created_at"`
+}
@@ -0,0 +1,18 @@
+package pq_models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type Incident struct {
+	ID         int            `db:"id"`
+	Title      string         `db:"title"`
+	Status     string         `db:"status"`
+	Components pq.StringArray `db:"components"`
+	CreatedBy  string         `db:"created_by"`
+	Resolved   sql.NullTime   `db:"resolved_at"`
+	Created    time.Time      `db:"created_at"`
+}
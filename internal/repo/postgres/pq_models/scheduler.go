@@ -0,0 +1,11 @@
+package pq_models
+
+type Scheduler struct {
+	ID           string `db:"id"`
+	Interval     int64  `db:"interval"`
+	MaxCount     int    `db:"max_count"`
+	CycleTimeout int64  `db:"cycle_timeout"`
+	StartDelay   int64  `db:"start_delay"`
+	QuietStart   int64  `db:"quiet_start"`
+	QuietEnd     int64  `db:"quiet_end"`
+}
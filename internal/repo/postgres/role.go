@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+type RoleRepo struct {
+	db *sqlx.DB
+}
+
+func NewRoleRepo(db *sqlx.DB) *RoleRepo {
+	return &RoleRepo{db: db}
+}
+
+type Role interface {
+	GetAll(context.Context) ([]*models.UserRole, error)
+	Get(ctx context.Context, userID string) (*models.UserRole, error)
+	Set(context.Context, *models.UserRoleDTO) error
+	Delete(ctx context.Context, userID string) error
+}
+
+func (r *RoleRepo) GetAll(ctx context.Context) ([]*models.UserRole, error) {
+	query := fmt.Sprintf(`SELECT user_id, username, role, granted_by, created_at FROM %s ORDER BY role, username`, UserRoleTable)
+	data := []*models.UserRole{}
+
+	err := r.db.SelectContext(ctx, &data, query)
+	if err != nil {
+		return nil, queryError(err)
+	}
+	return data, nil
+}
+
+func (r *RoleRepo) Get(ctx context.Context, userID string) (*models.UserRole, error) {
+	query := fmt.Sprintf(`SELECT user_id, username, role, granted_by, created_at FROM %s WHERE user_id = $1`, UserRoleTable)
+	data := &models.UserRole{}
+
+	err := r.db.GetContext(ctx, data, query, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRows
+		}
+		return nil, queryError(err)
+	}
+	return data, nil
+}
+
+// Set назначает роль пользователю, заменяя ранее выданную
+func (r *RoleRepo) Set(ctx context.Context, dto *models.UserRoleDTO) error {
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, username, role, granted_by) VALUES (:user_id, :username, :role, :granted_by)
+		ON CONFLICT (user_id) DO UPDATE SET username = EXCLUDED.username, role = EXCLUDED.role, granted_by = EXCLUDED.granted_by, created_at = now()`,
+		UserRoleTable,
+	)
+
+	_, err := r.db.NamedExecContext(ctx, query, dto)
+	if err != nil {
+		return queryError(err)
+	}
+	return nil
+}
+
+func (r *RoleRepo) Delete(ctx context.Context, userID string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1`, UserRoleTable)
+
+	res, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return queryError(err)
+	}
+	if count, err := res.RowsAffected(); err == nil && count == 0 {
+		return models.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/repo/postgres/pq_models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type SchedulerRepo struct {
+	db *sqlx.DB
+}
+
+func NewSchedulerRepo(db *sqlx.DB) *SchedulerRepo {
+	return &SchedulerRepo{db: db}
+}
+
+type Scheduler interface {
+	Get(context.Context) (*models.Scheduler, error)
+	Create(context.Context, *models.Scheduler) error
+	Update(context.Context, *models.Scheduler) error
+}
+
+func (r *SchedulerRepo) Get(ctx context.Context) (*models.Scheduler, error) {
+	query := fmt.Sprintf(`SELECT id, interval, max_count, cycle_timeout, start_delay, quiet_start, quiet_end
+		FROM %s ORDER BY created_at LIMIT 1`,
+		SchedulerTable,
+	)
+	tmp := &pq_models.Scheduler{}
+
+	/* Хранится в int
+	*	interval, cycle_timeout, start_delay в секундах
+	*	quiet_start, quiet_end в минутах
+	 */
+	err := r.db.GetContext(ctx, tmp, query)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRows
+		}
+		return nil, queryError(err)
+	}
+
+	data := &models.Scheduler{
+		ID:           tmp.ID,
+		Interval:     time.Duration(tmp.Interval) * time.Second,
+		MaxCount:     tmp.MaxCount,
+		CycleTimeout: time.Duration(tmp.CycleTimeout) * time.Second,
+		StartDelay:   time.Duration(tmp.StartDelay) * time.Second,
+		QuietStart:   time.Duration(tmp.QuietStart) * time.Minute,
+		QuietEnd:     time.Duration(tmp.QuietEnd) * time.Minute,
+	}
+	return data, nil
+}
+
+func (r *SchedulerRepo) Create(ctx context.Context, dto *models.Scheduler) error {
+	query := fmt.Sprintf(`INSERT INTO %s (id, interval, max_count, cycle_timeout, start_delay, quiet_start, quiet_end)
+		VALUES (:id, :interval, :max_count, :cycle_timeout, :start_delay, :quiet_start, :quiet_end)`,
+		SchedulerTable,
+	)
+	dto.ID = uuid.NewString()
+
+	_, err := r.db.NamedExecContext(ctx, query, r.toPQ(dto))
+	if err != nil {
+		return queryError(err)
+	}
+	return nil
+}
+
+func (r *SchedulerRepo) Update(ctx context.Context, dto *models.Scheduler) error {
+	query := fmt.Sprintf(`UPDATE %s SET interval = :interval, max_count = :max_count, cycle_timeout = :cycle_timeout,
+		start_delay = :start_delay, quiet_start = :quiet_start, quiet_end = :quiet_end, updated_at = now() WHERE id = :id`,
+		SchedulerTable,
+	)
+
+	_, err := r.db.NamedExecContext(ctx, query, r.toPQ(dto))
+	if err != nil {
+		return queryError(err)
+	}
+	return nil
+}
+
+func (r *SchedulerRepo) toPQ(dto *models.Scheduler) *pq_models.Scheduler {
+	return &pq_models.Scheduler{
+		ID:           dto.ID,
+		Interval:     int64(dto.Interval.Seconds()),
+		MaxCount:     dto.MaxCount,
+		CycleTimeout: int64(dto.CycleTimeout.Seconds()),
+		StartDelay:   int64(dto.StartDelay.Seconds()),
+		QuietStart:   int64(dto.QuietStart.Minutes()),
+		QuietEnd:     int64(dto.QuietEnd.Minutes()),
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/Alexander272/Pinger/internal/models"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 type StatisticRepo struct {
@@ -25,22 +26,23 @@ type Statistic interface {
 	GetByIP(ctx context.Context, req *models.GetStatisticByIPDTO) ([]*models.Statistic, error)
 	GetUnavailable(ctx context.Context, req *models.GetUnavailableDTO) ([]*models.Statistic, error)
 	GetLast(ctx context.Context, req *models.GetStatisticByIPDTO) (*models.Statistic, error)
+	GetOutages(ctx context.Context, req *models.GetOutagesDTO) ([]*models.Statistic, error)
 	Create(ctx context.Context, dto *models.StatisticDTO) error
 	Update(ctx context.Context, dto *models.StatisticDTO) error
 }
 
 func (r *StatisticRepo) Get(ctx context.Context, req *models.GetStatisticDTO) ([]*models.Statistic, error) {
 	// по умолчанию я хочу получать суммарное количество времени за месяц по каждому IP
-	// но думаю, нужно еще предусмотреть возможность указания периода
-	query := fmt.Sprintf(`SELECT ip, name, ROUND(SUM(extract (epoch from time_end - time_start))) AS time FROM %s 
-		WHERE time_end IS NOT NULL AND time_start >= $1 AND time_start <= $2 GROUP BY ip, name ORDER BY ip`,
+	// суммирование выполняется в сервисе, т.к. нужно учитывать расписание проверок адреса
+	query := fmt.Sprintf(`SELECT id, ip, name, ROUND(extract (epoch from time_end - time_start)) AS time, time_start, time_end FROM %s 
+		WHERE time_end IS NOT NULL AND time_start >= $1 AND time_start <= $2 ORDER BY ip, name, time_start`,
 		StatisticTable,
 	)
 	data := []*models.Statistic{}
 
 	err := r.db.SelectContext(ctx, &data, query, req.PeriodStart, req.PeriodEnd)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query. error: %w", err)
+		return nil, queryError(err)
 	}
 
 	for i := range data {
@@ -60,7 +62,7 @@ func (r *StatisticRepo) GetByIP(ctx context.Context, req *models.GetStatisticByI
 
 	err := r.db.SelectContext(ctx, &data, query, req.IP, req.PeriodStart, req.PeriodEnd)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query. error: %w", err)
+		return nil, queryError(err)
 	}
 
 	for i := range data {
@@ -78,7 +80,22 @@ func (r *StatisticRepo) GetUnavailable(ctx context.Context, req *models.GetUnava
 
 	err := r.db.SelectContext(ctx, &data, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query. error: %w", err)
+		return nil, queryError(err)
+	}
+	return data, nil
+}
+
+// GetOutages возвращает простои адресов, пересекающиеся с периодом. Незавершенные простои заканчиваются концом периода
+func (r *StatisticRepo) GetOutages(ctx context.Context, req *models.GetOutagesDTO) ([]*models.Statistic, error) {
+	query := fmt.Sprintf(`SELECT id, ip, name, time_start, COALESCE(time_end, $3) AS time_end FROM %s 
+		WHERE ip = ANY($1) AND time_start < $3 AND (time_end IS NULL OR time_end > $2) ORDER BY ip, time_start`,
+		StatisticTable,
+	)
+	data := []*models.Statistic{}
+
+	err := r.db.SelectContext(ctx, &data, query, pq.Array(req.IPs), req.PeriodStart, req.PeriodEnd)
+	if err != nil {
+		return nil, queryError(err)
 	}
 	return data, nil
 }
@@ -95,7 +112,7 @@ func (r *StatisticRepo) GetLast(ctx context.Context, req *models.GetStatisticByI
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, models.ErrNoRows
 		}
-		return nil, fmt.Errorf("failed to execute query. error: %w", err)
+		return nil, queryError(err)
 	}
 	return data, nil
 }
@@ -106,7 +123,7 @@ func (r *StatisticRepo) Create(ctx context.Context, dto *models.StatisticDTO) er
 
 	_, err := r.db.NamedExecContext(ctx, query, dto)
 	if err != nil {
-		return fmt.Errorf("failed to execute query. error: %w", err)
+		return queryError(err)
 	}
 	return nil
 }
@@ -116,7 +133,7 @@ func (r *StatisticRepo) Update(ctx context.Context, dto *models.StatisticDTO) er
 
 	_, err := r.db.NamedExecContext(ctx, query, dto)
 	if err != nil {
-		return fmt.Errorf("failed to execute query. error: %w", err)
+		return queryError(err)
 	}
 	return nil
 }
@@ -0,0 +1,220 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/repo/postgres/pq_models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type StatusPageRepo struct {
+	db *sqlx.DB
+}
+
+func NewStatusPageRepo(db *sqlx.DB) *StatusPageRepo {
+	return &StatusPageRepo{db: db}
+}
+
+type StatusPage interface {
+	GetComponents(context.Context) ([]*models.StatusComponent, error)
+	CreateComponent(context.Context, *models.StatusComponentDTO) error
+	DeleteComponent(ctx context.Context, name string) error
+	GetIncidents(context.Context, *models.GetIncidentsDTO) ([]*models.Incident, error)
+	GetIncident(ctx context.Context, id int) (*models.Incident, error)
+	CreateIncident(context.Context, *models.IncidentDTO) (int, error)
+	UpdateIncident(context.Context, *models.IncidentUpdateDTO) error
+}
+
+func (r *StatusPageRepo) GetComponents(ctx context.Context) ([]*models.StatusComponent, error) {
+	query := fmt.Sprintf(`SELECT id, name, ip, group_name, position, created_at FROM %s ORDER BY position, created_at`, StatusComponentTable)
+	data := []*models.StatusComponent{}
+
+	err := r.db.SelectContext(ctx, &data, query)
+	if err != nil {
+		return nil, queryError(err)
+	}
+	return data, nil
+}
+
+func (r *StatusPageRepo) CreateComponent(ctx context.Context, dto *models.StatusComponentDTO) error {
+	query := fmt.Sprintf(`INSERT INTO %s (id, name, ip, group_name, position) 
+		VALUES (:id, :name, :ip, :group_name, (SELECT COALESCE(MAX(position), 0) + 1 FROM %s))`,
+		StatusComponentTable, StatusComponentTable,
+	)
+	dto.ID = uuid.NewString()
+
+	_, err := r.db.NamedExecContext(ctx, query, dto)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key value") || strings.Contains(err.Error(), "повторяющееся значение ключа") {
+			return models.ErrExist
+		}
+		return queryError(err)
+	}
+	return nil
+}
+
+func (r *StatusPageRepo) DeleteComponent(ctx context.Context, name string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE name = $1`, StatusComponentTable)
+
+	res, err := r.db.ExecContext(ctx, query, name)
+	if err != nil {
+		return queryError(err)
+	}
+	if count, err := res.RowsAffected(); err == nil && count == 0 {
+		return models.ErrNoRows
+	}
+	return nil
+}
+
+func (r *StatusPageRepo) GetIncidents(ctx context.Context, req *models.GetIncidentsDTO) ([]*models.Incident, error) {
+	query := fmt.Sprintf(`SELECT id, title, status, components, created_by, resolved_at, created_at FROM %s 
+		WHERE resolved_at IS NULL OR (NOT $1 AND resolved_at >= $2) ORDER BY created_at DESC`,
+		IncidentTable,
+	)
+	tmp := []*pq_models.Incident{}
+
+	err := r.db.SelectContext(ctx, &tmp, query, req.OnlyOpen, req.Since)
+	if err != nil {
+		return nil, queryError(err)
+	}
+
+	data := make([]*models.Incident, 0, len(tmp))
+	ids := make([]int64, 0, len(tmp))
+	byID := make(map[int]*models.Incident, len(tmp))
+	for _, v := range tmp {
+		incident := r.toModel(v)
+		data = append(data, incident)
+		ids = append(ids, int64(v.ID))
+		byID[v.ID] = incident
+	}
+	if len(ids) == 0 {
+		return data, nil
+	}
+
+	updates, err := r.getUpdates(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range updates {
+		byID[u.IncidentID].Updates = append(byID[u.IncidentID].Updates, u)
+	}
+	return data, nil
+}
+
+func (r *StatusPageRepo) GetIncident(ctx context.Context, id int) (*models.Incident, error) {
+	query := fmt.Sprintf(`SELECT id, title, status, components, created_by, resolved_at, created_at FROM %s WHERE id = $1`, IncidentTable)
+	tmp := &pq_models.Incident{}
+
+	err := r.db.GetContext(ctx, tmp, query, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRows
+		}
+		return nil, queryError(err)
+	}
+
+	data := r.toModel(tmp)
+	if data.Updates, err = r.getUpdates(ctx, []int64{int64(id)}); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CreateIncident создает инцидент вместе с первым сообщением и возвращает его номер
+func (r *StatusPageRepo) CreateIncident(ctx context.Context, dto *models.IncidentDTO) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction. error: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`INSERT INTO %s (title, status, components, created_by) VALUES ($1, $2, $3, $4) RETURNING id`, IncidentTable)
+	var id int
+	if err := tx.GetContext(ctx, &id, query, dto.Title, dto.Status, pq.Array(dto.Components), dto.CreatedBy); err != nil {
+		return 0, queryError(err)
+	}
+
+	update := &models.IncidentUpdateDTO{ID: uuid.NewString(), IncidentID: id, Status: dto.Status, Message: dto.Message}
+	query = fmt.Sprintf(`INSERT INTO %s (id, incident_id, status, message) VALUES (:id, :incident_id, :status, :message)`, IncidentUpdateTable)
+	if _, err := tx.NamedExecContext(ctx, query, update); err != nil {
+		return 0, queryError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction. error: %w", err)
+	}
+	return id, nil
+}
+
+// UpdateIncident добавляет сообщение к инциденту и меняет его статус
+func (r *StatusPageRepo) UpdateIncident(ctx context.Context, dto *models.IncidentUpdateDTO) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction. error: %w", err)
+	}
+	defer tx.Rollback()
+
+	var resolved *time.Time
+	if dto.Status == models.IncidentResolved {
+		now := time.Now()
+		resolved = &now
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET status = $1, resolved_at = $2 WHERE id = $3`, IncidentTable)
+	res, err := tx.ExecContext(ctx, query, dto.Status, resolved, dto.IncidentID)
+	if err != nil {
+		return queryError(err)
+	}
+	if count, err := res.RowsAffected(); err == nil && count == 0 {
+		return models.ErrNoRows
+	}
+
+	dto.ID = uuid.NewString()
+	query = fmt.Sprintf(`INSERT INTO %s (id, incident_id, status, message) VALUES (:id, :incident_id, :status, :message)`, IncidentUpdateTable)
+	if _, err := tx.NamedExecContext(ctx, query, dto); err != nil {
+		return queryError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction. error: %w", err)
+	}
+	return nil
+}
+
+func (r *StatusPageRepo) getUpdates(ctx context.Context, ids []int64) ([]*models.IncidentUpdate, error) {
+	query := fmt.Sprintf(`SELECT id, incident_id, status, message, created_at FROM %s WHERE incident_id = ANY($1) ORDER BY created_at DESC`,
+		IncidentUpdateTable,
+	)
+	data := []*models.IncidentUpdate{}
+
+	err := r.db.SelectContext(ctx, &data, query, pq.Array(ids))
+	if err != nil {
+		return nil, queryError(err)
+	}
+	return data, nil
+}
+
+func (r *StatusPageRepo) toModel(v *pq_models.Incident) *models.Incident {
+	components := []string(v.Components)
+	if components == nil {
+		components = []string{}
+	}
+	return &models.Incident{
+		ID:         v.ID,
+		Title:      v.Title,
+		Status:     v.Status,
+		Components: components,
+		CreatedBy:  v.CreatedBy,
+		Resolved:   v.Resolved,
+		Created:    v.Created,
+		Updates:    []*models.IncidentUpdate{},
+	}
+}
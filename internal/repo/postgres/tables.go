@@ -1,7 +1,16 @@
 package postgres
 
 const (
-	AddressTable   = "addresses"
-	StatisticTable = "statistics"
-	SchedulerTable = "scheduler"
+	AddressTable     = "addresses"
+	StatisticTable   = "statistics"
+	SchedulerTable   = "scheduler"
+	HolidayTable     = "holidays"
+	TokenTable       = "api_tokens"
+	MeasurementTable = "measurements"
+	UserRoleTable    = "user_roles"
+	AuditTable       = "audit_log"
+
+	StatusComponentTable = "status_components"
+	IncidentTable        = "incidents"
+	IncidentUpdateTable  = "incident_updates"
 )
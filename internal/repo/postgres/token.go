@@ -0,0 +1,99 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type TokenRepo struct {
+	db *sqlx.DB
+}
+
+func NewTokenRepo(db *sqlx.DB) *TokenRepo {
+	return &TokenRepo{db: db}
+}
+
+type Token interface {
+	GetAll(context.Context) ([]*models.Token, error)
+	GetByHash(ctx context.Context, hash string) (*models.Token, error)
+	Create(context.Context, *models.TokenDTO) error
+	UpdateLastUsed(ctx context.Context, id string) error
+	Revoke(ctx context.Context, name string) error
+}
+
+func (r *TokenRepo) GetAll(ctx context.Context) ([]*models.Token, error) {
+	query := fmt.Sprintf(`SELECT id, name, scope, created_by, last_used_at, created_at FROM %s 
+		WHERE revoked_at IS NULL ORDER BY created_at`,
+		TokenTable,
+	)
+	data := []*models.Token{}
+
+	err := r.db.SelectContext(ctx, &data, query)
+	if err != nil {
+		return nil, queryError(err)
+	}
+	return data, nil
+}
+
+func (r *TokenRepo) GetByHash(ctx context.Context, hash string) (*models.Token, error) {
+	query := fmt.Sprintf(`SELECT id, name, scope, created_by, last_used_at, created_at FROM %s 
+		WHERE token_hash = $1 AND revoked_at IS NULL`,
+		TokenTable,
+	)
+	data := &models.Token{}
+
+	err := r.db.GetContext(ctx, data, query, hash)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNoRows
+		}
+		return nil, queryError(err)
+	}
+	return data, nil
+}
+
+func (r *TokenRepo) Create(ctx context.Context, dto *models.TokenDTO) error {
+	query := fmt.Sprintf(`INSERT INTO %s (id, name, token_hash, scope, created_by) VALUES (:id, :name, :token_hash, :scope, :created_by)`,
+		TokenTable,
+	)
+	dto.ID = uuid.NewString()
+
+	_, err := r.db.NamedExecContext(ctx, query, dto)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key value") || strings.Contains(err.Error(), "повторяющееся значение ключа") {
+			return models.ErrExist
+		}
+		return queryError(err)
+	}
+	return nil
+}
+
+func (r *TokenRepo) UpdateLastUsed(ctx context.Context, id string) error {
+	query := fmt.Sprintf(`UPDATE %s SET last_used_at = now() WHERE id = $1`, TokenTable)
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return queryError(err)
+	}
+	return nil
+}
+
+func (r *TokenRepo) Revoke(ctx context.Context, name string) error {
+	query := fmt.Sprintf(`UPDATE %s SET revoked_at = now() WHERE name = $1 AND revoked_at IS NULL`, TokenTable)
+
+	res, err := r.db.ExecContext(ctx, query, name)
+	if err != nil {
+		return queryError(err)
+	}
+	if count, err := res.RowsAffected(); err == nil && count == 0 {
+		return models.ErrNoRows
+	}
+	return nil
+}
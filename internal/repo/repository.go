@@ -11,15 +11,55 @@ type Address interface {
 type Statistic interface {
 	postgres.Statistic
 }
+type Scheduler interface {
+	postgres.Scheduler
+}
+type Holiday interface {
+	postgres.Holiday
+}
+type Token interface {
+	postgres.Token
+}
+type Health interface {
+	postgres.Health
+}
+type Measurement interface {
+	postgres.Measurement
+}
+type StatusPage interface {
+	postgres.StatusPage
+}
+type Role interface {
+	postgres.Role
+}
+type Audit interface {
+	postgres.Audit
+}
 
 type Repository struct {
 	Address
 	Statistic
+	Scheduler
+	Holiday
+	Token
+	Health
+	StatusPage
+	Measurement
+	Role
+	Audit
 }
 
 func NewRepository(db *sqlx.DB) *Repository {
 	return &Repository{
-		Address:   postgres.NewAddressRepo(db),
-		Statistic: postgres.NewStatisticRepo(db),
+		Address:     postgres.NewAddressRepo(db),
+		Statistic:   postgres.NewStatisticRepo(db),
+		Scheduler:   postgres.NewSchedulerRepo(db),
+		Holiday:     postgres.NewHolidayRepo(db),
+		Token:       postgres.NewTokenRepo(db),
+		Health:      postgres.NewHealthRepo(db),
+		StatusPage:  postgres.NewStatusPageRepo(db),
+		Measurement: postgres.NewMeasurementRepo(db),
+		Role:        postgres.NewRoleRepo(db),
+		Audit:       postgres.NewAuditRepo(db),
 	}
 }
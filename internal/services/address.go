@@ -4,28 +4,69 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Alexander272/Pinger/internal/models"
 	"github.com/Alexander272/Pinger/internal/repo"
+	"github.com/Alexander272/Pinger/pkg/logger"
 )
 
 type AddressService struct {
-	repo repo.Address
+	repo      repo.Address
+	audit     Audit
+	observers []AddressObserver
+	retention time.Duration
+
+	mx       sync.RWMutex
+	defaults *models.AddressDefaults
+}
+
+type AddressDeps struct {
+	Repo repo.Address
+	// параметры проверки новых адресов по умолчанию
+	Defaults *models.AddressDefaults
+	// срок, в течении которого удаленный адрес можно восстановить
+	Retention time.Duration
 }
 
-func NewAddressService(repo repo.Address) *AddressService {
+func NewAddressService(deps *AddressDeps) *AddressService {
 	return &AddressService{
-		repo: repo,
+		repo:      deps.Repo,
+		defaults:  deps.Defaults,
+		retention: deps.Retention,
 	}
 }
 
+// AddressObserver получает уведомления об изменении списка адресов
+type AddressObserver interface {
+	AddressChanged(ctx context.Context, address *models.Address)
+	AddressDeleted(ctx context.Context, ip string)
+}
+
 type Address interface {
 	Get(ctx context.Context) ([]*models.Address, error)
 	GetAll(ctx context.Context) ([]*models.Address, error)
 	GetByIP(ctx context.Context, ip string) (*models.Address, error)
+	GetDeleted(ctx context.Context) ([]*models.Address, error)
 	Create(ctx context.Context, address *models.AddressDTO) error
 	Update(ctx context.Context, address *models.AddressDTO) error
+	ToggleActive(ctx context.Context, ip string, enabled bool) error
 	Delete(ctx context.Context, ip string) error
+	Restore(ctx context.Context, ip string) error
+	Purge(ctx context.Context) error
+	Retention() time.Duration
+	BulkCreate(ctx context.Context, ips []string, address *models.AddressDTO) ([]*models.BulkResult, error)
+	BulkUpdate(ctx context.Context, ips []string, address *models.AddressDTO) ([]*models.BulkResult, error)
+	BulkToggle(ctx context.Context, ips []string, enabled bool) ([]*models.BulkResult, error)
+	BulkDelete(ctx context.Context, ips []string) ([]*models.BulkResult, error)
+	Import(ctx context.Context, plan *models.ImportPlan) error
+	Reconcile(ctx context.Context, list []*models.AddressConf) (*models.ImportPlan, error)
+	Defaults() *models.AddressDefaults
+	SetDefaults(defaults *models.AddressDefaults)
+	Subscribe(observer AddressObserver)
 }
 
 func (s *AddressService) Get(ctx context.Context) ([]*models.Address, error) {
@@ -55,26 +96,517 @@ func (s *AddressService) GetByIP(ctx context.Context, ip string) (*models.Addres
 	return data, nil
 }
 
-func (s *AddressService) Create(ctx context.Context, address *models.AddressDTO) error {
+// GetDeleted возвращает удаленные адреса, которые еще можно восстановить
+func (s *AddressService) GetDeleted(ctx context.Context) ([]*models.Address, error) {
+	data, err := s.repo.GetDeleted(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted addresses. error: %w", err)
+	}
+	return data, nil
+}
+
+func (s *AddressService) create(ctx context.Context, address *models.AddressDTO) error {
+	address.SetDefaults(s.Defaults())
+	if err := address.Validate(); err != nil {
+		return err
+	}
 	if err := s.repo.Create(ctx, address); err != nil {
 		if errors.Is(err, models.ErrExist) {
 			return models.ErrExist
 		}
 		return fmt.Errorf("failed to create addresses. error: %w", err)
 	}
+	s.notifyChanged(ctx, address.IP)
 	return nil
 }
 
-func (s *AddressService) Update(ctx context.Context, address *models.AddressDTO) error {
+// update изменяет адрес. Адреса из файла конфигурации изменять нельзя
+func (s *AddressService) update(ctx context.Context, address *models.AddressDTO) error {
+	if err := address.Validate(); err != nil {
+		return err
+	}
+	if err := s.checkManaged(ctx, address.IP); err != nil {
+		return err
+	}
 	if err := s.repo.Update(ctx, address); err != nil {
 		return fmt.Errorf("failed to update addresses. error: %w", err)
 	}
+	s.notifyChanged(ctx, address.IP)
 	return nil
 }
 
-func (s *AddressService) Delete(ctx context.Context, ip string) error {
+func (s *AddressService) toggleActive(ctx context.Context, ip string, enabled bool) error {
+	data, err := s.GetByIP(ctx, ip)
+	if err != nil {
+		return err
+	}
+	if data.Managed {
+		return models.ErrManaged
+	}
+
+	address := &models.AddressDTO{IP: ip, Enabled: &enabled}
+	address.Fill(data)
+
+	return s.update(ctx, address)
+}
+
+func (s *AddressService) delete(ctx context.Context, ip string) error {
+	if err := s.checkManaged(ctx, ip); err != nil {
+		return err
+	}
 	if err := s.repo.Delete(ctx, ip); err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			return models.ErrNoRows
+		}
 		return fmt.Errorf("failed to delete addresses. error: %w", err)
 	}
+	for _, o := range s.observers {
+		o.AddressDeleted(ctx, ip)
+	}
+	return nil
+}
+
+// restore восстанавливает удаленный адрес с прежними параметрами. Адрес, который убрали из файла конфигурации,
+// восстановить нельзя, иначе он разойдется с конфигурацией. Он вернется, когда его снова добавят в файл
+func (s *AddressService) restore(ctx context.Context, ip string) error {
+	deleted, err := s.GetDeleted(ctx)
+	if err != nil {
+		return err
+	}
+	for _, address := range deleted {
+		if address.IP == ip && address.Managed {
+			return models.ErrManaged
+		}
+	}
+
+	if err := s.repo.Restore(ctx, ip); err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			return models.ErrNoRows
+		}
+		return fmt.Errorf("failed to restore address. error: %w", err)
+	}
+	s.notifyChanged(ctx, ip)
+	return nil
+}
+
+func (s *AddressService) Create(ctx context.Context, address *models.AddressDTO) error {
+	return s.audited(ctx, models.AuditCreate, []string{address.IP}, func() error { return s.create(ctx, address) })
+}
+
+func (s *AddressService) Update(ctx context.Context, address *models.AddressDTO) error {
+	return s.audited(ctx, models.AuditUpdate, []string{address.IP}, func() error { return s.update(ctx, address) })
+}
+
+func (s *AddressService) ToggleActive(ctx context.Context, ip string, enabled bool) error {
+	return s.audited(ctx, toggleAction(enabled), []string{ip}, func() error { return s.toggleActive(ctx, ip, enabled) })
+}
+
+func (s *AddressService) Delete(ctx context.Context, ip string) error {
+	return s.audited(ctx, models.AuditDelete, []string{ip}, func() error { return s.delete(ctx, ip) })
+}
+
+func (s *AddressService) Restore(ctx context.Context, ip string) error {
+	return s.audited(ctx, models.AuditRestore, []string{ip}, func() error { return s.restore(ctx, ip) })
+}
+
+func (s *AddressService) BulkCreate(ctx context.Context, ips []string, address *models.AddressDTO) (results []*models.BulkResult, err error) {
+	err = s.audited(ctx, models.AuditCreate, ips, func() error {
+		results, err = s.bulkCreate(ctx, ips, address)
+		return err
+	})
+	return results, err
+}
+
+func (s *AddressService) BulkUpdate(ctx context.Context, ips []string, address *models.AddressDTO) (results []*models.BulkResult, err error) {
+	err = s.audited(ctx, models.AuditUpdate, ips, func() error {
+		results, err = s.bulkUpdate(ctx, ips, address)
+		return err
+	})
+	return results, err
+}
+
+func (s *AddressService) BulkDelete(ctx context.Context, ips []string) (results []*models.BulkResult, err error) {
+	err = s.audited(ctx, models.AuditDelete, ips, func() error {
+		results, err = s.bulkDelete(ctx, ips)
+		return err
+	})
+	return results, err
+}
+
+// Import применяет проверенный план импорта. Действие в журнале уточняется для каждого адреса при записи
+func (s *AddressService) Import(ctx context.Context, plan *models.ImportPlan) error {
+	return s.audited(ctx, models.AuditUpdate, plan.IPs(), func() error { return s.importPlan(ctx, plan) })
+}
+
+// Purge окончательно удаляет адреса, которые удалены дольше срока хранения
+func (s *AddressService) Purge(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	count, err := s.repo.Purge(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		return fmt.Errorf("failed to purge deleted addresses. error: %w", err)
+	}
+	logger.Info("deleted addresses purged", logger.Int64Attr("count", count))
 	return nil
 }
+
+// Retention срок, в течении которого удаленный адрес можно восстановить. 0 - удаленные адреса не очищаются
+func (s *AddressService) Retention() time.Duration {
+	return s.retention
+}
+
+// bulkCreate добавляет адреса с одинаковыми параметрами. Уже добавленные адреса не изменяются
+func (s *AddressService) bulkCreate(ctx context.Context, ips []string, address *models.AddressDTO) ([]*models.BulkResult, error) {
+	address.SetDefaults(s.Defaults())
+	if err := address.Validate(); err != nil {
+		return nil, err
+	}
+	dtos := make([]*models.AddressDTO, 0, len(ips))
+	for _, ip := range ips {
+		dto := *address
+		dto.IP = ip
+		dtos = append(dtos, &dto)
+	}
+
+	results, err := s.repo.CreateMany(ctx, dtos)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create addresses. error: %w", err)
+	}
+	for _, r := range results {
+		if r.Status == models.BulkDone {
+			s.notifyChanged(ctx, r.IP)
+		}
+	}
+	return results, nil
+}
+
+// bulkUpdate применяет одинаковые изменения к адресам. Отсутствующие адреса и адреса из файла конфигурации отмечаются в результате
+func (s *AddressService) bulkUpdate(ctx context.Context, ips []string, address *models.AddressDTO) ([]*models.BulkResult, error) {
+	if err := address.Validate(); err != nil {
+		return nil, err
+	}
+	data, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*models.Address, len(data))
+	for _, a := range data {
+		existing[a.IP] = a
+	}
+
+	results := make([]*models.BulkResult, 0, len(ips))
+	dtos := []*models.AddressDTO{}
+	for _, ip := range ips {
+		current, ok := existing[ip]
+		if !ok {
+			results = append(results, &models.BulkResult{IP: ip, Status: models.BulkNotFound})
+			continue
+		}
+		if current.Managed {
+			results = append(results, &models.BulkResult{IP: ip, Status: models.BulkManaged})
+			continue
+		}
+
+		dto := *address
+		dto.IP = ip
+		dto.Fill(current)
+		dtos = append(dtos, &dto)
+		results = append(results, &models.BulkResult{IP: ip, Status: models.BulkDone})
+	}
+	if len(dtos) == 0 {
+		return results, nil
+	}
+
+	if err := s.repo.UpdateMany(ctx, dtos); err != nil {
+		return nil, fmt.Errorf("failed to update addresses. error: %w", err)
+	}
+	for _, dto := range dtos {
+		s.notifyChanged(ctx, dto.IP)
+	}
+	return results, nil
+}
+
+func (s *AddressService) BulkToggle(ctx context.Context, ips []string, enabled bool) (results []*models.BulkResult, err error) {
+	err = s.audited(ctx, toggleAction(enabled), ips, func() error {
+		results, err = s.bulkUpdate(ctx, ips, &models.AddressDTO{Enabled: &enabled})
+		return err
+	})
+	return results, err
+}
+
+func (s *AddressService) bulkDelete(ctx context.Context, ips []string) ([]*models.BulkResult, error) {
+	data, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	managed := make(map[string]struct{})
+	for _, a := range data {
+		if a.Managed {
+			managed[a.IP] = struct{}{}
+		}
+	}
+	removable := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		if _, ok := managed[ip]; !ok {
+			removable = append(removable, ip)
+		}
+	}
+
+	deleted, err := s.repo.DeleteMany(ctx, removable)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete addresses. error: %w", err)
+	}
+
+	done := make(map[string]struct{}, len(deleted))
+	for _, ip := range deleted {
+		done[ip] = struct{}{}
+		for _, o := range s.observers {
+			o.AddressDeleted(ctx, ip)
+		}
+	}
+
+	results := make([]*models.BulkResult, 0, len(ips))
+	for _, ip := range ips {
+		status := models.BulkNotFound
+		if _, ok := done[ip]; ok {
+			status = models.BulkDone
+		} else if _, ok := managed[ip]; ok {
+			status = models.BulkManaged
+		}
+		results = append(results, &models.BulkResult{IP: ip, Status: status})
+	}
+	return results, nil
+}
+
+// importPlan применяет проверенный план импорта
+func (s *AddressService) importPlan(ctx context.Context, plan *models.ImportPlan) error {
+	defaults := s.Defaults()
+	for _, dto := range plan.Create {
+		dto.SetDefaults(defaults)
+		if err := dto.Validate(); err != nil {
+			return fmt.Errorf("address %s. error: %w", dto.IP, err)
+		}
+	}
+	for _, change := range plan.Update {
+		if err := change.Address.Validate(); err != nil {
+			return fmt.Errorf("address %s. error: %w", change.IP, err)
+		}
+	}
+	if err := s.repo.Import(ctx, plan); err != nil {
+		if errors.Is(err, models.ErrExist) {
+			return models.ErrExist
+		}
+		return fmt.Errorf("failed to import addresses. error: %w", err)
+	}
+
+	for _, dto := range plan.Create {
+		s.notifyChanged(ctx, dto.IP)
+	}
+	for _, change := range plan.Update {
+		s.notifyChanged(ctx, change.IP)
+	}
+	for _, ip := range plan.Delete {
+		for _, o := range s.observers {
+			o.AddressDeleted(ctx, ip)
+		}
+	}
+	return nil
+}
+
+// Reconcile приводит адреса к списку из файла конфигурации. Адреса, добавленные из чата, с тем же IP переходят
+// под управление конфигурации, адреса, которые убрали из конфигурации, удаляются. Параметры, не заданные в конфигурации,
+// не изменяются. При ошибке в списке адреса не изменяются
+func (s *AddressService) Reconcile(ctx context.Context, list []*models.AddressConf) (*models.ImportPlan, error) {
+	dtos := make([]*models.AddressDTO, 0, len(list))
+	seen := make(map[string]struct{}, len(list))
+	for i, conf := range list {
+		dto, err := addressFromConf(conf)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address %d (%s) in config. error: %w", i+1, conf.IP, err)
+		}
+		if _, ok := seen[dto.IP]; ok {
+			return nil, fmt.Errorf("duplicate address %s in config", dto.IP)
+		}
+		seen[dto.IP] = struct{}{}
+		dtos = append(dtos, dto)
+	}
+
+	current, err := s.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*models.Address, len(current))
+	for _, a := range current {
+		existing[a.IP] = a
+	}
+
+	plan := &models.ImportPlan{Create: []*models.AddressDTO{}, Update: []*models.AddressChange{}, Delete: []string{}}
+	for _, dto := range dtos {
+		data, ok := existing[dto.IP]
+		if !ok {
+			plan.Create = append(plan.Create, dto)
+			continue
+		}
+		changes := diffAddress(data, dto.Apply(data))
+		if len(changes) == 0 && data.Managed {
+			plan.Unchanged++
+			continue
+		}
+		dto.Fill(data)
+		plan.Update = append(plan.Update, &models.AddressChange{IP: dto.IP, Fields: changes, Address: dto})
+	}
+	for _, a := range current {
+		if _, ok := seen[a.IP]; a.Managed && !ok {
+			plan.Delete = append(plan.Delete, a.IP)
+		}
+	}
+
+	if plan.Empty() {
+		return plan, nil
+	}
+	if err := s.Import(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Defaults параметры проверки новых адресов по умолчанию
+func (s *AddressService) Defaults() *models.AddressDefaults {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	if s.defaults == nil {
+		return &models.AddressDefaults{}
+	}
+	return s.defaults
+}
+
+// SetDefaults заменяет параметры по умолчанию, используется при перечитывании конфигурации
+func (s *AddressService) SetDefaults(defaults *models.AddressDefaults) {
+	s.mx.Lock()
+	s.defaults = defaults
+	s.mx.Unlock()
+}
+
+func (s *AddressService) Subscribe(observer AddressObserver) {
+	s.observers = append(s.observers, observer)
+}
+
+// SetAudit подключает журнал изменений. Сервис журнала сам зависит от сервиса адресов, поэтому подключается после создания
+func (s *AddressService) SetAudit(audit Audit) {
+	s.audit = audit
+}
+
+// audited выполняет изменение и записывает его в журнал, если в контексте указан автор (запросы API).
+// Команды из чата, кнопки и формы записывают изменения сами, одной записью на команду, чтобы ее можно было отменить
+func (s *AddressService) audited(ctx context.Context, action string, ips []string, change func() error) error {
+	actor, ok := ctx.Value(AuditActorKey).(*models.Post)
+	if !ok || s.audit == nil {
+		return change()
+	}
+
+	before, err := s.audit.Snapshot(ctx, ips)
+	if err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	if err := s.audit.Record(ctx, actor, action, ips, before); err != nil {
+		logger.Error("failed to record audit.", logger.StringAttr("command", actor.Message), logger.ErrAttr(err))
+	}
+	return nil
+}
+
+func toggleAction(enabled bool) string {
+	if enabled {
+		return models.AuditEnable
+	}
+	return models.AuditDisable
+}
+
+// checkManaged запрещает изменение адресов из файла конфигурации. Отсутствующий адрес не считается ошибкой
+func (s *AddressService) checkManaged(ctx context.Context, ip string) error {
+	data, err := s.repo.GetByIP(ctx, ip)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to get address by ip. error: %w", err)
+	}
+	if data.Managed {
+		return models.ErrManaged
+	}
+	return nil
+}
+
+func (s *AddressService) notifyChanged(ctx context.Context, ip string) {
+	if len(s.observers) == 0 {
+		return
+	}
+
+	address, err := s.repo.GetByIP(ctx, ip)
+	if err != nil {
+		logger.Error("failed to get changed address.", logger.StringAttr("ip", ip), logger.ErrAttr(err))
+		return
+	}
+	for _, o := range s.observers {
+		o.AddressChanged(ctx, address)
+	}
+}
+
+// addressFromConf проверяет адрес из файла конфигурации и переводит его в формат для сохранения
+func addressFromConf(conf *models.AddressConf) (*models.AddressDTO, error) {
+	addr, err := netip.ParseAddr(strings.TrimSpace(conf.IP))
+	if err != nil {
+		return nil, models.ErrInvalidAddress
+	}
+	windows, err := models.ParseWindows(conf.Period)
+	if err != nil {
+		return nil, fmt.Errorf("invalid period %q", conf.Period)
+	}
+	if windows == nil {
+		windows = []*models.Window{}
+	}
+
+	managed, enabled := true, true
+	if conf.Enabled != nil {
+		enabled = *conf.Enabled
+	}
+	groups := conf.Groups
+	if groups == nil {
+		groups = []string{}
+	}
+	dto := &models.AddressDTO{
+		IP:                addr.String(),
+		Name:              &conf.Name,
+		Groups:            groups,
+		NotificationCount: conf.Notification,
+		Windows:           windows,
+		TimeZone:          &conf.TimeZone,
+		SkipHolidays:      &conf.SkipHolidays,
+		CheckInterval:     &conf.CheckInterval,
+		Cron:              &conf.Cron,
+		Enabled:           &enabled,
+		Managed:           &managed,
+	}
+	// нулевые параметры проверки не изменяют адрес, при добавлении берутся значения по умолчанию
+	if conf.MaxRTT > 0 {
+		dto.MaxRTT = &conf.MaxRTT
+	}
+	if conf.Count > 0 {
+		dto.Count = &conf.Count
+	}
+	if conf.Interval > 0 {
+		dto.Interval = &conf.Interval
+	}
+	if conf.Timeout > 0 {
+		dto.Timeout = &conf.Timeout
+	}
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/pkg/logger"
+	"github.com/goodsign/monday"
+)
+
+// количество простоев в истории, которая показывается по кнопке
+const alertHistorySize = 10
+
+// AlertService обрабатывает кнопки под уведомлениями и хранит подтверждения и отключения уведомлений
+type AlertService struct {
+	addresses Address
+	stats     Statistic
+	post      Post
+	roles     Role
+	audit     Audit
+	conf      *models.AlertsConf
+
+	mx     sync.Mutex
+	states map[string]*models.AlertState
+}
+
+type AlertDeps struct {
+	Address Address
+	Stats   Statistic
+	Post    Post
+	Role    Role
+	Audit   Audit
+	Conf    *models.AlertsConf
+}
+
+func NewAlertService(deps *AlertDeps) *AlertService {
+	return &AlertService{
+		addresses: deps.Address,
+		stats:     deps.Stats,
+		post:      deps.Post,
+		roles:     deps.Role,
+		audit:     deps.Audit,
+		conf:      deps.Conf,
+		states:    make(map[string]*models.AlertState),
+	}
+}
+
+type Alert interface {
+	Actions(ip string) []*models.Action
+	Muted(ip string) bool
+	Resolve(ip string)
+	Handle(ctx context.Context, req *models.ActionRequest) (string, error)
+	AddressObserver
+}
+
+// Actions возвращает кнопки для уведомления о недоступности адреса
+func (s *AlertService) Actions(ip string) []*models.Action {
+	if s.conf.URL == "" {
+		return nil
+	}
+
+	action := func(id, name, style string) *models.Action {
+		return &models.Action{
+			ID:      id,
+			Name:    name,
+			Style:   style,
+			URL:     s.conf.URL,
+			Context: map[string]any{"action": id, "ip": ip, "sign": s.sign(id, ip)},
+		}
+	}
+	return []*models.Action{
+		action(models.ActionAck, "Принято", "primary"),
+		action(models.ActionSilence, "Тишина на "+formatSilence(s.conf.SilenceDuration), "default"),
+		action(models.ActionDisable, "Отключить", "danger"),
+		action(models.ActionHistory, "История", "default"),
+	}
+}
+
+// Muted проверяет, нужно ли пропускать повторные уведомления по адресу
+func (s *AlertService) Muted(ip string) bool {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	state, ok := s.states[ip]
+	if !ok {
+		return false
+	}
+	return state.AckBy != "" || time.Now().Before(state.SilencedUntil)
+}
+
+// AddressChanged сбрасывает подтверждение и тишину отключенного адреса, после включения уведомления приходят заново
+func (s *AlertService) AddressChanged(ctx context.Context, address *models.Address) {
+	if !address.Enabled {
+		s.clear(address.IP)
+	}
+}
+
+func (s *AlertService) AddressDeleted(ctx context.Context, ip string) {
+	s.clear(ip)
+}
+
+func (s *AlertService) clear(ip string) {
+	s.mx.Lock()
+	delete(s.states, ip)
+	s.mx.Unlock()
+}
+
+// Resolve снимает подтверждение после восстановления адреса, чтобы о новом сбое снова пришло уведомление.
+// Тишина действует до окончания периода
+func (s *AlertService) Resolve(ip string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	state, ok := s.states[ip]
+	if !ok {
+		return
+	}
+	state.AckBy = ""
+	if time.Now().After(state.SilencedUntil) {
+		delete(s.states, ip)
+	}
+}
+
+// Handle выполняет действие кнопки и возвращает текст, который увидит только нажавший пользователь
+func (s *AlertService) Handle(ctx context.Context, req *models.ActionRequest) (string, error) {
+	action, _ := req.Context["action"].(string)
+	ip, _ := req.Context["ip"].(string)
+	sign, _ := req.Context["sign"].(string)
+	if action == "" || ip == "" || !hmac.Equal([]byte(sign), []byte(s.sign(action, ip))) {
+		return "", models.ErrInvalidAction
+	}
+	// история доступна всем, остальные кнопки меняют уведомления и проверки
+	if action != models.ActionHistory && !s.roles.Allowed(ctx, req.UserID, req.ChannelID, models.RoleOperator) {
+		logger.Warn("alert action denied", logger.StringAttr("action", action), logger.StringAttr("ip", ip), logger.StringAttr("user", req.UserName))
+		return "Недостаточно прав. Действие доступно операторам и администраторам.", nil
+	}
+
+	var note string
+	switch action {
+	case models.ActionAck:
+		s.mx.Lock()
+		s.state(ip).AckBy = req.UserName
+		s.mx.Unlock()
+		note = fmt.Sprintf("@%s принял уведомление", req.UserName)
+
+	case models.ActionSilence:
+		until := time.Now().Add(s.conf.SilenceDuration)
+		s.mx.Lock()
+		state := s.state(ip)
+		state.SilencedBy = req.UserName
+		state.SilencedUntil = until
+		s.mx.Unlock()
+		note = fmt.Sprintf("@%s отключил уведомления до %s", req.UserName, until.Format("15:04"))
+
+	case models.ActionDisable:
+		// нажатие записывается в журнал изменений, чтобы было видно, кто отключил адрес, и отключение можно было отменить
+		before, err := s.audit.Snapshot(ctx, []string{ip})
+		if err != nil {
+			return "", err
+		}
+		if err := s.addresses.ToggleActive(ctx, ip, false); err != nil {
+			if errors.Is(err, models.ErrNoRows) {
+				return "Адрес не найден.", nil
+			}
+			if errors.Is(err, models.ErrManaged) {
+				return "Адрес задан в файле конфигурации, отключить его можно только там.", nil
+			}
+			return "", err
+		}
+		change := &models.Post{UserID: req.UserID, ChannelID: req.ChannelID, Message: "кнопка «Отключить»"}
+		if err := s.audit.Record(ctx, change, models.AuditDisable, []string{ip}, before); err != nil {
+			logger.Error("failed to record audit.", logger.StringAttr("ip", ip), logger.ErrAttr(err))
+		}
+		note = fmt.Sprintf("@%s отключил проверку адреса", req.UserName)
+
+	case models.ActionHistory:
+		return s.history(ctx, ip)
+
+	default:
+		return "", models.ErrInvalidAction
+	}
+
+	post, err := s.post.Get(req.PostID)
+	if err != nil {
+		return "", err
+	}
+	message := fmt.Sprintf("%s\n_%s (%s)_", post.Message, note, time.Now().Format("02.01.2006 15:04"))
+	if err := s.post.Patch(req.PostID, message); err != nil {
+		return "", err
+	}
+	logger.Info("alert action", logger.StringAttr("action", action), logger.StringAttr("ip", ip), logger.StringAttr("user", req.UserName))
+	return "", nil
+}
+
+func (s *AlertService) history(ctx context.Context, ip string) (string, error) {
+	now := time.Now()
+	data, err := s.stats.GetByIP(ctx, &models.GetStatisticByIPDTO{IP: ip, PeriodStart: now.AddDate(0, -1, 0), PeriodEnd: now})
+	if err != nil {
+		return "", err
+	}
+	if len(data) == 0 {
+		return fmt.Sprintf("За последний месяц адрес %s был доступен.", ip), nil
+	}
+	if len(data) > alertHistorySize {
+		data = data[len(data)-alertHistorySize:]
+	}
+
+	table := []string{
+		fmt.Sprintf("Последние простои адреса %s:", ip),
+		"| С | По | Длительность |",
+		"|:--|:--|:--|",
+	}
+	format := "Mon 2 Jan 15:04"
+	for _, d := range data {
+		table = append(table, fmt.Sprintf("|%s|%s|%s|", monday.Format(d.TimeStart, format, monday.LocaleRuRU),
+			monday.Format(d.TimeEnd, format, monday.LocaleRuRU), d.Time.String()))
+	}
+	return strings.Join(table, "\n"), nil
+}
+
+// state возвращает состояние уведомлений по адресу, вызывается под блокировкой
+func (s *AlertService) state(ip string) *models.AlertState {
+	state, ok := s.states[ip]
+	if !ok {
+		state = &models.AlertState{}
+		s.states[ip] = state
+	}
+	return state
+}
+
+func (s *AlertService) sign(action, ip string) string {
+	mac := hmac.New(sha256.New, []byte(s.conf.Secret))
+	mac.Write([]byte(action + ":" + ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func formatSilence(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dч", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dм", int(d.Minutes()))
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/repo"
+	"github.com/Alexander272/Pinger/pkg/logger"
+)
+
+// AuditService журнал изменений адресов. Перед выполнением команды сохраняется состояние адресов,
+// после выполнения в журнал записываются адреса, которые изменились
+type AuditService struct {
+	repo      repo.Audit
+	addresses Address
+	users     User
+}
+
+type AuditDeps struct {
+	Repo    repo.Audit
+	Address Address
+	User    User
+}
+
+func NewAuditService(deps *AuditDeps) *AuditService {
+	return &AuditService{
+		repo:      deps.Repo,
+		addresses: deps.Address,
+		users:     deps.User,
+	}
+}
+
+// AuditActorKey ключ контекста с автором изменений (*models.Post). Если автор задан, сервис адресов
+// сам записывает изменения в журнал, так записываются изменения через API
+const AuditActorKey = "audit_actor"
+
+type Audit interface {
+	Get(context.Context, *models.AuditFilter) ([]*models.AuditEntry, error)
+	Snapshot(ctx context.Context, ips []string) (map[string]*models.Address, error)
+	Record(ctx context.Context, post *models.Post, action string, ips []string, before map[string]*models.Address) error
+	Undo(ctx context.Context, post *models.Post) (*models.UndoResult, error)
+}
+
+// Get возвращает записи журнала с заполненным списком измененных полей
+func (s *AuditService) Get(ctx context.Context, filter *models.AuditFilter) ([]*models.AuditEntry, error) {
+	data, err := s.repo.Get(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log. error: %w", err)
+	}
+	for _, entry := range data {
+		entry.Changes = auditChanges(entry)
+	}
+	return data, nil
+}
+
+// Snapshot возвращает текущее состояние адресов. Адресов, которых нет в базе, в результате нет
+func (s *AuditService) Snapshot(ctx context.Context, ips []string) (map[string]*models.Address, error) {
+	addresses, err := s.addresses.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get addresses. error: %w", err)
+	}
+
+	wanted := make(map[string]struct{}, len(ips))
+	for _, ip := range ips {
+		wanted[ip] = struct{}{}
+	}
+	snapshot := make(map[string]*models.Address, len(ips))
+	for _, address := range addresses {
+		if _, ok := wanted[address.IP]; ok {
+			snapshot[address.IP] = address
+		}
+	}
+	return snapshot, nil
+}
+
+// Record сравнивает адреса с состоянием до выполнения команды и записывает изменившиеся.
+// Действие уточняется по состоянию: появившийся адрес записывается как добавленный, пропавший - как удаленный.
+// Восстановление и отмена записываются как есть
+func (s *AuditService) Record(ctx context.Context, post *models.Post, action string, ips []string, before map[string]*models.Address) error {
+	after, err := s.Snapshot(ctx, ips)
+	if err != nil {
+		return err
+	}
+
+	username := post.UserName
+	if username == "" && post.UserID != "" {
+		user, err := s.users.Get(post.UserID)
+		if err != nil {
+			logger.Error("failed to get user.", logger.StringAttr("user", post.UserID), logger.ErrAttr(err))
+		} else {
+			username = user.Username
+		}
+	}
+
+	entries := []*models.AuditEntry{}
+	for _, ip := range ips {
+		old, new := before[ip], after[ip]
+		if (old == nil && new == nil) || reflect.DeepEqual(old, new) {
+			continue
+		}
+
+		entry := &models.AuditEntry{
+			IP:        ip,
+			Action:    action,
+			UserID:    post.UserID,
+			Username:  username,
+			ChannelID: post.ChannelID,
+			Command:   post.Message,
+			Before:    old,
+			After:     new,
+		}
+		if action != models.AuditRestore && action != models.AuditUndo {
+			if old == nil {
+				entry.Action = models.AuditCreate
+			} else if new == nil {
+				entry.Action = models.AuditDelete
+			}
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if err := s.repo.Create(ctx, entries); err != nil {
+		return fmt.Errorf("failed to create audit entries. error: %w", err)
+	}
+	return nil
+}
+
+// Undo отменяет последнюю команду пользователя: добавленные адреса удаляются, удаленные восстанавливаются,
+// измененным возвращаются прежние параметры. Адреса, которые изменились после команды, не трогаются,
+// но команда все равно считается отмененной, чтобы повторный вызов отменял предыдущую команду
+func (s *AuditService) Undo(ctx context.Context, post *models.Post) (*models.UndoResult, error) {
+	entries, err := s.repo.GetLast(ctx, post.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last audit entries. error: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil, models.ErrNoRows
+	}
+
+	ips := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ips = append(ips, entry.IP)
+	}
+	before, err := s.Snapshot(ctx, ips)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &models.UndoResult{Command: entries[0].Command, Results: make([]*models.BulkResult, 0, len(entries))}
+	undone, done := []int64{}, []string{}
+	defer func() {
+		if len(undone) == 0 {
+			return
+		}
+		if err := s.Record(ctx, post, models.AuditUndo, done, before); err != nil {
+			logger.Error("failed to record undo.", logger.ErrAttr(err))
+		}
+		if err := s.repo.SetUndone(ctx, undone); err != nil {
+			logger.Error("failed to mark audit entries undone.", logger.ErrAttr(err))
+		}
+	}()
+
+	for _, entry := range entries {
+		result := &models.BulkResult{IP: entry.IP, Status: models.BulkDone}
+		res.Results = append(res.Results, result)
+
+		if !sameAddress(before[entry.IP], entry.After) {
+			result.Status = models.BulkChanged
+			undone = append(undone, entry.ID)
+			continue
+		}
+
+		switch {
+		case entry.Before == nil:
+			err = s.addresses.Delete(ctx, entry.IP)
+		case entry.After == nil:
+			err = s.addresses.Restore(ctx, entry.IP)
+		default:
+			dto := &models.AddressDTO{IP: entry.IP}
+			dto.Fill(entry.Before)
+			err = s.addresses.Update(ctx, dto)
+		}
+		switch {
+		case errors.Is(err, models.ErrManaged):
+			result.Status = models.BulkManaged
+		case errors.Is(err, models.ErrNoRows):
+			result.Status = models.BulkNotFound
+		case err != nil:
+			return nil, fmt.Errorf("failed to undo change of %s. error: %w", entry.IP, err)
+		default:
+			done = append(done, entry.IP)
+		}
+		undone = append(undone, entry.ID)
+	}
+	return res, nil
+}
+
+// sameAddress сравнивает параметры адресов. Служебные поля (id, время создания) не учитываются,
+// так как состояние в журнале хранится в json
+func sameAddress(a, b *models.Address) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return len(diffAddress(a, b)) == 0
+}
+
+// auditChanges измененные поля адреса. У добавленного адреса перечисляются все заданные поля, у удаленного - ни одного
+func auditChanges(entry *models.AuditEntry) []*models.FieldChange {
+	switch {
+	case entry.After == nil:
+		return []*models.FieldChange{}
+	case entry.Before == nil:
+		return diffAddress(&models.Address{}, entry.After)
+	default:
+		return diffAddress(entry.Before, entry.After)
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"sync"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/pkg/logger"
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// ChannelService определяет, какие команды бот принимает в канале
+type ChannelService struct {
+	client *model.Client4
+
+	confMx sync.RWMutex
+	conf   *models.ChannelsConf
+	access map[string]string
+
+	mx    sync.RWMutex
+	types map[string]string
+}
+
+func NewChannelService(client *model.Client4, conf *models.ChannelsConf) *ChannelService {
+	service := &ChannelService{
+		client: client,
+		types:  make(map[string]string),
+	}
+	service.SetConf(conf)
+	return service
+}
+
+type Channel interface {
+	Access(channelID, channelType string) string
+	SetConf(conf *models.ChannelsConf)
+}
+
+// SetConf заменяет список каналов, используется при перечитывании конфигурации
+func (s *ChannelService) SetConf(conf *models.ChannelsConf) {
+	access := make(map[string]string, len(conf.Channels))
+	for _, channel := range conf.Channels {
+		mode := models.ChannelAccessFull
+		if channel.Access == models.ChannelAccessRead {
+			mode = models.ChannelAccessRead
+		}
+		access[channel.ID] = mode
+	}
+
+	s.confMx.Lock()
+	s.conf = conf
+	s.access = access
+	s.confMx.Unlock()
+}
+
+// Access возвращает доступ к командам в канале. Тип канала приходит вместе с сообщением,
+// если он не известен (slash-команда), то запрашивается у Mattermost
+func (s *ChannelService) Access(channelID, channelType string) string {
+	if channelType == "" {
+		channelType = s.channelType(channelID)
+	}
+
+	s.confMx.RLock()
+	defer s.confMx.RUnlock()
+
+	if channelType == string(model.ChannelTypeDirect) {
+		if s.conf.Direct {
+			return models.ChannelAccessFull
+		}
+		return models.ChannelAccessNone
+	}
+
+	if access, ok := s.access[channelID]; ok {
+		return access
+	}
+	if len(s.access) == 0 && channelID == s.conf.Default {
+		return models.ChannelAccessFull
+	}
+	return models.ChannelAccessNone
+}
+
+func (s *ChannelService) channelType(channelID string) string {
+	s.mx.RLock()
+	channelType, ok := s.types[channelID]
+	s.mx.RUnlock()
+	if ok {
+		return channelType
+	}
+
+	channel, _, err := s.client.GetChannel(channelID, "")
+	if err != nil {
+		logger.Error("failed to get channel.", logger.StringAttr("channel", channelID), logger.ErrAttr(err))
+		return ""
+	}
+
+	s.mx.Lock()
+	s.types[channelID] = string(channel.Type)
+	s.mx.Unlock()
+	return string(channel.Type)
+}
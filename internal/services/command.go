@@ -0,0 +1,98 @@
+package services
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"sync"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/pkg/logger"
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+type CommandService struct {
+	client *model.Client4
+	conf   *models.SlashCommand
+
+	mx     sync.RWMutex
+	tokens []string
+}
+
+func NewCommandService(client *model.Client4, conf *models.SlashCommand) *CommandService {
+	service := &CommandService{
+		client: client,
+		conf:   conf,
+	}
+	if conf.Token != "" {
+		service.tokens = append(service.tokens, conf.Token)
+	}
+	return service
+}
+
+type Command interface {
+	Register() error
+	Verify(token string) bool
+}
+
+// Register создает slash-команду в команде Mattermost, если ее еще нет, и запоминает ее токен
+func (s *CommandService) Register() error {
+	if s.conf.TeamID == "" || s.conf.URL == "" {
+		return nil
+	}
+
+	commands, _, err := s.client.ListCommands(s.conf.TeamID, true)
+	if err != nil {
+		return fmt.Errorf("failed to list commands. error: %w", err)
+	}
+
+	for _, cmd := range commands {
+		if cmd.Trigger != s.conf.Trigger {
+			continue
+		}
+		if cmd.URL != s.conf.URL {
+			cmd.URL = s.conf.URL
+			if _, _, err := s.client.UpdateCommand(cmd); err != nil {
+				return fmt.Errorf("failed to update command. error: %w", err)
+			}
+		}
+		s.addToken(cmd.Token)
+		return nil
+	}
+
+	cmd, _, err := s.client.CreateCommand(&model.Command{
+		TeamId:           s.conf.TeamID,
+		Trigger:          s.conf.Trigger,
+		Method:           model.CommandMethodPost,
+		URL:              s.conf.URL,
+		DisplayName:      "Pinger",
+		Description:      "Управление проверкой доступности адресов",
+		AutoComplete:     true,
+		AutoCompleteDesc: "Управление проверкой доступности адресов. help - список команд",
+		AutoCompleteHint: "[команда] [параметры]",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create command. error: %w", err)
+	}
+	s.addToken(cmd.Token)
+	logger.Info("slash command registered", logger.StringAttr("trigger", cmd.Trigger))
+	return nil
+}
+
+// Verify проверяет токен, переданный Mattermost вместе с командой
+func (s *CommandService) Verify(token string) bool {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	for _, t := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *CommandService) addToken(token string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.tokens = append(s.tokens, token)
+}
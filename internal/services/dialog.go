@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/pkg/logger"
+	"github.com/robfig/cron/v3"
+)
+
+// DialogService открывает формы добавления и изменения адресов и обрабатывает их отправку
+type DialogService struct {
+	addresses Address
+	post      Post
+	roles     Role
+	audit     Audit
+	conf      *models.DialogConf
+}
+
+type DialogDeps struct {
+	Address Address
+	Post    Post
+	Role    Role
+	Audit   Audit
+	Conf    *models.DialogConf
+}
+
+func NewDialogService(deps *DialogDeps) *DialogService {
+	return &DialogService{
+		addresses: deps.Address,
+		post:      deps.Post,
+		roles:     deps.Role,
+		audit:     deps.Audit,
+		conf:      deps.Conf,
+	}
+}
+
+type Dialog interface {
+	Open(ctx context.Context, post *models.Post, ip string) error
+	OpenFromAction(ctx context.Context, req *models.ActionRequest) error
+	Submit(ctx context.Context, req *models.DialogSubmission) (*models.DialogErrors, error)
+}
+
+// Open открывает форму адреса. Пустой ip - форма добавления, иначе форма изменения с текущими значениями.
+// Если открыть форму нельзя (команда пришла сообщением), отправляется кнопка, открывающая форму
+func (s *DialogService) Open(ctx context.Context, post *models.Post, ip string) error {
+	if s.conf.URL == "" {
+		return models.ErrDialogDisabled
+	}
+
+	if post.TriggerID == "" {
+		if s.conf.ActionsURL == "" {
+			return models.ErrDialogDisabled
+		}
+		if ip != "" {
+			data, err := s.addresses.GetByIP(ctx, ip)
+			if err != nil {
+				return err
+			}
+			if data.Managed {
+				return models.ErrManaged
+			}
+		}
+		return s.post.Send(&models.Post{
+			ChannelID: post.ChannelID,
+			Message:   "Заполните параметры адреса в форме.",
+			Actions: []*models.Action{{
+				ID:      models.ActionDialog,
+				Name:    "Открыть форму",
+				Style:   "primary",
+				URL:     s.conf.ActionsURL,
+				Context: map[string]any{"action": models.ActionDialog, "ip": ip},
+			}},
+		})
+	}
+
+	dialog, err := s.addressDialog(ctx, ip)
+	if err != nil {
+		return err
+	}
+	return s.post.OpenDialog(post.TriggerID, dialog)
+}
+
+// OpenFromAction открывает форму по нажатию кнопки
+func (s *DialogService) OpenFromAction(ctx context.Context, req *models.ActionRequest) error {
+	ip, _ := req.Context["ip"].(string)
+	return s.Open(ctx, &models.Post{ChannelID: req.ChannelID, UserID: req.UserID, TriggerID: req.TriggerID}, ip)
+}
+
+// Submit проверяет и сохраняет заполненную форму. Ошибки в полях возвращаются, чтобы показать их рядом с полями
+func (s *DialogService) Submit(ctx context.Context, req *models.DialogSubmission) (*models.DialogErrors, error) {
+	if req.CallbackID != models.DialogAddress {
+		return nil, models.ErrInvalidDialog
+	}
+	ip, sign, ok := strings.Cut(req.State, ":")
+	if !ok || !hmac.Equal([]byte(sign), []byte(s.sign(ip))) {
+		return nil, models.ErrInvalidDialog
+	}
+	if !s.roles.Allowed(ctx, req.UserID, req.ChannelID, models.RoleOperator) {
+		logger.Warn("dialog submission denied", logger.StringAttr("ip", ip), logger.StringAttr("user", req.UserID))
+		return &models.DialogErrors{Error: "Недостаточно прав. Добавлять и изменять адреса могут операторы и администраторы."}, nil
+	}
+	editing := ip != ""
+
+	values := make(map[string]string, len(req.Values))
+	for name, value := range req.Values {
+		values[name] = formValue(value)
+	}
+	if !editing {
+		ip = values["ip"]
+	}
+
+	dto, fields := parseAddressForm(values)
+	dto.IP = ip
+	if !editing && net.ParseIP(ip) == nil {
+		fields["ip"] = "Некорректный IP адрес."
+	}
+	if len(fields) > 0 {
+		return &models.DialogErrors{Fields: fields}, nil
+	}
+
+	before, err := s.audit.Snapshot(ctx, []string{ip})
+	if err != nil {
+		return nil, err
+	}
+
+	action, message := models.AuditCreate, fmt.Sprintf("IP адрес %s добавлен.", ip)
+	if editing {
+		data, err := s.addresses.GetByIP(ctx, ip)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRows) {
+				return &models.DialogErrors{Error: "Адрес не найден, возможно его уже удалили."}, nil
+			}
+			return nil, err
+		}
+		dto.Fill(data)
+		if err := s.addresses.Update(ctx, dto); err != nil {
+			if errors.Is(err, models.ErrManaged) {
+				return &models.DialogErrors{Error: "Адрес задан в файле конфигурации, изменить его можно только там."}, nil
+			}
+			if errors.Is(err, models.ErrInvalidAddress) {
+				return &models.DialogErrors{Error: "Некорректные параметры адреса."}, nil
+			}
+			return nil, err
+		}
+		action, message = models.AuditUpdate, fmt.Sprintf("IP адрес %s обновлен.", ip)
+	} else if err := s.addresses.Create(ctx, dto); err != nil {
+		if errors.Is(err, models.ErrExist) {
+			return &models.DialogErrors{Fields: map[string]string{"ip": "IP адрес уже добавлен."}}, nil
+		}
+		if errors.Is(err, models.ErrInvalidAddress) {
+			return &models.DialogErrors{Error: "Некорректные параметры адреса."}, nil
+		}
+		return nil, err
+	}
+
+	change := &models.Post{UserID: req.UserID, ChannelID: req.ChannelID, Message: "форма адреса"}
+	if err := s.audit.Record(ctx, change, action, []string{ip}, before); err != nil {
+		logger.Error("failed to record audit.", logger.StringAttr("ip", ip), logger.ErrAttr(err))
+	}
+	logger.Info("address saved from dialog", logger.StringAttr("ip", ip), logger.StringAttr("user", req.UserID))
+	s.post.Send(&models.Post{ChannelID: req.ChannelID, Message: message})
+	return nil, nil
+}
+
+func (s *DialogService) addressDialog(ctx context.Context, ip string) (*models.Dialog, error) {
+	dialog := &models.Dialog{
+		CallbackID:  models.DialogAddress,
+		URL:         s.conf.URL,
+		Title:       "Новый адрес",
+		SubmitLabel: "Добавить",
+		State:       ip + ":" + s.sign(ip),
+	}
+
+	address := &models.Address{Count: 5, Interval: 100 * time.Millisecond, Timeout: time.Second, NotificationCount: 3, Enabled: true}
+	defaults := &models.AddressDTO{}
+	defaults.SetDefaults(s.addresses.Defaults())
+	address = defaults.Apply(address)
+	if ip != "" {
+		data, err := s.addresses.GetByIP(ctx, ip)
+		if err != nil {
+			return nil, err
+		}
+		if data.Managed {
+			return nil, models.ErrManaged
+		}
+		address = data
+		dialog.Title = "Изменение адреса"
+		dialog.Intro = fmt.Sprintf("IP адрес **%s**", ip)
+		dialog.SubmitLabel = "Сохранить"
+	} else {
+		dialog.Elements = append(dialog.Elements, &models.DialogElement{
+			DisplayName: "IP адрес", Name: "ip", Type: "text", Placeholder: "10.0.0.1",
+		})
+	}
+
+	number := func(v int64) string { return strconv.FormatInt(v, 10) }
+	dialog.Elements = append(dialog.Elements,
+		&models.DialogElement{DisplayName: "Название", Name: "name", Type: "text", Default: address.Name, Optional: true},
+		&models.DialogElement{DisplayName: "Группы", Name: "groups", Type: "text", Default: strings.Join(address.Groups, ", "),
+			Optional: true, HelpText: "Через запятую"},
+		&models.DialogElement{DisplayName: "Допустимое время пинга, мс", Name: "rtt", Type: "text", SubType: "number",
+			Default: number(address.MaxRTT.Milliseconds()), Optional: true, HelpText: "0 - не проверять"},
+		&models.DialogElement{DisplayName: "Количество пакетов", Name: "count", Type: "text", SubType: "number",
+			Default: number(int64(address.Count)), Optional: true},
+		&models.DialogElement{DisplayName: "Интервал между пакетами, мс", Name: "interval", Type: "text", SubType: "number",
+			Default: number(address.Interval.Milliseconds()), Optional: true},
+		&models.DialogElement{DisplayName: "Таймаут, мс", Name: "timeout", Type: "text", SubType: "number",
+			Default: number(address.Timeout.Milliseconds()), Optional: true},
+		&models.DialogElement{DisplayName: "Количество уведомлений", Name: "notification", Type: "text", SubType: "number",
+			Default: number(int64(address.NotificationCount)), Optional: true, HelpText: "0 - без ограничений"},
+		&models.DialogElement{DisplayName: "Периоды проверок", Name: "period", Type: "text", Default: models.FormatWindows(address.Windows),
+			Optional: true, Placeholder: "пн-пт 09:00-18:00; сб 10:00-14:00", HelpText: "Пусто - проверять всегда"},
+		&models.DialogElement{DisplayName: "Часовой пояс", Name: "timezone", Type: "text", Default: address.TimeZone,
+			Optional: true, Placeholder: "Europe/Moscow", HelpText: "Пусто - часовой пояс сервера"},
+		&models.DialogElement{DisplayName: "Не проверять в праздники", Name: "holidays", Type: "bool",
+			Default: strconv.FormatBool(address.SkipHolidays), Optional: true},
+		&models.DialogElement{DisplayName: "Интервал проверок, с", Name: "every", Type: "text", SubType: "number",
+			Default: number(int64(address.CheckInterval.Seconds())), Optional: true, HelpText: "0 - интервал планировщика"},
+		&models.DialogElement{DisplayName: "Расписание cron", Name: "schedule", Type: "text", Default: address.Cron,
+			Optional: true, Placeholder: "*/5 9-18 * * 1-5", HelpText: "Используется вместо интервала"},
+		&models.DialogElement{DisplayName: "Проверка включена", Name: "enabled", Type: "bool",
+			Default: strconv.FormatBool(address.Enabled), Optional: true},
+	)
+	return dialog, nil
+}
+
+func (s *DialogService) sign(ip string) string {
+	mac := hmac.New(sha256.New, []byte(s.conf.Secret))
+	mac.Write([]byte(models.DialogAddress + ":" + ip))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// parseAddressForm разбирает поля формы адреса. Пустые числовые поля не изменяют значение
+func parseAddressForm(values map[string]string) (*models.AddressDTO, map[string]string) {
+	dto := &models.AddressDTO{}
+	fields := make(map[string]string)
+
+	integer := func(name string, min int) (*int, bool) {
+		value := strings.TrimSpace(values[name])
+		if value == "" {
+			return nil, true
+		}
+		res, err := strconv.Atoi(value)
+		if err != nil || res < min {
+			fields[name] = fmt.Sprintf("Нужно целое число не меньше %d.", min)
+			return nil, false
+		}
+		return &res, true
+	}
+	duration := func(name string, min int, unit time.Duration) *time.Duration {
+		value, ok := integer(name, min)
+		if !ok || value == nil {
+			return nil
+		}
+		res := time.Duration(*value) * unit
+		return &res
+	}
+
+	name := strings.TrimSpace(values["name"])
+	dto.Name = &name
+	dto.Groups = models.ParseGroups(values["groups"])
+	dto.MaxRTT = duration("rtt", 0, time.Millisecond)
+	dto.Count, _ = integer("count", 1)
+	dto.Interval = duration("interval", 1, time.Millisecond)
+	dto.Timeout = duration("timeout", 1, time.Millisecond)
+	dto.NotificationCount, _ = integer("notification", 0)
+	dto.CheckInterval = duration("every", 0, time.Second)
+
+	windows, err := models.ParseWindows(values["period"])
+	if err != nil {
+		fields["period"] = "Некорректный период. Пример: пн-пт 09:00-18:00; сб 10:00-14:00"
+	}
+	dto.Windows = windows
+
+	tz := strings.TrimSpace(values["timezone"])
+	if _, err := time.LoadLocation(tz); err != nil {
+		fields["timezone"] = "Неизвестный часовой пояс."
+	}
+	dto.TimeZone = &tz
+
+	schedule := strings.TrimSpace(values["schedule"])
+	if schedule != "" {
+		if _, err := cron.ParseStandard(schedule); err != nil {
+			fields["schedule"] = "Некорректное расписание."
+		}
+	}
+	dto.Cron = &schedule
+
+	for _, name := range []string{"holidays", "enabled"} {
+		value, err := strconv.ParseBool(values[name])
+		if err != nil {
+			value = false
+		}
+		if name == "holidays" {
+			dto.SkipHolidays = &value
+		} else {
+			dto.Enabled = &value
+		}
+	}
+
+	return dto, fields
+}
+
+// formValue приводит значение поля формы к строке. Mattermost присылает числа и флаги без кавычек
+func formValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/pkg/events"
+)
+
+// EventService внутренняя шина событий проверок. Сервис пинга публикует события,
+// уведомления в mattermost и поток событий для внешних клиентов получают их по подписке
+type EventService struct {
+	bus *events.Bus[*models.Event]
+}
+
+func NewEventService() *EventService {
+	return &EventService{
+		bus: events.NewBus[*models.Event](),
+	}
+}
+
+type Events interface {
+	Publish(event *models.Event)
+	Subscribe(filter *models.EventFilter, size int) *events.Subscription[*models.Event]
+}
+
+func (s *EventService) Publish(event *models.Event) {
+	s.bus.Publish(event)
+}
+
+func (s *EventService) Subscribe(filter *models.EventFilter, size int) *events.Subscription[*models.Event] {
+	if filter == nil {
+		return s.bus.Subscribe(size, nil)
+	}
+	return s.bus.Subscribe(size, filter.Match)
+}
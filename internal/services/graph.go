@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/pkg/charts"
+)
+
+// максимальное количество точек на графике, при большем количестве проверки усредняются
+const maxGraphPoints = 1000
+
+type GraphService struct {
+	measurements Measurement
+	stats        Statistic
+	addresses    Address
+}
+
+type GraphDeps struct {
+	Measurement Measurement
+	Stats       Statistic
+	Address     Address
+}
+
+func NewGraphService(deps *GraphDeps) *GraphService {
+	return &GraphService{
+		measurements: deps.Measurement,
+		stats:        deps.Stats,
+		addresses:    deps.Address,
+	}
+}
+
+type Graph interface {
+	Render(ctx context.Context, req *models.GraphDTO) ([]byte, error)
+}
+
+// Render строит график задержки и потерь по адресу за период. Периоды недоступности выделяются цветом
+func (s *GraphService) Render(ctx context.Context, req *models.GraphDTO) ([]byte, error) {
+	address, err := findAddress(ctx, s.addresses, req.IP)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := s.measurements.Get(ctx, &models.GetMeasurementsDTO{IP: req.IP, PeriodStart: req.PeriodStart, PeriodEnd: req.PeriodEnd})
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, models.ErrNoRows
+	}
+
+	outages, err := s.stats.GetOutages(ctx, &models.GetOutagesDTO{IPs: []string{req.IP}, PeriodStart: req.PeriodStart, PeriodEnd: req.PeriodEnd})
+	if err != nil {
+		return nil, err
+	}
+
+	title := req.IP
+	if address.Name != "" {
+		title = fmt.Sprintf("%s (%s)", address.Name, req.IP)
+	}
+	chart := &charts.RttChart{
+		Title:     title,
+		Start:     req.PeriodStart,
+		End:       req.PeriodEnd,
+		Points:    downsample(data, maxGraphPoints),
+		RttLabel:  "Задержка, мс",
+		LossLabel: "Потери, %",
+		BandLabel: "Недоступен",
+	}
+	for _, o := range outages {
+		chart.Bands = append(chart.Bands, charts.Band{Start: o.TimeStart, End: o.TimeEnd})
+	}
+
+	image, err := charts.RenderRtt(chart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render graph. error: %w", err)
+	}
+	return image, nil
+}
+
+// downsample усредняет соседние проверки, чтобы на графике было не больше limit точек.
+// Точка считается неудачной, если неудачной была хотя бы одна проверка из объединенных
+func downsample(data []*models.Measurement, limit int) []charts.Point {
+	step := (len(data) + limit - 1) / limit
+	points := make([]charts.Point, 0, len(data)/step+1)
+
+	for i := 0; i < len(data); i += step {
+		bucket := data[i:min(i+step, len(data))]
+
+		point := charts.Point{Time: bucket[len(bucket)/2].Time.Local()}
+		var rtt time.Duration
+		var ok int
+		for _, m := range bucket {
+			point.Loss += m.PacketLoss
+			if m.IsFailed {
+				point.Failed = true
+				continue
+			}
+			rtt += m.AvgRtt
+			ok++
+		}
+		point.Loss /= float64(len(bucket))
+		if ok > 0 {
+			point.Rtt = rtt / time.Duration(ok)
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+// parseGraphPeriod разбирает период графика: длительность от текущего момента ("6h", "7d")
+// или даты "ДД.ММ.ГГГГ-ДД.ММ.ГГГГ". По умолчанию - последние сутки
+func parseGraphPeriod(value string, now time.Time) (time.Time, time.Time, error) {
+	if value == "" {
+		return now.Add(-24 * time.Hour), now, nil
+	}
+
+	if from, to, ok := strings.Cut(value, "-"); ok {
+		start, err := time.ParseInLocation("02.01.2006", from, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q", value)
+		}
+		end, err := time.ParseInLocation("02.01.2006", to, now.Location())
+		if err != nil || end.Before(start) {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q", value)
+		}
+		// конечная дата входит в период
+		end = end.AddDate(0, 0, 1)
+		if end.After(now) {
+			end = now
+		}
+		return start, end, nil
+	}
+
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.Atoi(days)
+		if err != nil || count < 1 {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q", value)
+		}
+		return now.AddDate(0, 0, -count), now, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q", value)
+	}
+	return now.Add(-duration), now, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/repo"
+	"github.com/Alexander272/Pinger/pkg/mattermost"
+	"golang.org/x/net/icmp"
+)
+
+type HealthService struct {
+	repo      repo.Health
+	client    *mattermost.Client
+	scheduler Scheduler
+	ping      Ping
+	// количество пропущенных интервалов, после которого планировщик считается зависшим
+	missed int
+}
+
+type HealthDeps struct {
+	Repo           repo.Health
+	Client         *mattermost.Client
+	Scheduler      Scheduler
+	Ping           Ping
+	MissedInterval int
+}
+
+func NewHealthService(deps *HealthDeps) *HealthService {
+	service := &HealthService{
+		repo:      deps.Repo,
+		client:    deps.Client,
+		scheduler: deps.Scheduler,
+		ping:      deps.Ping,
+		missed:    deps.MissedInterval,
+	}
+	if service.missed < 1 {
+		service.missed = 3
+	}
+	return service
+}
+
+type Health interface {
+	Liveness(ctx context.Context) *models.Health
+	Readiness(ctx context.Context) *models.Health
+}
+
+// Liveness проверяет, что бот не завис: планировщик работает и пинг может быть отправлен
+func (s *HealthService) Liveness(ctx context.Context) *models.Health {
+	return s.collect(map[string]*models.ComponentHealth{
+		"scheduler": s.checkScheduler(),
+		"icmp":      s.checkICMP(),
+	})
+}
+
+// Readiness проверяет все подсистемы, без которых бот не может полноценно работать
+func (s *HealthService) Readiness(ctx context.Context) *models.Health {
+	return s.collect(map[string]*models.ComponentHealth{
+		"postgres":   s.checkPostgres(ctx),
+		"mattermost": s.checkMattermost(),
+		"scheduler":  s.checkScheduler(),
+		"icmp":       s.checkICMP(),
+	})
+}
+
+func (s *HealthService) collect(components map[string]*models.ComponentHealth) *models.Health {
+	health := &models.Health{Status: models.StatusUp, Components: components}
+	for _, c := range components {
+		if c.Critical && c.Status == models.StatusDown {
+			health.Status = models.StatusDown
+		}
+	}
+	return health
+}
+
+func (s *HealthService) checkPostgres(ctx context.Context) *models.ComponentHealth {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	component := &models.ComponentHealth{Status: models.StatusUp, Critical: true}
+	start := time.Now()
+	if err := s.repo.Ping(ctx); err != nil {
+		component.Status = models.StatusDown
+		component.Error = err.Error()
+	}
+	component.Details = map[string]any{"latency": time.Since(start).String()}
+	return component
+}
+
+func (s *HealthService) checkMattermost() *models.ComponentHealth {
+	component := &models.ComponentHealth{Status: models.StatusUp, Critical: true}
+	if !s.client.IsConnected() {
+		component.Status = models.StatusDown
+		component.Error = "websocket connection is lost"
+	}
+	return component
+}
+
+// checkScheduler проверяет, что проверки адресов завершались недавно. Проверки разных адресов идут независимо,
+// поэтому сравнивается время завершения последней проверки с самым коротким периодом проверок.
+// До первой проверки отсчет идет от момента старта с учетом задержки. Без адресов проверять нечего
+func (s *HealthService) checkScheduler() *models.ComponentHealth {
+	status := s.scheduler.Status()
+	stats := s.ping.Stats()
+
+	component := &models.ComponentHealth{
+		Status:   models.StatusUp,
+		Critical: true,
+		Details: map[string]any{
+			"startedAt":    status.StartedAt,
+			"addresses":    status.Addresses,
+			"checkPeriod":  status.CheckPeriod.String(),
+			"lastCheck":    status.LastCheck,
+			"lastDuration": stats.LastDuration.String(),
+			"inFlight":     stats.InFlight,
+			"queueDepth":   stats.QueueDepth,
+		},
+	}
+
+	if !status.Running {
+		component.Status = models.StatusDown
+		component.Error = "scheduler is not running"
+		return component
+	}
+
+	if status.Addresses == 0 {
+		component.Status = models.StatusIdle
+		return component
+	}
+
+	last := status.LastCheck
+	if last.IsZero() {
+		last = status.StartedAt.Add(status.StartDelay)
+	}
+	if since := time.Since(last); since > time.Duration(s.missed)*status.CheckPeriod {
+		component.Status = models.StatusDown
+		component.Error = fmt.Sprintf("no address check has completed for %s", since.Truncate(time.Second))
+	}
+	return component
+}
+
+// checkICMP проверяет, что можно открыть сокет для отправки пинга (pro-bing по умолчанию использует непривилегированный режим)
+func (s *HealthService) checkICMP() *models.ComponentHealth {
+	component := &models.ComponentHealth{Status: models.StatusUp, Critical: true}
+
+	conn, err := icmp.ListenPacket("udp4", "0.0.0.0")
+	if err != nil {
+		component.Status = models.StatusDown
+		component.Error = fmt.Sprintf("failed to open icmp socket. error: %s", err.Error())
+		return component
+	}
+	conn.Close()
+	return component
+}
@@ -0,0 +1,84 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+)
+
+type fakeScheduler struct {
+	Scheduler
+	status *models.SchedulerStatus
+}
+
+func (f *fakeScheduler) Status() *models.SchedulerStatus {
+	return f.status
+}
+
+// pingAPI позволяет встроить интерфейс Ping в заглушку, у которой есть метод Ping
+type pingAPI = Ping
+
+type fakePing struct {
+	pingAPI
+}
+
+func (f *fakePing) Stats() *models.CheckStats {
+	return &models.CheckStats{}
+}
+
+func TestCheckScheduler(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name   string
+		status *models.SchedulerStatus
+		want   string
+	}{
+		{
+			name:   "not running",
+			status: &models.SchedulerStatus{Running: false, Addresses: 1, CheckPeriod: time.Minute, LastCheck: now},
+			want:   models.StatusDown,
+		},
+		{
+			name:   "no addresses",
+			status: &models.SchedulerStatus{Running: true, StartedAt: now.Add(-time.Hour)},
+			want:   models.StatusIdle,
+		},
+		{
+			name:   "recent check",
+			status: &models.SchedulerStatus{Running: true, Addresses: 2, CheckPeriod: time.Minute, LastCheck: now.Add(-time.Minute)},
+			want:   models.StatusUp,
+		},
+		{
+			name:   "stale checks",
+			status: &models.SchedulerStatus{Running: true, Addresses: 2, CheckPeriod: time.Minute, LastCheck: now.Add(-5 * time.Minute)},
+			want:   models.StatusDown,
+		},
+		{
+			name:   "waiting for first check",
+			status: &models.SchedulerStatus{Running: true, Addresses: 1, CheckPeriod: time.Minute, StartedAt: now.Add(-time.Minute), StartDelay: time.Minute},
+			want:   models.StatusUp,
+		},
+		{
+			name:   "first check never completed",
+			status: &models.SchedulerStatus{Running: true, Addresses: 1, CheckPeriod: time.Minute, StartedAt: now.Add(-10 * time.Minute)},
+			want:   models.StatusDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := NewHealthService(&HealthDeps{Scheduler: &fakeScheduler{status: tt.status}, Ping: &fakePing{}, MissedInterval: 3})
+			if got := health.checkScheduler(); got.Status != tt.want {
+				t.Fatalf("status = %s (%s), want %s", got.Status, got.Error, tt.want)
+			}
+		})
+	}
+
+	// без адресов бот исправен и сигнал внешнему наблюдателю продолжает отправляться
+	health := NewHealthService(&HealthDeps{Scheduler: &fakeScheduler{status: tests[1].status}, Ping: &fakePing{}, MissedInterval: 3})
+	collected := health.collect(map[string]*models.ComponentHealth{"scheduler": health.checkScheduler()})
+	if collected.Status != models.StatusUp {
+		t.Fatalf("idle scheduler makes bot %s", collected.Status)
+	}
+}
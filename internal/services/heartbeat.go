@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/metrics"
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/pkg/logger"
+)
+
+// HeartbeatService следит за самим ботом. Если проверки перестали выполняться, в канал отправляется предупреждение,
+// а сигнал на внешний адрес не отправляется, чтобы внешний наблюдатель тоже мог сообщить о проблеме
+type HeartbeatService struct {
+	health Health
+	post   Post
+	conf   *models.Heartbeat
+	client *http.Client
+
+	mx      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	failing bool
+}
+
+type HeartbeatDeps struct {
+	Health Health
+	Post   Post
+	Conf   *models.Heartbeat
+}
+
+func NewHeartbeatService(deps *HeartbeatDeps) *HeartbeatService {
+	return &HeartbeatService{
+		health: deps.Health,
+		post:   deps.Post,
+		conf:   deps.Conf,
+		client: &http.Client{Timeout: deps.Conf.Timeout},
+	}
+}
+
+type Heartbeat interface {
+	Start()
+	Stop()
+}
+
+// Start запускает проверку в отдельной горутине, не зависящей от планировщика
+func (s *HeartbeatService) Start() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.cancel != nil || s.conf.Interval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan struct{})
+
+	go s.run(ctx, s.done)
+}
+
+func (s *HeartbeatService) Stop() {
+	s.mx.Lock()
+	cancel, done := s.cancel, s.done
+	s.cancel = nil
+	s.mx.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+func (s *HeartbeatService) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(s.conf.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.beat(ctx)
+		}
+	}
+}
+
+func (s *HeartbeatService) beat(ctx context.Context) {
+	health := s.health.Liveness(ctx)
+
+	if health.Status != models.StatusUp {
+		if !s.failing {
+			s.failing = true
+			logger.Warn("bot is not healthy", logger.AnyAttr("health", health))
+			s.post.Send(&models.Post{Message: "#### Внимание.\nПроверки адресов не выполняются, уведомления могут не приходить.\n" + s.describe(health)})
+		}
+		return
+	}
+	if s.failing {
+		s.failing = false
+		s.post.Send(&models.Post{Message: "Проверки адресов возобновлены."})
+	}
+
+	if s.conf.URL == "" {
+		return
+	}
+	if err := s.push(ctx); err != nil {
+		metrics.HeartbeatErrors.Inc()
+		logger.Warn("failed to push heartbeat", logger.ErrAttr(err))
+	}
+}
+
+func (s *HeartbeatService) push(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.conf.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request. error: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request. error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *HeartbeatService) describe(health *models.Health) string {
+	names := make([]string, 0, len(health.Components))
+	for name, c := range health.Components {
+		if c.Status != models.StatusUp {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	lines := []string{"```"}
+	for _, name := range names {
+		lines = append(lines, fmt.Sprintf("%s: %s", name, health.Components[name].Error))
+	}
+	lines = append(lines, "```")
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+)
+
+type fakeHealth struct {
+	mx     sync.Mutex
+	status string
+}
+
+func (f *fakeHealth) set(status string) {
+	f.mx.Lock()
+	f.status = status
+	f.mx.Unlock()
+}
+
+func (f *fakeHealth) Liveness(ctx context.Context) *models.Health {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+
+	component := &models.ComponentHealth{Status: f.status, Critical: true}
+	if f.status == models.StatusDown {
+		component.Error = "no address check has completed for 5m0s"
+	}
+	return &models.Health{Status: f.status, Components: map[string]*models.ComponentHealth{"scheduler": component}}
+}
+
+func (f *fakeHealth) Readiness(ctx context.Context) *models.Health {
+	return f.Liveness(ctx)
+}
+
+type fakePost struct {
+	Post
+
+	mx       sync.Mutex
+	messages []string
+}
+
+func (f *fakePost) Send(post *models.Post) error {
+	f.mx.Lock()
+	f.messages = append(f.messages, post.Message)
+	f.mx.Unlock()
+	return nil
+}
+
+func (f *fakePost) sent() []string {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	return append([]string{}, f.messages...)
+}
+
+// newWatcher локальный сервер вместо внешнего наблюдателя, считает полученные сигналы
+func newWatcher(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	hits := &atomic.Int64{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, hits
+}
+
+func newTestHeartbeat(health Health, post Post, url string, interval time.Duration) *HeartbeatService {
+	return NewHeartbeatService(&HeartbeatDeps{
+		Health: health,
+		Post:   post,
+		Conf:   &models.Heartbeat{Interval: interval, MissedIntervals: 3, URL: url, Timeout: time.Second},
+	})
+}
+
+func TestHeartbeatBeat(t *testing.T) {
+	server, hits := newWatcher(t)
+	health := &fakeHealth{status: models.StatusUp}
+	post := &fakePost{}
+	heartbeat := newTestHeartbeat(health, post, server.URL, time.Minute)
+	ctx := context.Background()
+
+	heartbeat.beat(ctx)
+	if hits.Load() != 1 {
+		t.Fatalf("healthy bot: watcher got %d heartbeats, want 1", hits.Load())
+	}
+	if len(post.sent()) != 0 {
+		t.Fatalf("healthy bot: unexpected messages %q", post.sent())
+	}
+
+	// проверки зависли: сигнал не отправляется, предупреждение публикуется один раз
+	health.set(models.StatusDown)
+	heartbeat.beat(ctx)
+	heartbeat.beat(ctx)
+	if hits.Load() != 1 {
+		t.Fatalf("stale checks: watcher got %d heartbeats, want 1", hits.Load())
+	}
+	messages := post.sent()
+	if len(messages) != 1 || !strings.Contains(messages[0], "Проверки адресов не выполняются") || !strings.Contains(messages[0], "scheduler") {
+		t.Fatalf("stale checks: messages %q, want one warning about scheduler", messages)
+	}
+
+	health.set(models.StatusUp)
+	heartbeat.beat(ctx)
+	if hits.Load() != 2 {
+		t.Fatalf("recovered bot: watcher got %d heartbeats, want 2", hits.Load())
+	}
+	messages = post.sent()
+	if len(messages) != 2 || messages[1] != "Проверки адресов возобновлены." {
+		t.Fatalf("recovered bot: messages %q, want recovery message", messages)
+	}
+}
+
+func TestHeartbeatRun(t *testing.T) {
+	server, hits := newWatcher(t)
+	heartbeat := newTestHeartbeat(&fakeHealth{status: models.StatusUp}, &fakePost{}, server.URL, 10*time.Millisecond)
+
+	heartbeat.Start()
+	deadline := time.Now().Add(2 * time.Second)
+	for hits.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	heartbeat.Stop()
+
+	if hits.Load() < 3 {
+		t.Fatalf("watcher got %d heartbeats in 2s, want at least 3", hits.Load())
+	}
+	stopped := hits.Load()
+	time.Sleep(50 * time.Millisecond)
+	if hits.Load() != stopped {
+		t.Fatalf("heartbeats are sent after Stop")
+	}
+}
+
+func TestHeartbeatWatcherError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	heartbeat := newTestHeartbeat(&fakeHealth{status: models.StatusUp}, &fakePost{}, server.URL, time.Minute)
+
+	if err := heartbeat.push(context.Background()); err == nil {
+		t.Fatal("push succeeded on 503 response")
+	}
+}
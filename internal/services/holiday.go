@@ -0,0 +1,197 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/repo"
+	"github.com/Alexander272/Pinger/pkg/logger"
+)
+
+const holidayLayout = "2006-01-02"
+
+type HolidayService struct {
+	repo repo.Holiday
+
+	mx     sync.RWMutex
+	loaded bool
+	cache  map[string]string
+}
+
+func NewHolidayService(repo repo.Holiday) *HolidayService {
+	return &HolidayService{
+		repo:  repo,
+		cache: make(map[string]string),
+	}
+}
+
+type Holiday interface {
+	Get(ctx context.Context, req *models.GetHolidaysDTO) ([]*models.Holiday, error)
+	Create(ctx context.Context, dto []*models.Holiday) error
+	Delete(ctx context.Context, dto *models.Holiday) error
+	Import(ctx context.Context, data []byte) ([]*models.Holiday, error)
+	models.HolidayChecker
+}
+
+func (s *HolidayService) Get(ctx context.Context, req *models.GetHolidaysDTO) ([]*models.Holiday, error) {
+	data, err := s.repo.Get(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holidays. error: %w", err)
+	}
+	return data, nil
+}
+
+func (s *HolidayService) Create(ctx context.Context, dto []*models.Holiday) error {
+	if err := s.repo.Create(ctx, dto); err != nil {
+		return fmt.Errorf("failed to create holidays. error: %w", err)
+	}
+	s.reload(ctx)
+	return nil
+}
+
+func (s *HolidayService) Delete(ctx context.Context, dto *models.Holiday) error {
+	if err := s.repo.Delete(ctx, dto); err != nil {
+		return fmt.Errorf("failed to delete holiday. error: %w", err)
+	}
+	s.reload(ctx)
+	return nil
+}
+
+// Import разбирает и сохраняет праздничные дни. Поддерживается формат iCalendar (.ics)
+// и простой список, где каждая строка содержит дату (ДД.ММ.ГГГГ или ГГГГ-ММ-ДД) и необязательное название
+func (s *HolidayService) Import(ctx context.Context, data []byte) ([]*models.Holiday, error) {
+	var (
+		holidays []*models.Holiday
+		err      error
+	)
+	if bytes.Contains(data, []byte("BEGIN:VCALENDAR")) {
+		holidays, err = parseICalendar(data)
+	} else {
+		holidays, err = parseHolidayList(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Create(ctx, holidays); err != nil {
+		return nil, err
+	}
+	return holidays, nil
+}
+
+func (s *HolidayService) IsHoliday(date time.Time) bool {
+	s.mx.RLock()
+	loaded := s.loaded
+	s.mx.RUnlock()
+	if !loaded {
+		s.reload(context.Background())
+	}
+
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	_, ok := s.cache[date.Format(holidayLayout)]
+	return ok
+}
+
+func (s *HolidayService) reload(ctx context.Context) {
+	data, err := s.repo.GetAll(ctx)
+	if err != nil {
+		logger.Error("failed to load holidays.", logger.ErrAttr(err))
+		return
+	}
+
+	cache := make(map[string]string, len(data))
+	for _, h := range data {
+		cache[h.Date.Format(holidayLayout)] = h.Name
+	}
+
+	s.mx.Lock()
+	s.cache = cache
+	s.loaded = true
+	s.mx.Unlock()
+}
+
+func parseHolidayList(data []byte) ([]*models.Holiday, error) {
+	holidays := []*models.Holiday{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		value, name, _ := strings.Cut(strings.NewReplacer(";", " ", ",", " ", "\t", " ").Replace(text), " ")
+		date, err := parseHolidayDate(value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		holidays = append(holidays, &models.Holiday{Date: date, Name: strings.Trim(strings.TrimSpace(name), `"`)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read holidays. error: %w", err)
+	}
+	return holidays, nil
+}
+
+func parseICalendar(data []byte) ([]*models.Holiday, error) {
+	holidays := []*models.Holiday{}
+	var current *models.Holiday
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		key, value, _ := strings.Cut(text, ":")
+		// параметры свойства (DTSTART;VALUE=DATE) не нужны
+		key, _, _ = strings.Cut(key, ";")
+
+		switch key {
+		case "BEGIN":
+			if value == "VEVENT" {
+				current = &models.Holiday{}
+			}
+		case "DTSTART":
+			if current == nil {
+				continue
+			}
+			if len(value) < 8 {
+				return nil, fmt.Errorf("invalid date %q", value)
+			}
+			date, err := time.Parse("20060102", value[:8])
+			if err != nil {
+				return nil, fmt.Errorf("invalid date %q", value)
+			}
+			current.Date = date
+		case "SUMMARY":
+			if current != nil {
+				current.Name = value
+			}
+		case "END":
+			if value == "VEVENT" && current != nil {
+				if !current.Date.IsZero() {
+					holidays = append(holidays, current)
+				}
+				current = nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar. error: %w", err)
+	}
+	return holidays, nil
+}
+
+func parseHolidayDate(value string) (time.Time, error) {
+	for _, layout := range []string{"02.01.2006", holidayLayout} {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
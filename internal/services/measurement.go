@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/repo"
+	"github.com/Alexander272/Pinger/pkg/logger"
+)
+
+type MeasurementService struct {
+	repo      repo.Measurement
+	retention time.Duration
+}
+
+func NewMeasurementService(repo repo.Measurement, retention time.Duration) *MeasurementService {
+	return &MeasurementService{
+		repo:      repo,
+		retention: retention,
+	}
+}
+
+type Measurement interface {
+	Get(ctx context.Context, req *models.GetMeasurementsDTO) ([]*models.Measurement, error)
+	Create(ctx context.Context, dto *models.Measurement) error
+	Cleanup(ctx context.Context) error
+}
+
+func (s *MeasurementService) Get(ctx context.Context, req *models.GetMeasurementsDTO) ([]*models.Measurement, error) {
+	data, err := s.repo.Get(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get measurements. error: %w", err)
+	}
+	return data, nil
+}
+
+func (s *MeasurementService) Create(ctx context.Context, dto *models.Measurement) error {
+	if err := s.repo.Create(ctx, dto); err != nil {
+		return fmt.Errorf("failed to create measurement. error: %w", err)
+	}
+	return nil
+}
+
+// Cleanup удаляет результаты проверок, которые хранятся дольше срока хранения
+func (s *MeasurementService) Cleanup(ctx context.Context) error {
+	if s.retention <= 0 {
+		return nil
+	}
+
+	count, err := s.repo.DeleteOld(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		return fmt.Errorf("failed to delete old measurements. error: %w", err)
+	}
+	logger.Info("old measurements deleted", logger.Int64Attr("count", count))
+	return nil
+}
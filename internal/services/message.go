@@ -4,7 +4,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"net"
 	"strconv"
 	"strings"
 	"time"
@@ -12,26 +11,52 @@ import (
 	"github.com/Alexander272/Pinger/internal/models"
 	"github.com/Alexander272/Pinger/pkg/logger"
 	"github.com/goodsign/monday"
-	"github.com/google/shlex"
 )
 
 type MessageService struct {
-	addresses Address
-	stats     Statistic
-	post      Post
+	addresses  Address
+	stats      Statistic
+	post       Post
+	scheduler  Scheduler
+	holidays   Holiday
+	tokens     Token
+	roles      Role
+	statusPage StatusPage
+	graph      Graph
+	dialogs    Dialog
+	transfer   Transfer
+	audit      Audit
 }
 
 type MessageDeps struct {
-	Address Address
-	Stats   Statistic
-	Post    Post
+	Address    Address
+	Stats      Statistic
+	Post       Post
+	Scheduler  Scheduler
+	Holiday    Holiday
+	Token      Token
+	Role       Role
+	StatusPage StatusPage
+	Graph      Graph
+	Dialog     Dialog
+	Transfer   Transfer
+	Audit      Audit
 }
 
 func NewMessageService(deps *MessageDeps) *MessageService {
 	return &MessageService{
-		addresses: deps.Address,
-		stats:     deps.Stats,
-		post:      deps.Post,
+		addresses:  deps.Address,
+		stats:      deps.Stats,
+		post:       deps.Post,
+		scheduler:  deps.Scheduler,
+		holidays:   deps.Holiday,
+		tokens:     deps.Token,
+		roles:      deps.Role,
+		statusPage: deps.StatusPage,
+		graph:      deps.Graph,
+		dialogs:    deps.Dialog,
+		transfer:   deps.Transfer,
+		audit:      deps.Audit,
 	}
 }
 
@@ -42,22 +67,30 @@ type Message interface {
 	Delete(post *models.Post) error
 	ToggleActive(post *models.Post, isEnable bool) error
 	Statistics(post *models.Post) error
+	Graph(post *models.Post) error
 	Unavailable(post *models.Post) error
+	Scheduler(post *models.Post) error
+	Holidays(post *models.Post) error
+	Tokens(post *models.Post) error
+	StatusPage(post *models.Post) error
+	Incidents(post *models.Post) error
+	Roles(post *models.Post) error
+	Export(post *models.Post) error
+	Import(post *models.Post) error
+	Audit(post *models.Post) error
+	Restore(post *models.Post) error
+	Undo(post *models.Post) error
 }
 
 func (s *MessageService) List(post *models.Post) error {
 	addresses, err := s.addresses.GetAll(context.Background())
 	if err != nil {
 		logger.Error("failed to get addresses.", logger.ErrAttr(err))
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nПроизошла ошибка при получении адресов."})
+		s.post.Reply(post, "#### Ошибка.\nПроизошла ошибка при получении адресов.")
 		return err
 	}
 
-	isAll := false
-	parts := strings.Split(post.Message, " ")
-	if len(parts) > 1 && (parts[1] == "--all" || parts[1] == "-a") {
-		isAll = true
-	}
+	isAll := post.Input.Bool("all")
 	table := []string{
 		"| № | IP-адрес | Название | Статус |",
 		"|:--|:----|:----|:--|",
@@ -65,8 +98,8 @@ func (s *MessageService) List(post *models.Post) error {
 	}
 	if isAll {
 		table = []string{
-			"| № | IP-адрес | Название | Допустимое время пинга | Количество уведомлений | Период | Интервал отправки пакетов | Таймаут до завершения ping | Количество пакетов | Статус |",
-			"|:--|:----|:----|:--|:--|:--|:--|:--|:--|:--|",
+			"| № | IP-адрес | Название | Группы | Допустимое время пинга | Количество уведомлений | Период | Интервал отправки пакетов | Таймаут до завершения ping | Количество пакетов | Интервал проверки | Статус |",
+			"|:--|:----|:----|:--|:--|:--|:--|:--|:--|:--|:--|:--|",
 		}
 	}
 
@@ -75,242 +108,294 @@ func (s *MessageService) List(post *models.Post) error {
 		if !address.Enabled {
 			isEnable = "Не активен"
 		}
+		if address.Managed {
+			isEnable += ", из конфигурации"
+		}
 
 		if !isAll {
 			table = append(table, fmt.Sprintf("|%d|%s|%s|%s|", i+1, address.IP, address.Name, isEnable))
 		} else {
-			start := time.Date(0, 1, 1, 0, int(address.PeriodStart.Minutes()), 0, 0, time.UTC)
-			end := time.Date(0, 1, 1, 0, int(address.PeriodEnd.Minutes()), 0, 0, time.UTC)
-			period := fmt.Sprintf("%s-%s", start.Format("15:04"), end.Format("15:04"))
-			table = append(table, fmt.Sprintf("|%d|%s|%s|%d|%d|%s|%d|%d|%d|%s|",
-				i+1, address.IP, address.Name, address.MaxRTT.Milliseconds(), address.NotificationCount, period, address.Interval.Milliseconds(),
-				address.Timeout.Milliseconds(), address.Count, isEnable,
+			period := "всегда"
+			if len(address.Windows) > 0 {
+				period = models.FormatWindows(address.Windows)
+			}
+			if address.TimeZone != "" {
+				period += fmt.Sprintf(" (%s)", address.TimeZone)
+			}
+			if address.SkipHolidays {
+				period += ", кроме праздников"
+			}
+			checkInterval := "по умолчанию"
+			if address.Cron != "" {
+				checkInterval = fmt.Sprintf("`%s`", address.Cron)
+			} else if address.CheckInterval != 0 {
+				checkInterval = address.CheckInterval.String()
+			}
+			table = append(table, fmt.Sprintf("|%d|%s|%s|%s|%d|%d|%s|%d|%d|%d|%s|%s|",
+				i+1, address.IP, address.Name, strings.Join(address.Groups, ", "), address.MaxRTT.Milliseconds(), address.NotificationCount, period, address.Interval.Milliseconds(),
+				address.Timeout.Milliseconds(), address.Count, checkInterval, isEnable,
 			))
 		}
 	}
 
-	s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: strings.Join(table, "\n")})
+	s.post.Reply(post, strings.Join(table, "\n"))
 	return nil
 }
 
 func (s *MessageService) Create(post *models.Post) error {
 	logger.Info("create ip", logger.StringAttr("message", post.Message))
-	address := s.decode(post)
-	if address == nil {
+	// без параметров открывается форма
+	if post.Input.Arg("ip") == "" {
+		return s.openDialog(post, "")
+	}
+	ips, err := s.expandAddresses(post)
+	if err != nil {
+		return nil
+	}
+	address := decodeAddress(post.Input)
+	before, err := s.snapshot(post, ips)
+	if err != nil {
+		return err
+	}
+
+	if len(ips) > 1 {
+		results, err := s.addresses.BulkCreate(context.Background(), ips, address)
+		if err != nil {
+			s.post.Reply(post, "#### Ошибка.\nНе удалось добавить IP адреса. Изменения не сохранены.")
+			logger.Error("failed to create addresses.", logger.ErrAttr(err))
+			return err
+		}
+		s.record(post, models.AuditCreate, ips, before)
+		s.post.Announce(post, bulkSummary(results, "добавлен"))
 		return nil
 	}
+	address.IP = ips[0]
 
 	if err := s.addresses.Create(context.Background(), address); err != nil {
 		if errors.Is(err, models.ErrExist) {
-			s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "IP адрес уже добавлен."})
+			s.post.Reply(post, "IP адрес уже добавлен.")
+			return nil
+		}
+		if errors.Is(err, models.ErrInvalidAddress) {
+			s.post.Reply(post, "#### Ошибка.\nНекорректные параметры адреса.")
 			return nil
 		}
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось добавить IP адрес."})
+		s.post.Reply(post, "#### Ошибка.\nНе удалось добавить IP адрес.")
 		logger.Error("failed to create address.", logger.ErrAttr(err))
 		return err
 	}
+	s.record(post, models.AuditCreate, ips, before)
 
-	s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "IP адрес добавлен."})
+	s.post.Announce(post, "IP адрес добавлен.")
 	return nil
 }
 
 func (s *MessageService) Update(post *models.Post) error {
 	logger.Info("update ip", logger.StringAttr("message", post.Message))
-	address := s.decode(post)
-	if address == nil {
+	// если указан только адрес, открывается форма с текущими значениями
+	ips, err := s.expandAddresses(post)
+	if err != nil {
 		return nil
 	}
-
-	data, err := s.addresses.GetByIP(context.Background(), address.IP)
-	if err != nil {
-		if errors.Is(err, models.ErrNoRows) {
-			s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе найден указанный IP адрес."})
+	if len(post.Input.Flags) == 0 {
+		if len(ips) > 1 {
+			s.post.Reply(post, "#### Ошибка.\nНе указаны изменяемые параметры. Форма доступна только для одного адреса, подробнее в `help update`.")
 			return nil
 		}
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nПри получении адреса произошла ошибка"})
-		logger.Error("failed to get address by ip.", logger.ErrAttr(err))
+		return s.openDialog(post, ips[0])
+	}
+	address := decodeAddress(post.Input)
+	before, err := s.snapshot(post, ips)
+	if err != nil {
 		return err
 	}
 
-	if address.Name == nil {
-		address.Name = &data.Name
-	}
-	if address.MaxRTT == nil {
-		address.MaxRTT = &data.MaxRTT
-	}
-	if address.NotificationCount == nil {
-		address.NotificationCount = &data.NotificationCount
-	}
-	if address.PeriodStart == nil {
-		address.PeriodStart = &data.PeriodStart
-	}
-	if address.PeriodEnd == nil {
-		address.PeriodEnd = &data.PeriodEnd
-	}
-	if address.Interval == nil {
-		address.Interval = &data.Interval
-	}
-	if address.Count == nil {
-		address.Count = &data.Count
-	}
-	if address.Timeout == nil {
-		address.Timeout = &data.Timeout
+	if len(ips) > 1 {
+		results, err := s.addresses.BulkUpdate(context.Background(), ips, address)
+		if err != nil {
+			s.post.Reply(post, "#### Ошибка.\nНе удалось обновить IP адреса. Изменения не сохранены.")
+			logger.Error("failed to update addresses.", logger.ErrAttr(err))
+			return err
+		}
+		s.record(post, models.AuditUpdate, ips, before)
+		s.post.Announce(post, bulkSummary(results, "обновлен"))
+		return nil
 	}
-	if address.Enabled == nil {
-		address.Enabled = &data.Enabled
+	address.IP = ips[0]
+
+	data, ok := before[address.IP]
+	if !ok {
+		s.post.Reply(post, "#### Ошибка.\nНе найден указанный IP адрес.")
+		return nil
 	}
 
+	address.Fill(data)
+
 	if err := s.addresses.Update(context.Background(), address); err != nil {
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось обновить IP адрес."})
+		if errors.Is(err, models.ErrManaged) {
+			s.post.Reply(post, "#### Ошибка.\nАдрес задан в файле конфигурации, изменить его можно только там.")
+			return nil
+		}
+		if errors.Is(err, models.ErrInvalidAddress) {
+			s.post.Reply(post, "#### Ошибка.\nНекорректные параметры адреса.")
+			return nil
+		}
+		s.post.Reply(post, "#### Ошибка.\nНе удалось обновить IP адрес.")
 		logger.Error("failed to update address.", logger.ErrAttr(err))
 		return err
 	}
+	s.record(post, models.AuditUpdate, ips, before)
 
-	s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "IP адрес обновлен."})
+	s.post.Announce(post, "IP адрес обновлен.")
+	return nil
+}
+
+// openDialog открывает форму добавления или изменения адреса
+func (s *MessageService) openDialog(post *models.Post, ip string) error {
+	if err := s.dialogs.Open(context.Background(), post, ip); err != nil {
+		if errors.Is(err, models.ErrDialogDisabled) {
+			s.post.Reply(post, "#### Ошибка.\nНе удалось распознать команду. Не указаны параметры адреса, подробнее в `help add`.")
+			return nil
+		}
+		if errors.Is(err, models.ErrNoRows) {
+			s.post.Reply(post, "#### Ошибка.\nНе найден указанный IP адрес.")
+			return nil
+		}
+		if errors.Is(err, models.ErrManaged) {
+			s.post.Reply(post, "#### Ошибка.\nАдрес задан в файле конфигурации, изменить его можно только там.")
+			return nil
+		}
+		s.post.Reply(post, "#### Ошибка.\nНе удалось открыть форму.")
+		logger.Error("failed to open dialog.", logger.ErrAttr(err))
+		return err
+	}
 	return nil
 }
 
 func (s *MessageService) ToggleActive(post *models.Post, isEnable bool) error {
 	logger.Info("toggle active ip", logger.StringAttr("message", post.Message), logger.BoolAttr("isEnable", isEnable))
-	parts := strings.Split(post.Message, " ")
-	if net.ParseIP(parts[1]) == nil {
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Неправильный IP адрес."})
+	ips, err := s.expandAddresses(post)
+	if err != nil {
 		return nil
 	}
-
-	data, err := s.addresses.GetByIP(context.Background(), parts[1])
+	before, err := s.snapshot(post, ips)
 	if err != nil {
-		if errors.Is(err, models.ErrNoRows) {
-			s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе найден указанный IP адрес."})
-			return nil
-		}
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nПри получении адреса произошла ошибка"})
-		logger.Error("failed to get address by ip.", logger.ErrAttr(err))
 		return err
 	}
+	action := models.AuditDisable
+	if isEnable {
+		action = models.AuditEnable
+	}
 
-	address := &models.AddressDTO{
-		ID:                data.ID,
-		IP:                data.IP,
-		Name:              &data.Name,
-		MaxRTT:            &data.MaxRTT,
-		Count:             &data.Count,
-		Timeout:           &data.Timeout,
-		PeriodStart:       &data.PeriodStart,
-		PeriodEnd:         &data.PeriodEnd,
-		Interval:          &data.Interval,
-		NotificationCount: &data.NotificationCount,
-		Enabled:           &isEnable,
+	if len(ips) > 1 {
+		results, err := s.addresses.BulkToggle(context.Background(), ips, isEnable)
+		if err != nil {
+			s.post.Reply(post, "#### Ошибка.\nНе удалось обновить IP адреса. Изменения не сохранены.")
+			logger.Error("failed to toggle addresses.", logger.ErrAttr(err))
+			return err
+		}
+		s.record(post, action, ips, before)
+		status := "отключен"
+		if isEnable {
+			status = "включен"
+		}
+		s.post.Announce(post, bulkSummary(results, status))
+		return nil
 	}
 
-	if err := s.addresses.Update(context.Background(), address); err != nil {
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось обновить IP адрес."})
-		logger.Error("failed to update address.", logger.ErrAttr(err))
+	if err := s.addresses.ToggleActive(context.Background(), ips[0], isEnable); err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			s.post.Reply(post, "#### Ошибка.\nНе найден указанный IP адрес.")
+			return nil
+		}
+		if errors.Is(err, models.ErrManaged) {
+			s.post.Reply(post, "#### Ошибка.\nАдрес задан в файле конфигурации, изменить его можно только там.")
+			return nil
+		}
+		if errors.Is(err, models.ErrInvalidAddress) {
+			s.post.Reply(post, "#### Ошибка.\nНекорректные параметры адреса.")
+			return nil
+		}
+		s.post.Reply(post, "#### Ошибка.\nНе удалось обновить IP адрес.")
+		logger.Error("failed to toggle address.", logger.ErrAttr(err))
 		return err
 	}
+	s.record(post, action, ips, before)
 
-	s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "IP адрес обновлен."})
+	s.post.Announce(post, "IP адрес обновлен.")
 	return nil
 }
 
 func (s *MessageService) Delete(post *models.Post) error {
 	logger.Info("delete ip", logger.StringAttr("message", post.Message))
-	parts := strings.Split(post.Message, " ")
-	if net.ParseIP(parts[1]) == nil {
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Неправильный IP адрес."})
+	ips, err := s.expandAddresses(post)
+	if err != nil {
+		return nil
+	}
+	before, err := s.snapshot(post, ips)
+	if err != nil {
+		return err
+	}
+
+	if len(ips) > 1 {
+		results, err := s.addresses.BulkDelete(context.Background(), ips)
+		if err != nil {
+			s.post.Reply(post, "#### Ошибка.\nНе удалось удалить IP адреса. Изменения не сохранены.")
+			logger.Error("failed to delete addresses.", logger.ErrAttr(err))
+			return err
+		}
+		s.record(post, models.AuditDelete, ips, before)
+		s.post.Announce(post, bulkSummary(results, "удален")+"\n\nВернуть удаленные адреса: `undo`.")
 		return nil
 	}
 
-	if err := s.addresses.Delete(context.Background(), parts[1]); err != nil {
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось удалить IP адрес."})
+	if err := s.addresses.Delete(context.Background(), ips[0]); err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			s.post.Reply(post, "#### Ошибка.\nНе найден указанный IP адрес.")
+			return nil
+		}
+		if errors.Is(err, models.ErrManaged) {
+			s.post.Reply(post, "#### Ошибка.\nАдрес задан в файле конфигурации, изменить его можно только там.")
+			return nil
+		}
+		s.post.Reply(post, "#### Ошибка.\nНе удалось удалить IP адрес.")
 		logger.Error("failed to delete address.", logger.ErrAttr(err))
 		return err
 	}
+	s.record(post, models.AuditDelete, ips, before)
 
-	s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "IP адрес удален."})
+	s.post.Announce(post, fmt.Sprintf("IP адрес удален. Восстановить: `restore %s`.", ips[0]))
 	return nil
 }
 
 func (s *MessageService) Statistics(post *models.Post) error {
 	logger.Info("statistics ip", logger.StringAttr("message", post.Message))
 
-	parts, err := shlex.Split(post.Message)
-	if err != nil {
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду."})
-		logger.Error("failed to split message.", logger.ErrAttr(err))
-		return fmt.Errorf("failed to split message. error: %w", err)
-	}
-
-	args := []string{"", ""}
-	for i := 1; i < len(parts); i++ {
-		_, ok := strings.CutPrefix(parts[i], "-p")
-		if !ok {
-			args[0] = parts[i]
-			continue
-		}
-
-		if strings.Contains(parts[i], "=") {
-			tmp := strings.Split(parts[i], "=")
-			args[1] = tmp[1]
-			continue
-		}
-
-		args[1] = parts[i+1]
-		i++
-	}
-
-	if args[0] != "" && net.ParseIP(args[0]) == nil {
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Некорректный IP адрес."})
-		return nil
-	}
+	ip := post.Input.Arg("ip")
 
 	now := time.Now()
 	period := &models.GetStatisticDTO{
 		PeriodStart: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()),
 		PeriodEnd:   time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()),
 	}
-	if args[1] != "" {
-		parts := strings.Split(args[1], "-")
-		if len(parts) != 2 {
-			s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Некорректный период."})
-			return fmt.Errorf("period is not correct")
-		}
-
-		start := []int{now.Day(), int(now.Month()), now.Year()}
-		startParts := strings.Split(parts[0], ".")
-		for i, p := range startParts {
-			tmp, err := strconv.Atoi(p)
-			if err != nil {
-				s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Некорректный период."})
-				return err
-			}
-			if tmp != 0 {
-				start[i] = tmp
-			}
-		}
-
-		end := []int{now.Day(), int(now.Month()), now.Year()}
-		endParts := strings.Split(parts[1], ".")
-		for i, p := range endParts {
-			end[i], err = strconv.Atoi(p)
-			if err != nil {
-				s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Некорректный период."})
-				return err
-			}
+	if value := post.Input.String("period"); value != "" {
+		start, end, err := parseStatisticPeriod(value, now)
+		if err != nil {
+			s.post.Reply(post, "#### Ошибка.\nНекорректное значение флага «--period»: ожидается диапазон дат.")
+			return nil
 		}
-		logger.Debug("stats", logger.AnyAttr("start", start))
-
-		//TODO не работает
-		period.PeriodStart = time.Date(start[2], time.Month(start[1]), start[0], 0, 0, 0, 0, now.Location())
-		period.PeriodEnd = time.Date(end[2], time.Month(end[1]), end[0], 0, 0, 0, 0, now.Location())
+		period.PeriodStart, period.PeriodEnd = start, end
 	}
 
 	logger.Debug("stats", logger.AnyAttr("period", period))
 
-	var data []*models.Statistic
-	if args[0] != "" {
+	var (
+		data []*models.Statistic
+		err  error
+	)
+	if ip != "" {
 		data, err = s.stats.GetByIP(context.Background(), &models.GetStatisticByIPDTO{
-			IP:          args[0],
+			IP:          ip,
 			PeriodStart: period.PeriodStart,
 			PeriodEnd:   period.PeriodEnd,
 		})
@@ -318,12 +403,12 @@ func (s *MessageService) Statistics(post *models.Post) error {
 		data, err = s.stats.Get(context.Background(), period)
 	}
 	if err != nil {
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nПри получении статистики произошла ошибка"})
+		s.post.Reply(post, "#### Ошибка.\nПри получении статистики произошла ошибка")
 		logger.Error("failed to get statistic.", logger.ErrAttr(err))
 		return err
 	}
 	if len(data) == 0 {
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "Ничего не найдено"})
+		s.post.Reply(post, "Ничего не найдено")
 		return nil
 	}
 
@@ -353,7 +438,7 @@ func (s *MessageService) Statistics(post *models.Post) error {
 		table = append(table, row)
 	}
 
-	s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: strings.Join(table, "\n")})
+	s.post.Reply(post, strings.Join(table, "\n"))
 	return nil
 }
 
@@ -362,7 +447,7 @@ func (s *MessageService) Unavailable(post *models.Post) error {
 
 	data, err := s.stats.GetUnavailable(context.Background(), &models.GetUnavailableDTO{})
 	if err != nil {
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nПри получении статистики произошла ошибка"})
+		s.post.Reply(post, "#### Ошибка.\nПри получении статистики произошла ошибка")
 		logger.Error("failed to get statistic.", logger.ErrAttr(err))
 		return err
 	}
@@ -377,187 +462,981 @@ func (s *MessageService) Unavailable(post *models.Post) error {
 		table = append(table, row)
 	}
 
-	s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: strings.Join(table, "\n")})
+	s.post.Reply(post, strings.Join(table, "\n"))
 	return nil
 }
 
-func (s *MessageService) decode(post *models.Post) *models.AddressDTO {
-	address := &models.AddressDTO{}
+func (s *MessageService) Scheduler(post *models.Post) error {
+	logger.Info("scheduler settings", logger.StringAttr("message", post.Message))
+
+	if len(post.Input.Flags) > 0 {
+		dto := decodeScheduler(post.Input)
+
+		if err := s.scheduler.UpdateSettings(context.Background(), dto); err != nil {
+			if errors.Is(err, models.ErrInvalidSettings) {
+				s.post.Reply(post, "#### Ошибка.\nНекорректные настройки планировщика.")
+				return nil
+			}
+			s.post.Reply(post, "#### Ошибка.\nНе удалось обновить настройки планировщика.")
+			logger.Error("failed to update scheduler settings.", logger.ErrAttr(err))
+			return err
+		}
+		if err := s.scheduler.Restart(); err != nil {
+			s.post.Reply(post, "#### Ошибка.\nНе удалось перезапустить планировщик.")
+			logger.Error("failed to restart scheduler.", logger.ErrAttr(err))
+			return err
+		}
+	}
 
-	// parts := strings.Split(message, " ")
-	// pattern := regexp.MustCompile(`\"[^\"]+\"|\S+`)
-	// parts := pattern.FindAllString(post.Message, -1)
-	parts, err := shlex.Split(post.Message)
+	data, err := s.scheduler.GetSettings(context.Background())
 	if err != nil {
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду."})
-		logger.Error("failed to split message.", logger.ErrAttr(err))
+		s.post.Reply(post, "#### Ошибка.\nПри получении настроек планировщика произошла ошибка")
+		logger.Error("failed to get scheduler settings.", logger.ErrAttr(err))
+		return err
+	}
+
+	quiet := "нет"
+	if data.QuietStart != data.QuietEnd {
+		start := time.Date(0, 1, 1, 0, int(data.QuietStart.Minutes()), 0, 0, time.UTC)
+		end := time.Date(0, 1, 1, 0, int(data.QuietEnd.Minutes()), 0, 0, time.UTC)
+		quiet = fmt.Sprintf("%s-%s", start.Format("15:04"), end.Format("15:04"))
+	}
+
+	table := []string{
+		"| Параметр | Значение |",
+		"|:--|:--|",
+		fmt.Sprintf("|Интервал проверок по умолчанию|%s|", data.Interval),
+		fmt.Sprintf("|Количество одновременных пингов|%d|", data.MaxCount),
+		fmt.Sprintf("|Максимальное время проверки|%s|", data.CycleTimeout),
+		fmt.Sprintf("|Задержка перед запуском|%s|", data.StartDelay),
+		fmt.Sprintf("|Период тишины|%s|", quiet),
+	}
+
+	s.post.Reply(post, strings.Join(table, "\n"))
+	return nil
+}
+
+func (s *MessageService) Holidays(post *models.Post) error {
+	logger.Info("holidays", logger.StringAttr("message", post.Message))
+
+	switch post.Input.Action {
+	case "add":
+		date, err := parseHolidayDate(post.Input.Arg("дата"))
+		if err != nil {
+			s.post.Reply(post, "#### Ошибка.\nНекорректное значение аргумента <дата>: ожидается дата в формате ДД.ММ.ГГГГ.")
+			return nil
+		}
+		holiday := &models.Holiday{Date: date, Name: post.Input.Arg("название")}
+
+		if err := s.holidays.Create(context.Background(), []*models.Holiday{holiday}); err != nil {
+			s.post.Reply(post, "#### Ошибка.\nНе удалось добавить праздничный день.")
+			logger.Error("failed to create holiday.", logger.ErrAttr(err))
+			return err
+		}
+		s.post.Announce(post, "Праздничный день добавлен.")
+		return nil
+
+	case "del":
+		date, err := parseHolidayDate(post.Input.Arg("дата"))
+		if err != nil {
+			s.post.Reply(post, "#### Ошибка.\nНекорректное значение аргумента <дата>: ожидается дата в формате ДД.ММ.ГГГГ.")
+			return nil
+		}
+
+		if err := s.holidays.Delete(context.Background(), &models.Holiday{Date: date}); err != nil {
+			s.post.Reply(post, "#### Ошибка.\nНе удалось удалить праздничный день.")
+			logger.Error("failed to delete holiday.", logger.ErrAttr(err))
+			return err
+		}
+		s.post.Announce(post, "Праздничный день удален.")
+		return nil
+
+	case "import":
+		// список праздников может быть в строках после команды
+		files := [][]byte{}
+		if strings.TrimSpace(post.Input.Body) != "" {
+			files = append(files, []byte(post.Input.Body))
+		}
+		for _, id := range post.FileIDs {
+			data, err := s.post.GetFile(id)
+			if err != nil {
+				s.post.Reply(post, "#### Ошибка.\nНе удалось получить файл.")
+				logger.Error("failed to get file.", logger.ErrAttr(err))
+				return err
+			}
+			files = append(files, data)
+		}
+		if len(files) == 0 {
+			s.post.Reply(post, "#### Ошибка.\nНе найден список праздничных дней. Приложите файл или добавьте список после команды.")
+			return nil
+		}
+
+		count := 0
+		for _, data := range files {
+			holidays, err := s.holidays.Import(context.Background(), data)
+			if err != nil {
+				s.post.Reply(post, "#### Ошибка.\nНе удалось импортировать праздничные дни. "+err.Error())
+				logger.Error("failed to import holidays.", logger.ErrAttr(err))
+				return nil
+			}
+			count += len(holidays)
+		}
+		s.post.Announce(post, fmt.Sprintf("Импортировано праздничных дней: %d.", count))
 		return nil
 	}
 
-	if net.ParseIP(parts[1]) == nil {
-		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Некорректный IP адрес."})
+	now := time.Now()
+	data, err := s.holidays.Get(context.Background(), &models.GetHolidaysDTO{
+		PeriodStart: time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(now.Year(), 12, 31, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		s.post.Reply(post, "#### Ошибка.\nПри получении праздничных дней произошла ошибка")
+		logger.Error("failed to get holidays.", logger.ErrAttr(err))
+		return err
+	}
+	if len(data) == 0 {
+		s.post.Reply(post, "Ничего не найдено")
 		return nil
 	}
-	address.IP = parts[1]
 
-	parts = parts[2:]
-	args := make(map[string]string, len(parts)/2)
+	table := []string{
+		"| № | Дата | Название |",
+		"|:--|:--|:--|",
+	}
+	for i, d := range data {
+		table = append(table, fmt.Sprintf("|%d|%s|%s|", i+1, monday.Format(d.Date, "Mon 2 Jan 2006", monday.LocaleRuRU), d.Name))
+	}
+
+	s.post.Reply(post, strings.Join(table, "\n"))
+	return nil
+}
+
+// Tokens управляет токенами доступа к API
+func (s *MessageService) Tokens(post *models.Post) error {
+	logger.Info("api tokens", logger.StringAttr("message", post.Message), logger.StringAttr("user", post.UserID))
+
+	switch post.Input.Action {
+	case "issue":
+		dto := &models.TokenDTO{Name: post.Input.Arg("название"), Scope: models.ScopeRead, CreatedBy: post.UserID}
+		if scope := post.Input.Arg("read|admin"); scope != "" {
+			dto.Scope = scope
+		}
 
-	logger.Debug("decode", logger.AnyAttr("args", args))
-	// Используя пакет github.com/jessevdk/go-flags я немного сокращу код, но этот пакет содержит сильно больше функций, чем мне нужно
-	// плюсом я могу вернуть ошибку пользователю только на английском поэтому пока оставлю так
+		token, err := s.tokens.Issue(context.Background(), dto)
+		if err != nil {
+			if errors.Is(err, models.ErrTokenInvalidScope) {
+				s.post.Reply(post, "#### Ошибка.\nНеизвестная область действия токена. Допустимые значения: read, admin.")
+				return nil
+			}
+			if errors.Is(err, models.ErrExist) {
+				s.post.Reply(post, "Токен с таким названием уже существует.")
+				return nil
+			}
+			s.post.Reply(post, "#### Ошибка.\nНе удалось выдать токен.")
+			logger.Error("failed to issue api token.", logger.ErrAttr(err))
+			return err
+		}
 
-	for i := 0; i < len(parts); i += 2 {
-		if strings.Contains(parts[i], "=") {
-			tmp := strings.Split(parts[i], "=")
-			args[tmp[0]] = tmp[1]
-			i -= 1
-			continue
+		// токен показывается один раз и только в личных сообщениях, чтобы он не остался в общем канале
+		message := fmt.Sprintf("Токен **%s** (%s):\n```\n%s\n```\nСохраните его, повторно получить токен нельзя.", dto.Name, dto.Scope, token)
+		if err := s.post.SendDirect(post.UserID, &models.Post{Message: message}); err != nil {
+			logger.Error("failed to send api token.", logger.ErrAttr(err))
+			if err := s.tokens.Revoke(context.Background(), dto.Name); err != nil {
+				logger.Error("failed to revoke api token.", logger.ErrAttr(err))
+			}
+			s.post.Reply(post, "#### Ошибка.\nНе удалось отправить токен в личные сообщения. Токен отозван.")
+			return err
 		}
-		args[parts[i]] = parts[i+1]
+		s.post.Reply(post, "Токен выдан и отправлен в личные сообщения.")
+		return nil
+
+	case "revoke":
+		if err := s.tokens.Revoke(context.Background(), post.Input.Arg("название")); err != nil {
+			if errors.Is(err, models.ErrNoRows) {
+				s.post.Reply(post, "#### Ошибка.\nНе найден указанный токен.")
+				return nil
+			}
+			s.post.Reply(post, "#### Ошибка.\nНе удалось отозвать токен.")
+			logger.Error("failed to revoke api token.", logger.ErrAttr(err))
+			return err
+		}
+		s.post.Reply(post, "Токен отозван.")
+		return nil
 	}
 
-	if name, ok := args["-n"]; ok || args["--name"] != "" {
-		address.Name = &name
+	data, err := s.tokens.GetAll(context.Background())
+	if err != nil {
+		s.post.Reply(post, "#### Ошибка.\nПри получении токенов произошла ошибка")
+		logger.Error("failed to get api tokens.", logger.ErrAttr(err))
+		return err
 	}
-	if rtt, ok := args["-r"]; ok || args["--rtt"] != "" {
-		rttDur, err := time.ParseDuration(rtt + "ms")
-		if err != nil {
-			s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Не удалось понять время пинга."})
+	if len(data) == 0 {
+		s.post.Reply(post, "Ничего не найдено")
+		return nil
+	}
+
+	table := []string{
+		"| № | Название | Доступ | Создан | Последнее использование |",
+		"|:--|:--|:--|:--|:--|",
+	}
+	for i, t := range data {
+		lastUsed := "-"
+		if t.LastUsed.Valid {
+			lastUsed = t.LastUsed.Time.Local().Format("02.01.2006 15:04")
+		}
+		table = append(table, fmt.Sprintf("|%d|%s|%s|%s|%s|", i+1, t.Name, t.Scope, t.Created.Local().Format("02.01.2006 15:04"), lastUsed))
+	}
+
+	s.post.Reply(post, strings.Join(table, "\n"))
+	return nil
+}
+
+// Graph строит график задержки и потерь по адресу и отправляет его картинкой
+func (s *MessageService) Graph(post *models.Post) error {
+	logger.Info("graph", logger.StringAttr("message", post.Message))
+
+	ip := post.Input.Arg("ip")
+	start, end, err := parseGraphPeriod(post.Input.String("period"), time.Now())
+	if err != nil {
+		s.post.Reply(post, "#### Ошибка.\nНекорректное значение флага «--period»: ожидается длительность (6h, 7d) или даты ДД.ММ.ГГГГ-ДД.ММ.ГГГГ.")
+		return nil
+	}
+
+	image, err := s.graph.Render(context.Background(), &models.GraphDTO{IP: ip, PeriodStart: start, PeriodEnd: end})
+	if err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			s.post.Reply(post, "Нет данных за указанный период")
 			return nil
 		}
-		address.MaxRTT = &rttDur
+		s.post.Reply(post, "#### Ошибка.\nНе удалось построить график.")
+		logger.Error("failed to render graph.", logger.ErrAttr(err))
+		return err
+	}
+
+	message := fmt.Sprintf("График по адресу %s с %s по %s", ip, start.Format("02.01.2006 15:04"), end.Format("02.01.2006 15:04"))
+	name := fmt.Sprintf("graph_%s_%s.png", ip, end.Format("20060102_1504"))
+	if err := s.post.SendFile(&models.Post{ChannelID: post.ChannelID, Message: message}, name, image); err != nil {
+		s.post.Reply(post, "#### Ошибка.\nНе удалось отправить график.")
+		logger.Error("failed to send graph.", logger.ErrAttr(err))
+		return err
+	}
+	return nil
+}
+
+// StatusPage управляет компонентами публичной страницы статуса
+func (s *MessageService) StatusPage(post *models.Post) error {
+	logger.Info("status page", logger.StringAttr("message", post.Message), logger.StringAttr("user", post.UserID))
+
+	switch post.Input.Action {
+	case "add":
+		dto := &models.StatusComponentDTO{Name: post.Input.Arg("название"), IP: post.Input.String("ip"), Group: post.Input.String("group")}
+
+		if err := s.statusPage.CreateComponent(context.Background(), dto); err != nil {
+			if errors.Is(err, models.ErrInvalidComponent) {
+				s.post.Reply(post, "#### Ошибка.\nДля компонента нужно указать либо адрес (-i), либо группу (-g).")
+				return nil
+			}
+			if errors.Is(err, models.ErrNoRows) {
+				s.post.Reply(post, "#### Ошибка.\nАдрес не найден.")
+				return nil
+			}
+			if errors.Is(err, models.ErrExist) {
+				s.post.Reply(post, "Компонент с таким названием уже существует.")
+				return nil
+			}
+			s.post.Reply(post, "#### Ошибка.\nНе удалось добавить компонент.")
+			logger.Error("failed to create status component.", logger.ErrAttr(err))
+			return err
+		}
+		s.post.Announce(post, "Компонент добавлен на страницу статуса.")
+		return nil
+
+	case "del":
+		if err := s.statusPage.DeleteComponent(context.Background(), post.Input.Arg("название")); err != nil {
+			if errors.Is(err, models.ErrNoRows) {
+				s.post.Reply(post, "#### Ошибка.\nНе найден указанный компонент.")
+				return nil
+			}
+			s.post.Reply(post, "#### Ошибка.\nНе удалось удалить компонент.")
+			logger.Error("failed to delete status component.", logger.ErrAttr(err))
+			return err
+		}
+		s.post.Announce(post, "Компонент удален со страницы статуса.")
+		return nil
 	}
-	if nc, ok := args["-N"]; ok || args["--notification"] != "" {
-		count, err := strconv.Atoi(nc)
+
+	data, err := s.statusPage.GetComponents(context.Background())
+	if err != nil {
+		s.post.Reply(post, "#### Ошибка.\nПри получении компонентов произошла ошибка")
+		logger.Error("failed to get status components.", logger.ErrAttr(err))
+		return err
+	}
+	if len(data) == 0 {
+		s.post.Reply(post, "Ничего не найдено")
+		return nil
+	}
+
+	table := []string{
+		"| № | Компонент | Адрес | Группа |",
+		"|:--|:--|:--|:--|",
+	}
+	for i, c := range data {
+		table = append(table, fmt.Sprintf("|%d|%s|%s|%s|", i+1, c.Name, c.IP, c.Group))
+	}
+
+	s.post.Reply(post, strings.Join(table, "\n"))
+	return nil
+}
+
+// Incidents ведет инциденты публичной страницы статуса
+func (s *MessageService) Incidents(post *models.Post) error {
+	logger.Info("incidents", logger.StringAttr("message", post.Message), logger.StringAttr("user", post.UserID))
+
+	switch action := post.Input.Action; action {
+	case "open":
+		dto := &models.IncidentDTO{
+			Title:     post.Input.Arg("заголовок"),
+			Message:   post.Input.String("message"),
+			Status:    post.Input.String("status"),
+			CreatedBy: post.UserID,
+		}
+		if post.Input.Has("components") {
+			dto.Components = models.ParseGroups(post.Input.String("components"))
+		}
+
+		id, err := s.statusPage.OpenIncident(context.Background(), dto)
 		if err != nil {
-			s.post.Send(&models.Post{
-				ChannelID: post.ChannelID,
-				Message:   "#### Ошибка.\nНе удалось распознать команду. Не удалось понять количество уведомлений.",
-			})
+			if errors.Is(err, models.ErrInvalidComponent) {
+				s.post.Reply(post, "#### Ошибка.\nНе найден компонент страницы статуса. "+err.Error())
+				return nil
+			}
+			if errors.Is(err, models.ErrInvalidIncident) {
+				s.post.Reply(post, "#### Ошибка.\nНекорректный статус инцидента. Допустимые значения: investigating, identified, monitoring.")
+				return nil
+			}
+			s.post.Reply(post, "#### Ошибка.\nНе удалось открыть инцидент.")
+			logger.Error("failed to open incident.", logger.ErrAttr(err))
+			return err
+		}
+		s.post.Announce(post, fmt.Sprintf("Инцидент №%d открыт.", id))
+		return nil
+
+	case "update", "resolve":
+		id, err := strconv.Atoi(strings.TrimPrefix(post.Input.Arg("номер"), "#"))
+		if err != nil {
+			s.post.Reply(post, "#### Ошибка.\nНекорректное значение аргумента <номер>: ожидается номер инцидента.")
+			return nil
+		}
+
+		dto := &models.IncidentUpdateDTO{IncidentID: id, Status: models.IncidentResolved, Message: post.Input.Arg("текст")}
+		if action == "update" {
+			dto.Status = post.Input.Arg("статус")
+		}
+
+		if err := s.statusPage.UpdateIncident(context.Background(), dto); err != nil {
+			if errors.Is(err, models.ErrInvalidIncident) {
+				s.post.Reply(post, "#### Ошибка.\nНекорректный статус инцидента. Допустимые значения: investigating, identified, monitoring, resolved.")
+				return nil
+			}
+			if errors.Is(err, models.ErrNoRows) {
+				s.post.Reply(post, "#### Ошибка.\nНе найден указанный инцидент.")
+				return nil
+			}
+			s.post.Reply(post, "#### Ошибка.\nНе удалось обновить инцидент.")
+			logger.Error("failed to update incident.", logger.ErrAttr(err))
+			return err
+		}
+		if dto.Status == models.IncidentResolved {
+			s.post.Announce(post, fmt.Sprintf("Инцидент №%d решен.", id))
 			return nil
 		}
-		address.NotificationCount = &count
+		s.post.Announce(post, fmt.Sprintf("Инцидент №%d обновлен.", id))
+		return nil
+	}
+
+	data, err := s.statusPage.GetIncidents(context.Background(), &models.GetIncidentsDTO{OnlyOpen: true})
+	if err != nil {
+		s.post.Reply(post, "#### Ошибка.\nПри получении инцидентов произошла ошибка")
+		logger.Error("failed to get incidents.", logger.ErrAttr(err))
+		return err
+	}
+	if len(data) == 0 {
+		s.post.Reply(post, "Открытых инцидентов нет")
+		return nil
+	}
+
+	table := []string{
+		"| № | Заголовок | Статус | Компоненты | Открыт |",
+		"|:--|:--|:--|:--|:--|",
 	}
-	if period, ok := args["-p"]; ok || args["--period"] != "" {
-		dates := strings.Split(period, "-")
-		if len(dates) != 2 {
-			s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Не удалось понять период."})
+	for _, i := range data {
+		table = append(table, fmt.Sprintf("|%d|%s|%s|%s|%s|", i.ID, i.Title, i.Status, strings.Join(i.Components, ", "), i.Created.Local().Format("02.01.2006 15:04")))
+	}
+
+	s.post.Reply(post, strings.Join(table, "\n"))
+	return nil
+}
+
+// Roles управляет ролями пользователей бота
+func (s *MessageService) Roles(post *models.Post) error {
+	logger.Info("user roles", logger.StringAttr("message", post.Message), logger.StringAttr("user", post.UserID))
+
+	switch post.Input.Action {
+	case "grant":
+		dto := &models.UserRoleDTO{Username: post.Input.Arg("@пользователь"), Role: post.Input.Arg("роль"), GrantedBy: post.UserID}
+		if err := s.roles.Grant(context.Background(), dto); err != nil {
+			if errors.Is(err, models.ErrInvalidRole) {
+				s.post.Reply(post, "#### Ошибка.\nНеизвестная роль. Допустимые значения: viewer, operator, admin.")
+				return nil
+			}
+			if errors.Is(err, models.ErrNoRows) {
+				s.post.Reply(post, "#### Ошибка.\nПользователь не найден.")
+				return nil
+			}
+			s.post.Reply(post, "#### Ошибка.\nНе удалось выдать роль.")
+			logger.Error("failed to grant user role.", logger.ErrAttr(err))
+			return err
+		}
+		s.post.Announce(post, fmt.Sprintf("Пользователю @%s выдана роль «%s».", dto.Username, models.RoleTitles[dto.Role]))
+		return nil
+
+	case "revoke":
+		username := post.Input.Arg("@пользователь")
+		if err := s.roles.Revoke(context.Background(), username); err != nil {
+			if errors.Is(err, models.ErrNoRows) {
+				s.post.Reply(post, "#### Ошибка.\nУ пользователя нет выданной роли.")
+				return nil
+			}
+			s.post.Reply(post, "#### Ошибка.\nНе удалось отозвать роль.")
+			logger.Error("failed to revoke user role.", logger.ErrAttr(err))
+			return err
+		}
+		s.post.Announce(post, fmt.Sprintf("Роль пользователя %s отозвана.", username))
+		return nil
+	}
+
+	data, err := s.roles.GetAll(context.Background())
+	if err != nil {
+		s.post.Reply(post, "#### Ошибка.\nПри получении ролей произошла ошибка")
+		logger.Error("failed to get user roles.", logger.ErrAttr(err))
+		return err
+	}
+	if len(data) == 0 {
+		s.post.Reply(post, "Выданных ролей нет")
+		return nil
+	}
+
+	table := []string{
+		"| № | Пользователь | Роль | Выдана |",
+		"|:--|:--|:--|:--|",
+	}
+	for i, r := range data {
+		table = append(table, fmt.Sprintf("|%d|@%s|%s|%s|", i+1, r.Username, models.RoleTitles[r.Role], r.Created.Local().Format("02.01.2006 15:04")))
+	}
+
+	s.post.Reply(post, strings.Join(table, "\n"))
+	return nil
+}
+
+// decodeScheduler собирает изменения настроек планировщика из флагов команды
+func decodeScheduler(input *models.CommandInput) *models.SchedulerDTO {
+	dto := &models.SchedulerDTO{}
+
+	duration := func(flag string) *time.Duration {
+		if !input.Has(flag) {
 			return nil
 		}
-		start, err := time.Parse("15:04", dates[0])
+		value := input.Duration(flag)
+		return &value
+	}
+	dto.Interval = duration("interval")
+	dto.CycleTimeout = duration("timeout")
+	dto.StartDelay = duration("delay")
+
+	if input.Has("count") {
+		count := input.Int("count")
+		dto.MaxCount = &count
+	}
+	if input.Has("quiet") {
+		// значение уже проверено при разборе команды
+		start, end, _ := models.ParseQuietPeriod(input.String("quiet"))
+		dto.QuietStart = &start
+		dto.QuietEnd = &end
+	}
+
+	return dto
+}
+
+// Export выгружает список адресов в файл
+func (s *MessageService) Export(post *models.Post) error {
+	logger.Info("export addresses", logger.StringAttr("message", post.Message))
+	format := post.Input.String("format")
+	if format == "" {
+		format = models.FormatCSV
+	}
+
+	data, err := s.transfer.Export(context.Background(), format)
+	if err != nil {
+		s.post.Reply(post, "#### Ошибка.\nНе удалось выгрузить список адресов.")
+		logger.Error("failed to export addresses.", logger.ErrAttr(err))
+		return err
+	}
+
+	name := fmt.Sprintf("addresses-%s.%s", time.Now().Format("2006-01-02"), format)
+	if err := s.post.SendFile(&models.Post{ChannelID: post.ChannelID, Message: "Список IP-адресов."}, name, data); err != nil {
+		s.post.Reply(post, "#### Ошибка.\nНе удалось загрузить файл.")
+		logger.Error("failed to send export file.", logger.ErrAttr(err))
+		return err
+	}
+	return nil
+}
+
+// Import проверяет файл со списком адресов и показывает изменения. Изменения применяются после подтверждения
+func (s *MessageService) Import(post *models.Post) error {
+	logger.Info("import addresses", logger.StringAttr("message", post.Message))
+
+	switch post.Input.Action {
+	case "apply":
+		plan, err := s.transfer.Confirm(context.Background(), post.Input.Arg("id"), post.UserID, post.ChannelID)
 		if err != nil {
-			s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Не удалось понять период."})
+			switch {
+			case errors.Is(err, models.ErrImportNotFound):
+				s.post.Reply(post, "#### Ошибка.\nИмпорт не найден. Возможно, он уже применен, отменен или устарел.")
+				return nil
+			case errors.Is(err, models.ErrPermissionDenied):
+				s.post.Reply(post, "#### Ошибка.\nНедостаточно прав. Импорт с удалением адресов доступен только администраторам.")
+				return nil
+			case errors.Is(err, models.ErrExist):
+				s.post.Reply(post, "#### Ошибка.\nСписок адресов изменился после проверки файла. Загрузите файл снова.")
+				return nil
+			}
+			s.post.Reply(post, "#### Ошибка.\nНе удалось импортировать адреса. Изменения не сохранены.")
+			logger.Error("failed to import addresses.", logger.ErrAttr(err))
+			return err
+		}
+		s.post.Announce(post, "Импорт применен: "+ImportSummary(plan)+".")
+		return nil
+
+	case "cancel":
+		if err := s.transfer.Cancel(post.Input.Arg("id")); err != nil {
+			s.post.Reply(post, "#### Ошибка.\nИмпорт не найден. Возможно, он уже применен, отменен или устарел.")
 			return nil
 		}
-		end, err := time.Parse("15:04", dates[1])
+		s.post.Reply(post, "Импорт отменен.")
+		return nil
+	}
+
+	var data []byte
+	switch {
+	case len(post.FileIDs) > 1:
+		s.post.Reply(post, "#### Ошибка.\nПриложите один файл.")
+		return nil
+	case len(post.FileIDs) == 1:
+		file, err := s.post.GetFile(post.FileIDs[0])
 		if err != nil {
-			s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Не удалось понять период."})
-			return nil
+			s.post.Reply(post, "#### Ошибка.\nНе удалось получить файл.")
+			logger.Error("failed to get file.", logger.ErrAttr(err))
+			return err
 		}
-		sd := start.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC))
-		ed := end.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC))
+		data = file
+	case strings.TrimSpace(post.Input.Body) != "":
+		// содержимое файла может быть в строках после команды
+		data = []byte(post.Input.Body)
+	default:
+		s.post.Reply(post, "#### Ошибка.\nНе найден список адресов. Приложите файл или добавьте его содержимое после команды.")
+		return nil
+	}
 
-		address.PeriodStart = &sd
-		address.PeriodEnd = &ed
+	plan, err := s.transfer.Plan(context.Background(), data, post.Input.String("format"), post.Input.Bool("prune"))
+	if err != nil {
+		s.post.Reply(post, "#### Ошибка.\nНе удалось проверить файл.")
+		logger.Error("failed to plan import.", logger.ErrAttr(err))
+		return err
 	}
-	if interval, ok := args["-i"]; ok || args["--interval"] != "" {
-		intervalDur, err := time.ParseDuration(interval + "ms")
+
+	if len(plan.Errors) > 0 {
+		lines := []string{
+			"#### Ошибка.\nВ файле есть ошибки, импорт не выполнен.",
+			"| Строка | IP адрес | Ошибка |",
+			"|:--|:--|:--|",
+		}
+		for _, e := range plan.Errors {
+			row := "-"
+			if e.Row > 0 {
+				row = strconv.Itoa(e.Row)
+			}
+			lines = append(lines, fmt.Sprintf("|%s|%s|%s|", row, e.IP, e.Message))
+		}
+		s.post.Reply(post, strings.Join(lines, "\n"))
+		return nil
+	}
+	if plan.Empty() {
+		s.post.Reply(post, fmt.Sprintf("Изменений нет, адресов в файле: %d.", plan.Unchanged))
+		return nil
+	}
+
+	id := s.transfer.Prepare(plan)
+	message := importPreview(plan) + fmt.Sprintf("\n\nПрименить: `import apply %s`, отменить: `import cancel %s`.", id, id)
+	return s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: message, Actions: s.transfer.Actions(id)})
+}
+
+// количество строк в предпросмотре импорта, остальные изменения только подсчитываются
+const importPreviewSize = 50
+
+// importPreview формирует таблицу изменений импорта
+func importPreview(plan *models.ImportPlan) string {
+	lines := []string{
+		"##### Проверка импорта",
+		"Будет " + ImportSummary(plan) + ".",
+		"| Действие | IP адрес | Изменения |",
+		"|:--|:--|:--|",
+	}
+	rows := []string{}
+	for _, dto := range plan.Create {
+		name := ""
+		if dto.Name != nil {
+			name = *dto.Name
+		}
+		rows = append(rows, fmt.Sprintf("|добавить|%s|%s|", dto.IP, name))
+	}
+	for _, change := range plan.Update {
+		rows = append(rows, fmt.Sprintf("|изменить|%s|%s|", change.IP, formatFieldChanges(change.Fields)))
+	}
+	for _, ip := range plan.Delete {
+		rows = append(rows, fmt.Sprintf("|**удалить**|%s||", ip))
+	}
+
+	if len(rows) > importPreviewSize {
+		lines = append(lines, rows[:importPreviewSize]...)
+		lines = append(lines, "", fmt.Sprintf("И еще изменений: %d.", len(rows)-importPreviewSize))
+	} else {
+		lines = append(lines, rows...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatFieldChanges(changes []*models.FieldChange) string {
+	fields := make([]string, 0, len(changes))
+	for _, f := range changes {
+		fields = append(fields, fmt.Sprintf("%s: «%s» → «%s»", f.Name, f.Old, f.New))
+	}
+	return strings.Join(fields, "; ")
+}
+
+// количество записей журнала в ответе на команду audit
+const auditListSize = 50
+
+// Audit выводит журнал изменений адресов. По умолчанию за последние 7 дней
+func (s *MessageService) Audit(post *models.Post) error {
+	now := time.Now()
+	filter := &models.AuditFilter{
+		IP:          post.Input.Arg("ip"),
+		PeriodStart: now.AddDate(0, 0, -7),
+		Limit:       auditListSize + 1,
+	}
+	if value := post.Input.String("period"); value != "" {
+		start, end, err := parseStatisticPeriod(value, now)
 		if err != nil {
-			s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Не удалось понять интервал."})
+			s.post.Reply(post, "#### Ошибка.\nНекорректное значение флага «--period»: ожидается диапазон дат.")
 			return nil
 		}
-		address.Interval = &intervalDur
+		// дата окончания входит в период
+		filter.PeriodStart, filter.PeriodEnd = start, end.AddDate(0, 0, 1)
 	}
-	if timeout, ok := args["-t"]; ok || args["--timeout"] != "" {
-		timeoutDur, err := time.ParseDuration(timeout + "ms")
-		if err != nil {
-			s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Не удалось понять таймаут."})
+
+	data, err := s.audit.Get(context.Background(), filter)
+	if err != nil {
+		s.post.Reply(post, "#### Ошибка.\nПри получении журнала изменений произошла ошибка")
+		logger.Error("failed to get audit log.", logger.ErrAttr(err))
+		return err
+	}
+	if len(data) == 0 {
+		s.post.Reply(post, "Ничего не найдено")
+		return nil
+	}
+
+	lines := []string{
+		"| Время | IP адрес | Действие | Пользователь | Команда | Изменения |",
+		"|:--|:--|:--|:--|:--|:--|",
+	}
+	for i, entry := range data {
+		if i == auditListSize {
+			break
+		}
+		user := "-"
+		if entry.Username != "" {
+			user = "@" + entry.Username
+		}
+		action := models.AuditTitles[entry.Action]
+		if entry.Undone {
+			action += " (отменено)"
+		}
+		command, _, _ := strings.Cut(entry.Command, "\n")
+		changes := ""
+		if entry.Before != nil && entry.After != nil {
+			changes = formatFieldChanges(entry.Changes)
+		}
+		lines = append(lines, fmt.Sprintf("|%s|%s|%s|%s|`%s`|%s|",
+			entry.Created.Local().Format("02.01.2006 15:04:05"), entry.IP, action, user,
+			strings.ReplaceAll(command, "`", "'"), strings.ReplaceAll(changes, "|", "\\|"),
+		))
+	}
+	if len(data) > auditListSize {
+		lines = append(lines, "", fmt.Sprintf("Показаны последние %d записей, уточните адрес или период.", auditListSize))
+	}
+
+	s.post.Reply(post, strings.Join(lines, "\n"))
+	return nil
+}
+
+// Restore восстанавливает удаленный адрес, без адреса выводит список удаленных адресов
+func (s *MessageService) Restore(post *models.Post) error {
+	ip := post.Input.Arg("ip")
+	if ip == "" {
+		return s.deleted(post)
+	}
+	logger.Info("restore ip", logger.StringAttr("message", post.Message))
+
+	before, err := s.snapshot(post, []string{ip})
+	if err != nil {
+		return err
+	}
+	if err := s.addresses.Restore(context.Background(), ip); err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			s.post.Reply(post, "#### Ошибка.\nСреди удаленных нет такого IP адреса. Список удаленных адресов: `restore`.")
+			return nil
+		}
+		if errors.Is(err, models.ErrManaged) {
+			s.post.Reply(post, "#### Ошибка.\nАдрес убран из файла конфигурации, вернуть его можно только там.")
 			return nil
 		}
-		address.Timeout = &timeoutDur
+		s.post.Reply(post, "#### Ошибка.\nНе удалось восстановить IP адрес.")
+		logger.Error("failed to restore address.", logger.ErrAttr(err))
+		return err
 	}
-	if count, ok := args["-c"]; ok || args["--count"] != "" {
-		countInt, err := strconv.Atoi(count)
-		if err != nil {
-			s.post.Send(&models.Post{
-				ChannelID: post.ChannelID,
-				Message:   "#### Ошибка.\nНе удалось распознать команду. Не удалось понять количество пакетов.",
-			})
+	s.record(post, models.AuditRestore, []string{ip}, before)
+
+	s.post.Announce(post, "IP адрес восстановлен.")
+	return nil
+}
+
+// deleted выводит удаленные адреса, которые еще можно восстановить
+func (s *MessageService) deleted(post *models.Post) error {
+	data, err := s.addresses.GetDeleted(context.Background())
+	if err != nil {
+		s.post.Reply(post, "#### Ошибка.\nПроизошла ошибка при получении удаленных адресов.")
+		logger.Error("failed to get deleted addresses.", logger.ErrAttr(err))
+		return err
+	}
+	if len(data) == 0 {
+		s.post.Reply(post, "Удаленных адресов нет.")
+		return nil
+	}
+
+	retention := s.addresses.Retention()
+	table := []string{"| IP-адрес | Название | Удален |", "|:--|:--|:--|"}
+	if retention > 0 {
+		table = []string{"| IP-адрес | Название | Удален | Будет удален окончательно |", "|:--|:--|:--|:--|"}
+	}
+	format := "02.01.2006 15:04"
+	for _, a := range data {
+		row := fmt.Sprintf("|%s|%s|%s|", a.IP, a.Name, a.Deleted.Local().Format(format))
+		if retention > 0 {
+			row += a.Deleted.Add(retention).Local().Format(format) + "|"
+		}
+		table = append(table, row)
+	}
+
+	s.post.Reply(post, strings.Join(table, "\n"))
+	return nil
+}
+
+// Undo отменяет последнюю команду пользователя, изменившую адреса
+func (s *MessageService) Undo(post *models.Post) error {
+	logger.Info("undo", logger.StringAttr("message", post.Message))
+
+	res, err := s.audit.Undo(context.Background(), post)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			s.post.Reply(post, "Нет изменений, которые можно отменить.")
+			return nil
+		}
+		s.post.Reply(post, "#### Ошибка.\nНе удалось отменить изменения.")
+		logger.Error("failed to undo.", logger.ErrAttr(err))
+		return err
+	}
+
+	command, _, _ := strings.Cut(res.Command, "\n")
+	message := fmt.Sprintf("Отменена команда `%s`.\n", strings.ReplaceAll(command, "`", "'"))
+	if len(res.Results) > 1 {
+		s.post.Announce(post, message+bulkSummary(res.Results, "отменено"))
+		return nil
+	}
+
+	result := res.Results[0]
+	switch result.Status {
+	case models.BulkChanged:
+		s.post.Reply(post, fmt.Sprintf("#### Ошибка.\nАдрес %s изменен после этой команды, отмена не выполнена. Подробнее в `audit %s`.", result.IP, result.IP))
+	case models.BulkManaged:
+		s.post.Reply(post, "#### Ошибка.\nАдрес задан в файле конфигурации, изменить его можно только там.")
+	case models.BulkNotFound:
+		s.post.Reply(post, "#### Ошибка.\nАдрес уже удален окончательно, отмена не выполнена.")
+	default:
+		s.post.Announce(post, message+fmt.Sprintf("IP адрес %s возвращен к прежнему состоянию.", result.IP))
+	}
+	return nil
+}
+
+// snapshot сохраняет состояние адресов перед изменением для журнала. При ошибке ответ пользователю уже отправлен
+func (s *MessageService) snapshot(post *models.Post, ips []string) (map[string]*models.Address, error) {
+	before, err := s.audit.Snapshot(context.Background(), ips)
+	if err != nil {
+		s.post.Reply(post, "#### Ошибка.\nПри получении адресов произошла ошибка")
+		logger.Error("failed to get addresses snapshot.", logger.ErrAttr(err))
+		return nil, err
+	}
+	return before, nil
+}
+
+// record записывает изменения в журнал. Изменения уже сохранены, поэтому ошибка записи только логируется
+func (s *MessageService) record(post *models.Post, action string, ips []string, before map[string]*models.Address) {
+	if err := s.audit.Record(context.Background(), post, action, ips, before); err != nil {
+		logger.Error("failed to record audit.", logger.StringAttr("message", post.Message), logger.ErrAttr(err))
+	}
+}
+
+// expandAddresses разворачивает аргумент команды в список адресов. При ошибке ответ пользователю уже отправлен
+func (s *MessageService) expandAddresses(post *models.Post) ([]string, error) {
+	ips, err := models.ExpandAddresses(post.Input.Arg("ip"))
+	if err != nil {
+		if errors.Is(err, models.ErrTooManyAddresses) {
+			s.post.Reply(post, fmt.Sprintf("#### Ошибка.\nСлишком много адресов, за раз можно изменить не больше %d.", models.MaxBulkAddresses))
+			return nil, err
+		}
+		s.post.Reply(post, "#### Ошибка.\nНекорректный IP адрес, подсеть или диапазон.")
+		return nil, err
+	}
+	return ips, nil
+}
+
+// bulkSummary формирует отчет о массовом изменении адресов. done - описание успешно выполненного действия
+func bulkSummary(results []*models.BulkResult, done string) string {
+	titles := map[string]string{
+		models.BulkDone:     done,
+		models.BulkExists:   "уже добавлен",
+		models.BulkNotFound: "не найден",
+		models.BulkManaged:  "задан в конфигурации",
+		models.BulkChanged:  "изменен позже, пропущен",
+	}
+	counts := map[string]int{}
+	for _, r := range results {
+		counts[r.Status]++
+	}
+
+	header := fmt.Sprintf("Обработано адресов: %d, успешно: %d", len(results), counts[models.BulkDone])
+	if counts[models.BulkExists] > 0 {
+		header += fmt.Sprintf(", уже добавлено: %d", counts[models.BulkExists])
+	}
+	if counts[models.BulkNotFound] > 0 {
+		header += fmt.Sprintf(", не найдено: %d", counts[models.BulkNotFound])
+	}
+	if counts[models.BulkManaged] > 0 {
+		header += fmt.Sprintf(", заданы в конфигурации: %d", counts[models.BulkManaged])
+	}
+	if counts[models.BulkChanged] > 0 {
+		header += fmt.Sprintf(", изменены позже: %d", counts[models.BulkChanged])
+	}
+
+	lines := []string{header + ".", "| IP адрес | Результат |", "|:--|:--|"}
+	for _, r := range results {
+		lines = append(lines, fmt.Sprintf("|%s|%s|", r.IP, titles[r.Status]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// decodeAddress собирает параметры адреса из аргументов и флагов команды. Значения уже проверены при разборе команды
+func decodeAddress(input *models.CommandInput) *models.AddressDTO {
+	address := &models.AddressDTO{IP: input.Arg("ip")}
+
+	str := func(flag string) *string {
+		if !input.Has(flag) {
+			return nil
+		}
+		value := input.String(flag)
+		return &value
+	}
+	duration := func(flag string) *time.Duration {
+		if !input.Has(flag) {
 			return nil
 		}
-		address.Count = &countInt
+		value := input.Duration(flag)
+		return &value
+	}
+	integer := func(flag string) *int {
+		if !input.Has(flag) {
+			return nil
+		}
+		value := input.Int(flag)
+		return &value
+	}
+
+	address.Name = str("name")
+	address.TimeZone = str("timezone")
+	address.Cron = str("schedule")
+	address.MaxRTT = duration("rtt")
+	address.Interval = duration("interval")
+	address.Timeout = duration("timeout")
+	address.CheckInterval = duration("every")
+	address.NotificationCount = integer("notification")
+	address.Count = integer("count")
+
+	if input.Has("groups") {
+		address.Groups = models.ParseGroups(input.String("groups"))
+	}
+	if input.Has("period") {
+		address.Windows, _ = models.ParseWindows(input.String("period"))
+	}
+	if input.Has("holidays") {
+		skip := input.Bool("holidays")
+		address.SkipHolidays = &skip
 	}
 
 	return address
 }
 
-// func (s *MessageService) decodeNew(post *models.Post) *models.AddressDTO {
-// 	address := &models.AddressDTO{}
-
-// 	// parts := strings.Split(message, " ")
-// 	pattern := regexp.MustCompile(`\"[^\"]+\"|\S+`)
-// 	args := pattern.FindAllString(post.Message, -1)
-// 	if net.ParseIP(args[1]) == nil {
-// 		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Некорректный IP адрес."})
-// 		return nil
-// 	}
-// 	address.IP = args[1]
-
-// 	args = args[2:]
-
-// 	opts := models.Decode{}
-
-// 	_, err := flags.ParseArgs(&opts, args)
-// 	if err != nil {
-
-// 		logger.Debug("failed to parse args.", logger.ErrAttr(err))
-// 		//TODO надо бы как-то указать пользователя какой флаг неправильно задан
-// 		s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду."})
-// 		return nil
-// 	}
-// 	// logger.Debug("decode", logger.AnyAttr("opts", opts))
-
-// 	address.Name = opts.Name
-// 	address.Count = opts.Count
-// 	address.NotificationCount = opts.NotificationCount
-// 	// address.Enabled = opts.Enabled
-// 	times := [5]time.Duration{}
-
-// 	if opts.MaxRTT != 0 {
-// 		times[0] = time.Duration(opts.MaxRTT) * time.Millisecond
-// 		address.MaxRTT = &times[0]
-// 	}
-// 	if opts.Interval != 0 {
-// 		times[1] = time.Duration(opts.Interval) * time.Millisecond
-// 		address.Interval = &times[1]
-// 	}
-// 	if opts.Timeout != 0 {
-// 		times[2] = time.Duration(opts.Timeout) * time.Millisecond
-// 		address.Timeout = &times[2]
-// 	}
-// 	if opts.Period != "" {
-// 		dates := strings.Split(opts.Period, "-")
-// 		if len(dates) != 2 {
-// 			s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Не удалось понять период."})
-// 			return nil
-// 		}
-// 		start, err := time.Parse("15:04", dates[0])
-// 		if err != nil {
-// 			s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Не удалось понять период."})
-// 			return nil
-// 		}
-// 		end, err := time.Parse("15:04", dates[1])
-// 		if err != nil {
-// 			s.post.Send(&models.Post{ChannelID: post.ChannelID, Message: "#### Ошибка.\nНе удалось распознать команду. Не удалось понять период."})
-// 			return nil
-// 		}
-// 		times[3] = start.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC))
-// 		times[4] = end.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC))
-
-// 		address.PeriodStart = &times[3]
-// 		address.PeriodEnd = &times[4]
-// 	}
-
-// 	return address
-// }
+// parseStatisticPeriod разбирает период статистики в формате <день>[.<месяц>[.<год>]]-<день>[.<месяц>[.<год>]].
+// Не указанные месяц и год берутся из текущей даты
+func parseStatisticPeriod(value string, now time.Time) (time.Time, time.Time, error) {
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid period %q", value)
+	}
+
+	date := func(value string) (time.Time, error) {
+		fields := []int{now.Day(), int(now.Month()), now.Year()}
+		for i, p := range strings.Split(value, ".") {
+			if i >= len(fields) {
+				return time.Time{}, fmt.Errorf("invalid date %q", value)
+			}
+			number, err := strconv.Atoi(p)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid date %q", value)
+			}
+			if number != 0 {
+				fields[i] = number
+			}
+		}
+		return time.Date(fields[2], time.Month(fields[1]), fields[0], 0, 0, 0, 0, now.Location()), nil
+	}
+
+	start, err := date(parts[0])
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := date(parts[1])
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return start, end, nil
+}
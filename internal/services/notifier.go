@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/pkg/events"
+	"github.com/Alexander272/Pinger/pkg/logger"
+)
+
+// размер очереди уведомлений, при переполнении уведомления теряются
+const notifierQueueSize = 1024
+
+// NotifierService отправляет в канал уведомления об изменении состояния адресов
+type NotifierService struct {
+	events Events
+	post   Post
+	alerts Alert
+
+	mx   sync.Mutex
+	sub  *events.Subscription[*models.Event]
+	done chan struct{}
+}
+
+func NewNotifierService(events Events, post Post, alerts Alert) *NotifierService {
+	return &NotifierService{
+		events: events,
+		post:   post,
+		alerts: alerts,
+	}
+}
+
+type Notifier interface {
+	Start()
+	Stop()
+}
+
+func (s *NotifierService) Start() {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.sub != nil {
+		return
+	}
+	s.sub = s.events.Subscribe(&models.EventFilter{
+		Types: []string{models.EventDown, models.EventUp, models.EventSlow, models.EventNormal},
+	}, notifierQueueSize)
+	s.done = make(chan struct{})
+
+	go s.run(s.sub, s.done)
+}
+
+// Stop прекращает отправку уведомлений, уже полученные события отправляются до конца
+func (s *NotifierService) Stop() {
+	s.mx.Lock()
+	sub, done := s.sub, s.done
+	s.sub = nil
+	s.mx.Unlock()
+
+	if sub != nil {
+		sub.Close()
+		<-done
+		if dropped := sub.Dropped(); dropped > 0 {
+			logger.Warn("notifications were dropped", logger.Int64Attr("count", dropped))
+		}
+	}
+}
+
+func (s *NotifierService) run(sub *events.Subscription[*models.Event], done chan struct{}) {
+	defer close(done)
+
+	for event := range sub.C {
+		switch event.Type {
+		case models.EventDown, models.EventSlow:
+			// уведомление приняли или включили тишину
+			if s.alerts.Muted(event.IP) {
+				continue
+			}
+		case models.EventUp:
+			s.alerts.Resolve(event.IP)
+		}
+
+		message := s.message(event)
+		if message == "" {
+			continue
+		}
+		post := &models.Post{Message: message}
+		if event.Type == models.EventDown {
+			post.Actions = s.alerts.Actions(event.IP)
+		}
+		s.post.Send(post)
+	}
+}
+
+func (s *NotifierService) message(event *models.Event) string {
+	switch event.Type {
+	case models.EventDown:
+		return fmt.Sprintf("Пинг по адресу **%s (%s)** не прошел.\n```\n%s\n```", event.IP, event.Name, event.Details)
+	case models.EventUp:
+		return fmt.Sprintf("Пинг по адресу **%s (%s)** прошел.", event.IP, event.Name)
+	case models.EventSlow:
+		return fmt.Sprintf("Превышено допустимое время пинга **(%s)** для IP **%s (%s)**", event.State.AvgRtt.String(), event.IP, event.Name)
+	case models.EventNormal:
+		return fmt.Sprintf("Время пинга **(%s)** для IP **%s (%s)** в норме", event.State.AvgRtt.String(), event.IP, event.Name)
+	}
+	return ""
+}
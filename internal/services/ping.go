@@ -2,45 +2,90 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Alexander272/Pinger/internal/metrics"
 	"github.com/Alexander272/Pinger/internal/models"
 	"github.com/Alexander272/Pinger/pkg/error_bot"
 	"github.com/Alexander272/Pinger/pkg/logger"
+	"github.com/Alexander272/Pinger/pkg/pool"
 	"github.com/gin-gonic/gin"
 	probing "github.com/prometheus-community/pro-bing"
 )
 
 type PingService struct {
-	addresses Address
-	stats     Statistic
-	post      Post
+	addresses    Address
+	stats        Statistic
+	measurements Measurement
+	post         Post
+	events       Events
+	holidays     models.HolidayChecker
 
 	failed *models.Counters
 	long   *models.Counters
+
+	pool    atomic.Pointer[pool.Pool]
+	running sync.Map
+	states  sync.Map
+	skipped atomic.Int64
+	dropped atomic.Int64
+
+	mx           sync.RWMutex
+	lastCheck    time.Time
+	lastDuration time.Duration
+
+	historyMx sync.RWMutex
+	history   map[string][]*models.CheckPoint
 }
 
+// количество последних проверок адреса, которые хранятся в памяти для графиков
+const historySize = 60
+
 type PingDeps struct {
-	Address Address
-	Stats   Statistic
-	Post    Post
+	Address     Address
+	Stats       Statistic
+	Measurement Measurement
+	Post        Post
+	Events      Events
+	Holidays    models.HolidayChecker
+	MaxCount    int
 }
 
 func NewPingService(deps *PingDeps) *PingService {
-	return &PingService{
-		addresses: deps.Address,
-		stats:     deps.Stats,
-		post:      deps.Post,
+	service := &PingService{
+		addresses:    deps.Address,
+		stats:        deps.Stats,
+		measurements: deps.Measurement,
+		post:         deps.Post,
+		events:       deps.Events,
+		holidays:     deps.Holidays,
 
 		failed: models.NewCounters(),
 		long:   models.NewCounters(),
+
+		history: make(map[string][]*models.CheckPoint),
 	}
+	service.pool.Store(pool.New(deps.MaxCount, deps.MaxCount))
+
+	return service
 }
 
 type Ping interface {
 	Ping(addr *models.Address) (*models.PingStatistic, error)
-	CheckPing(hostIP string)
+	Check(ctx context.Context, addr *models.Address, hostIP string) error
+	Stats() *models.CheckStats
+	States() []*models.CheckState
+	GetState(ip string) (*models.CheckState, bool)
+	History(ip string) []*models.CheckPoint
+	SetMaxCount(count int)
+	Close()
+	AddressObserver
 }
 
 func (s *PingService) Ping(addr *models.Address) (*models.PingStatistic, error) {
@@ -75,7 +120,7 @@ func (s *PingService) Ping(addr *models.Address) (*models.PingStatistic, error)
 	return statistic, nil
 }
 
-func (s *PingService) SendPing(addr *models.Address, hostIP string) {
+func (s *PingService) SendPing(ctx context.Context, addr *models.Address, hostIP string) {
 	pinger, err := probing.NewPinger(addr.IP)
 	if err != nil {
 		logger.Error("failed to create new pinger.", logger.ErrAttr(err))
@@ -88,16 +133,25 @@ func (s *PingService) SendPing(addr *models.Address, hostIP string) {
 	pinger.Interval = addr.Interval
 	pinger.Timeout = addr.Timeout
 
-	err = pinger.Run() // Blocks until finished.
-	if err != nil {
+	err = pinger.RunWithContext(ctx) // Blocks until finished.
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
 		logger.Error("failed to run pinger.", logger.ErrAttr(err))
 		error_bot.Send(&gin.Context{}, err.Error(), nil)
 
 		s.post.Send(&models.Post{Message: "Произошла ошибка при запуске pinger."})
 		return
 	}
+	// при прерывании по времени цикла статистика неполная, поэтому по ней нельзя судить о доступности адреса
+	if ctx.Err() != nil {
+		s.dropped.Add(1)
+		logger.Warn("ping was interrupted by cycle deadline", logger.StringAttr("ip", addr.IP))
+		return
+	}
 
 	stats := pinger.Statistics()
+	state := s.setState(addr, stats)
+	s.publish(models.EventCheck, addr, state, 0, "")
+	s.saveMeasurement(state)
 
 	if stats.PacketLoss > 50 {
 		count, ok := s.failed.Load(addr.IP)
@@ -118,16 +172,14 @@ func (s *PingService) SendPing(addr *models.Address, hostIP string) {
 			statistics := fmt.Sprintf("--- ping statistics. from %s to %s ---\n%d packets transmitted, %d packets received, %v%% packet loss",
 				hostIP, addr.IP, stats.PacketsSent, stats.PacketsRecv, stats.PacketLoss,
 			)
-			message := fmt.Sprintf("Пинг по адресу **%s (%s)** не прошел.\n```\n%s\n```", addr.IP, addr.Name, statistics)
-			s.post.Send(&models.Post{Message: message})
+			s.publish(models.EventDown, addr, state, count, statistics)
 		}
 		return
 	}
 
 	count, ok := s.failed.Load(addr.IP)
 	if ok && count != 0 {
-		message := fmt.Sprintf("Пинг по адресу **%s (%s)** прошел.", addr.IP, addr.Name)
-		s.post.Send(&models.Post{Message: message})
+		s.publish(models.EventUp, addr, state, 0, "")
 		s.failed.Store(addr.IP, 0)
 
 		stats := &models.StatisticDTO{IP: addr.IP, TimeEnd: time.Now()}
@@ -144,43 +196,243 @@ func (s *PingService) SendPing(addr *models.Address, hostIP string) {
 		count, ok := s.long.Load(addr.IP)
 		if addr.NotificationCount == 0 || !ok || count < addr.NotificationCount {
 			s.long.Inc(addr.IP)
-
-			message := fmt.Sprintf("Превышено допустимое время пинга **(%s)** для IP **%s (%s)**", stats.AvgRtt.String(), addr.IP, addr.Name)
-			s.post.Send(&models.Post{Message: message})
+			s.publish(models.EventSlow, addr, state, count, "")
 		}
 	} else {
 		count, ok := s.long.Load(addr.IP)
 		if ok && count != 0 {
-			message := fmt.Sprintf("Время пинга **(%s)** для IP **%s (%s)** в норме", stats.AvgRtt.String(), addr.IP, addr.Name)
-			s.post.Send(&models.Post{Message: message})
+			s.publish(models.EventNormal, addr, state, 0, "")
 			s.long.Store(addr.IP, 0)
 		}
 	}
 }
 
-func (s *PingService) CheckPing(hostIP string) {
-	addresses, err := s.addresses.Get(context.Background())
+func (s *PingService) publish(eventType string, addr *models.Address, state *models.CheckState, repeat int, details string) {
+	s.events.Publish(&models.Event{
+		Type:    eventType,
+		IP:      addr.IP,
+		Name:    addr.Name,
+		Groups:  addr.Groups,
+		State:   state,
+		Repeat:  repeat,
+		Details: details,
+		Time:    state.CheckedAt,
+	})
+}
+
+// Check выполняет проверку адреса через общий пул воркеров. Если предыдущая проверка этого адреса
+// еще не завершилась, новая пропускается. Ошибка возвращается, если проверка не была выполнена до конца.
+// Адрес вне периода проверок не проверяется, это не ошибка
+func (s *PingService) Check(ctx context.Context, addr *models.Address, hostIP string) error {
+	if !addr.IsActive(time.Now(), s.holidays) {
+		return nil
+	}
+
+	if _, loaded := s.running.LoadOrStore(addr.IP, struct{}{}); loaded {
+		s.skipped.Add(1)
+		p := s.pool.Load()
+		logger.Warn("previous check is not finished. check skipped", logger.StringAttr("ip", addr.IP),
+			logger.Int64Attr("queue", p.QueueDepth()), logger.Int64Attr("inFlight", p.InFlight()),
+		)
+		return errors.New("previous check is not finished")
+	}
+	defer s.running.Delete(addr.IP)
+
+	logger.Debug("ping", logger.AnyAttr("addr", addr))
+	done := make(chan struct{})
+	start := time.Now()
+
+	task := func() {
+		defer close(done)
+		if ctx.Err() != nil {
+			s.dropped.Add(1)
+			return
+		}
+		s.SendPing(ctx, addr, hostIP)
+	}
+	// пул могут заменить между получением и постановкой задачи, тогда задача ставится в новый пул
+	var err error
+	for {
+		p := s.pool.Load()
+		err = p.Submit(ctx, task)
+		if !errors.Is(err, pool.ErrClosed) || s.pool.Load() == p {
+			break
+		}
+	}
 	if err != nil {
-		logger.Error("failed to get addresses.", logger.ErrAttr(err))
-		error_bot.Send(&gin.Context{}, err.Error(), nil)
-		s.post.Send(&models.Post{Message: "Произошла ошибка при получении адресов."})
-		return
+		s.dropped.Add(1)
+		logger.Warn("failed to queue ping", logger.StringAttr("ip", addr.IP), logger.ErrAttr(err))
+		return fmt.Errorf("failed to queue ping. error: %w", err)
 	}
+	<-done
+
+	duration := time.Since(start)
+	metrics.CheckDuration.Observe(duration.Seconds())
+	s.mx.Lock()
+	s.lastCheck = time.Now()
+	s.lastDuration = duration
+	s.mx.Unlock()
 
-	// urls := make(chan struct{}, 20)
+	if err := ctx.Err(); err != nil {
+		logger.Warn("check exceeded deadline", logger.StringAttr("ip", addr.IP), logger.DurationAttr("duration", duration))
+		return err
+	}
+	return nil
+}
+
+func (s *PingService) Stats() *models.CheckStats {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	p := s.pool.Load()
+	return &models.CheckStats{
+		Workers:      p.Size(),
+		QueueDepth:   p.QueueDepth(),
+		InFlight:     p.InFlight(),
+		Skipped:      s.skipped.Load(),
+		Dropped:      s.dropped.Load(),
+		LastCheck:    s.lastCheck,
+		LastDuration: s.lastDuration,
+	}
+}
+
+// States возвращает результаты последних проверок всех адресов
+func (s *PingService) States() []*models.CheckState {
+	states := []*models.CheckState{}
+	s.states.Range(func(key, value any) bool {
+		state := *value.(*models.CheckState)
+		states = append(states, &state)
+		return true
+	})
+	sort.Slice(states, func(i, j int) bool { return states[i].IP < states[j].IP })
+	return states
+}
 
+func (s *PingService) GetState(ip string) (*models.CheckState, bool) {
+	value, ok := s.states.Load(ip)
+	if !ok {
+		return nil, false
+	}
+	state := *value.(*models.CheckState)
+	return &state, true
+}
+
+func (s *PingService) setState(addr *models.Address, stats *probing.Statistics) *models.CheckState {
 	now := time.Now()
-	for _, address := range addresses {
-		isAfter, isBefore := true, true
-		if address.PeriodStart != 0 && address.PeriodEnd != 0 {
-			isAfter = now.After(time.Date(now.Year(), now.Month(), now.Day(), 0, int(address.PeriodStart.Minutes()), 0, 0, now.Location()))
-			isBefore = now.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, int(address.PeriodEnd.Minutes()), 0, 0, now.Location()))
+	state := &models.CheckState{
+		IP:         addr.IP,
+		Name:       addr.Name,
+		Groups:     addr.Groups,
+		IsFailed:   stats.PacketLoss > 50,
+		IsLong:     addr.MaxRTT != 0 && stats.AvgRtt >= addr.MaxRTT,
+		PacketLoss: stats.PacketLoss,
+		MinRtt:     stats.MinRtt,
+		AvgRtt:     stats.AvgRtt,
+		MaxRtt:     stats.MaxRtt,
+		StdDevRtt:  stats.StdDevRtt,
+		CheckedAt:  now,
+		ChangedAt:  now,
+	}
+	if value, ok := s.states.Load(addr.IP); ok {
+		prev := value.(*models.CheckState)
+		if prev.IsFailed == state.IsFailed {
+			state.ChangedAt = prev.ChangedAt
 		}
-		if !isAfter || !isBefore {
-			continue
+	}
+	s.states.Store(addr.IP, state)
+	s.addHistory(state)
+
+	if !state.IsFailed {
+		metrics.Rtt.WithLabelValues(metrics.AddressLabels(addr.IP, addr.Name, addr.Groups)...).Observe(stats.AvgRtt.Seconds())
+	}
+	return state
+}
+
+// saveMeasurement сохраняет результат проверки для построения графиков
+func (s *PingService) saveMeasurement(state *models.CheckState) {
+	measurement := &models.Measurement{
+		IP:         state.IP,
+		AvgRtt:     state.AvgRtt,
+		PacketLoss: state.PacketLoss,
+		IsFailed:   state.IsFailed,
+		Time:       state.CheckedAt,
+	}
+	if err := s.measurements.Create(context.Background(), measurement); err != nil {
+		logger.Error("failed to save measurement.", logger.StringAttr("ip", state.IP), logger.ErrAttr(err))
+	}
+}
+
+// History возвращает последние результаты проверок адреса, начиная с самого старого
+func (s *PingService) History(ip string) []*models.CheckPoint {
+	s.historyMx.RLock()
+	defer s.historyMx.RUnlock()
+	return slices.Clone(s.history[ip])
+}
+
+func (s *PingService) addHistory(state *models.CheckState) {
+	point := &models.CheckPoint{Time: state.CheckedAt, AvgRtt: state.AvgRtt, PacketLoss: state.PacketLoss, IsFailed: state.IsFailed}
+
+	s.historyMx.Lock()
+	defer s.historyMx.Unlock()
+
+	points := append(s.history[state.IP], point)
+	if len(points) > historySize {
+		points = slices.Clone(points[len(points)-historySize:])
+	}
+	s.history[state.IP] = points
+}
+
+func (s *PingService) AddressChanged(ctx context.Context, address *models.Address) {
+	if !address.Enabled {
+		s.states.Delete(address.IP)
+		metrics.DeleteAddress(address.IP)
+		s.closeOutage(ctx, address.IP)
+		return
+	}
+	// при смене названия или групп меняются метки, старые ряды метрик больше не обновятся
+	if value, ok := s.states.Load(address.IP); ok {
+		prev := value.(*models.CheckState)
+		if prev.Name != address.Name || !slices.Equal(prev.Groups, address.Groups) {
+			s.states.Delete(address.IP)
+			metrics.DeleteAddress(address.IP)
 		}
+	}
+}
+
+func (s *PingService) AddressDeleted(ctx context.Context, ip string) {
+	s.states.Delete(ip)
+	metrics.DeleteAddress(ip)
+	s.historyMx.Lock()
+	delete(s.history, ip)
+	s.historyMx.Unlock()
+	s.closeOutage(ctx, ip)
+}
+
+// closeOutage завершает простой адреса, который больше не проверяется. Иначе адрес остается недоступным
+// в статистике навсегда, а после повторного добавления новый простой сливается со старым
+func (s *PingService) closeOutage(ctx context.Context, ip string) {
+	s.failed.Store(ip, 0)
+	s.long.Store(ip, 0)
+
+	stats := &models.StatisticDTO{IP: ip, TimeEnd: time.Now()}
+	if err := s.stats.Update(ctx, stats); err != nil {
+		logger.Error("failed to close outage.", logger.StringAttr("ip", ip), logger.ErrAttr(err))
+		error_bot.Send(&gin.Context{}, err.Error(), stats)
+	}
+}
 
-		logger.Debug("ping", logger.AnyAttr("addr", address))
-		go s.SendPing(address, hostIP)
+// SetMaxCount заменяет пул воркеров, если изменилось количество одновременных пингов или пул закрыт
+// после остановки планировщика. Уже поставленные в очередь проверки выполняются старым пулом,
+// он закрывается после их завершения
+func (s *PingService) SetMaxCount(count int) {
+	if p := s.pool.Load(); p.Size() == count && !p.Closed() {
+		return
 	}
+	old := s.pool.Swap(pool.New(count, count))
+	go old.Close()
+}
+
+// Close закрывает пул после завершения поставленных проверок. Новый пул создается при следующем запуске планировщика
+func (s *PingService) Close() {
+	s.pool.Load().Close()
 }
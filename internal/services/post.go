@@ -1,8 +1,14 @@
 package services
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"sync"
+	"time"
 
+	"github.com/Alexander272/Pinger/internal/metrics"
 	"github.com/Alexander272/Pinger/internal/models"
 	"github.com/Alexander272/Pinger/pkg/error_bot"
 	"github.com/gin-gonic/gin"
@@ -12,32 +18,211 @@ import (
 type PostService struct {
 	channelID string
 	client    *model.Client4
+	http      *http.Client
+
+	mx    sync.Mutex
+	botID string
 }
 
 func NewPostService(client *model.Client4, channelID string) *PostService {
 	return &PostService{
 		channelID: channelID,
 		client:    client,
+		http:      &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
 type Post interface {
 	Send(post *models.Post) error
+	SendDirect(userID string, post *models.Post) error
+	GetFile(fileID string) ([]byte, error)
+	SendFile(post *models.Post, name string, data []byte) error
+	Get(postID string) (*models.Post, error)
+	Patch(postID string, message string) error
+	OpenDialog(triggerID string, dialog *models.Dialog) error
+	Reply(to *models.Post, message string) error
+	Announce(to *models.Post, message string) error
 }
 
 func (s *PostService) Send(data *models.Post) error {
 	post := &model.Post{
 		ChannelId: data.ChannelID,
 		Message:   data.Message,
+		FileIds:   data.FileIDs,
 	}
 	if data.ChannelID == "" {
 		post.ChannelId = s.channelID
 	}
+	if len(data.Actions) > 0 {
+		model.ParseSlackAttachment(post, []*model.SlackAttachment{{Actions: postActions(data.Actions)}})
+	}
 
 	_, _, err := s.client.CreatePost(post)
 	if err != nil {
+		metrics.MattermostErrors.Inc()
 		error_bot.Send(&gin.Context{}, err.Error(), data)
 		return fmt.Errorf("failed to send message. error: %w", err)
 	}
 	return nil
 }
+
+func (s *PostService) GetFile(fileID string) ([]byte, error) {
+	data, _, err := s.client.GetFile(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file. error: %w", err)
+	}
+	return data, nil
+}
+
+func (s *PostService) Get(postID string) (*models.Post, error) {
+	post, _, err := s.client.GetPost(postID, "")
+	if err != nil {
+		metrics.MattermostErrors.Inc()
+		return nil, fmt.Errorf("failed to get post. error: %w", err)
+	}
+	return &models.Post{ID: post.Id, ChannelID: post.ChannelId, UserID: post.UserId, Message: post.Message}, nil
+}
+
+// Patch изменяет текст сообщения, вложения и кнопки остаются прежними
+func (s *PostService) Patch(postID string, message string) error {
+	if _, _, err := s.client.PatchPost(postID, &model.PostPatch{Message: &message}); err != nil {
+		metrics.MattermostErrors.Inc()
+		return fmt.Errorf("failed to patch post. error: %w", err)
+	}
+	return nil
+}
+
+func (s *PostService) OpenDialog(triggerID string, dialog *models.Dialog) error {
+	elements := make([]model.DialogElement, 0, len(dialog.Elements))
+	for _, e := range dialog.Elements {
+		elements = append(elements, model.DialogElement{
+			DisplayName: e.DisplayName,
+			Name:        e.Name,
+			Type:        e.Type,
+			SubType:     e.SubType,
+			Default:     e.Default,
+			Placeholder: e.Placeholder,
+			HelpText:    e.HelpText,
+			Optional:    e.Optional,
+		})
+	}
+
+	_, err := s.client.OpenInteractiveDialog(model.OpenDialogRequest{
+		TriggerId: triggerID,
+		URL:       dialog.URL,
+		Dialog: model.Dialog{
+			CallbackId:       dialog.CallbackID,
+			Title:            dialog.Title,
+			IntroductionText: dialog.Intro,
+			SubmitLabel:      dialog.SubmitLabel,
+			State:            dialog.State,
+			Elements:         elements,
+		},
+	})
+	if err != nil {
+		metrics.MattermostErrors.Inc()
+		return fmt.Errorf("failed to open dialog. error: %w", err)
+	}
+	return nil
+}
+
+// Reply отвечает на команду. Ответ на slash-команду видит только ее автор
+func (s *PostService) Reply(to *models.Post, message string) error {
+	if to.ResponseURL != "" {
+		return s.respond(to.ResponseURL, model.CommandResponseTypeEphemeral, message)
+	}
+	return s.Send(&models.Post{ChannelID: to.ChannelID, Message: message})
+}
+
+// Announce отвечает на команду так, чтобы ответ видели все участники канала. Используется для сообщений об изменениях
+func (s *PostService) Announce(to *models.Post, message string) error {
+	if to.ResponseURL != "" {
+		return s.respond(to.ResponseURL, model.CommandResponseTypeInChannel, message)
+	}
+	return s.Send(&models.Post{ChannelID: to.ChannelID, Message: message})
+}
+
+func (s *PostService) respond(url, responseType, message string) error {
+	body, err := json.Marshal(&model.CommandResponse{ResponseType: responseType, Text: message})
+	if err != nil {
+		return fmt.Errorf("failed to marshal command response. error: %w", err)
+	}
+
+	res, err := s.http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		metrics.MattermostErrors.Inc()
+		return fmt.Errorf("failed to send command response. error: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		metrics.MattermostErrors.Inc()
+		return fmt.Errorf("failed to send command response. status: %s", res.Status)
+	}
+	return nil
+}
+
+// SendFile загружает файл в канал и отправляет сообщение с ним
+func (s *PostService) SendFile(data *models.Post, name string, file []byte) error {
+	channelID := data.ChannelID
+	if channelID == "" {
+		channelID = s.channelID
+	}
+
+	res, _, err := s.client.UploadFile(file, channelID, name)
+	if err != nil {
+		metrics.MattermostErrors.Inc()
+		return fmt.Errorf("failed to upload file. error: %w", err)
+	}
+
+	post := &models.Post{ChannelID: channelID, Message: data.Message}
+	for _, info := range res.FileInfos {
+		post.FileIDs = append(post.FileIDs, info.Id)
+	}
+	return s.Send(post)
+}
+
+// SendDirect отправляет сообщение пользователю в личный канал с ботом
+func (s *PostService) SendDirect(userID string, data *models.Post) error {
+	botID, err := s.getBotID()
+	if err != nil {
+		return err
+	}
+
+	channel, _, err := s.client.CreateDirectChannel(botID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to create direct channel. error: %w", err)
+	}
+	return s.Send(&models.Post{ChannelID: channel.Id, Message: data.Message})
+}
+
+func (s *PostService) getBotID() (string, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.botID == "" {
+		bot, _, err := s.client.GetMe("")
+		if err != nil {
+			return "", fmt.Errorf("failed to get bot user. error: %w", err)
+		}
+		s.botID = bot.Id
+	}
+	return s.botID, nil
+}
+
+func postActions(actions []*models.Action) []*model.PostAction {
+	res := make([]*model.PostAction, 0, len(actions))
+	for _, a := range actions {
+		res = append(res, &model.PostAction{
+			Id:    a.ID,
+			Type:  model.PostActionTypeButton,
+			Name:  a.Name,
+			Style: a.Style,
+			Integration: &model.PostActionIntegration{
+				URL:     a.URL,
+				Context: a.Context,
+			},
+		})
+	}
+	return res
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/repo"
+	"github.com/Alexander272/Pinger/pkg/logger"
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// RoleService определяет роль пользователя бота. Роль складывается из списка администраторов в конфиге,
+// ролей Mattermost, выданной через бота роли и прав в канале, из которого пришла команда
+type RoleService struct {
+	repo  repo.Role
+	users User
+}
+
+func NewRoleService(repo repo.Role, users User) *RoleService {
+	return &RoleService{
+		repo:  repo,
+		users: users,
+	}
+}
+
+type Role interface {
+	GetAll(context.Context) ([]*models.UserRole, error)
+	Resolve(ctx context.Context, userID, channelID string) string
+	Allowed(ctx context.Context, userID, channelID, required string) bool
+	Grant(context.Context, *models.UserRoleDTO) error
+	Revoke(ctx context.Context, username string) error
+}
+
+func (s *RoleService) GetAll(ctx context.Context) ([]*models.UserRole, error) {
+	data, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user roles. error: %w", err)
+	}
+	return data, nil
+}
+
+// Resolve возвращает роль пользователя. Администраторы из конфига и системные администраторы Mattermost
+// всегда администраторы бота, администраторы канала получают права оператора
+func (s *RoleService) Resolve(ctx context.Context, userID, channelID string) string {
+	if userID == "" {
+		return models.RoleViewer
+	}
+	if s.users.IsAdmin(userID) {
+		return models.RoleAdmin
+	}
+
+	user, err := s.users.Get(userID)
+	if err != nil {
+		logger.Error("failed to get user.", logger.StringAttr("user", userID), logger.ErrAttr(err))
+	} else if slices.Contains(user.Roles, model.SystemAdminRoleId) {
+		return models.RoleAdmin
+	}
+
+	role := models.RoleViewer
+	data, err := s.repo.Get(ctx, userID)
+	if err != nil && !errors.Is(err, models.ErrNoRows) {
+		logger.Error("failed to get user role.", logger.StringAttr("user", userID), logger.ErrAttr(err))
+	}
+	if err == nil {
+		role = data.Role
+	}
+
+	if !models.RoleAllows(role, models.RoleOperator) && s.users.IsChannelAdmin(channelID, userID) {
+		role = models.RoleOperator
+	}
+	return role
+}
+
+func (s *RoleService) Allowed(ctx context.Context, userID, channelID, required string) bool {
+	return models.RoleAllows(s.Resolve(ctx, userID, channelID), required)
+}
+
+// Grant выдает роль пользователю по имени
+func (s *RoleService) Grant(ctx context.Context, dto *models.UserRoleDTO) error {
+	if !models.IsRole(dto.Role) {
+		return models.ErrInvalidRole
+	}
+
+	user, err := s.users.GetByUsername(dto.Username)
+	if err != nil {
+		return err
+	}
+	dto.UserID = user.ID
+	dto.Username = user.Username
+
+	if err := s.repo.Set(ctx, dto); err != nil {
+		return fmt.Errorf("failed to set user role. error: %w", err)
+	}
+	return nil
+}
+
+// Revoke отзывает выданную через бота роль. Права из конфига и Mattermost при этом сохраняются
+func (s *RoleService) Revoke(ctx context.Context, username string) error {
+	user, err := s.users.GetByUsername(username)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, user.ID); err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			return err
+		}
+		return fmt.Errorf("failed to delete user role. error: %w", err)
+	}
+	return nil
+}
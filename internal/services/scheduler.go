@@ -1,31 +1,73 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math/rand"
 	"os"
+	"sync"
 	"time"
 
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/repo"
+	"github.com/Alexander272/Pinger/pkg/logger"
 	"github.com/Alexander272/Pinger/pkg/mattermost"
 	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
 )
 
+// maxCronJitter ограничивает случайную задержку перед проверкой для адресов с cron-расписанием
+const maxCronJitter = 10 * time.Second
+
 type SchedulerService struct {
-	cron   gocron.Scheduler
-	ping   Ping
-	client *mattermost.Client
+	repo         repo.Scheduler
+	cron         gocron.Scheduler
+	ping         Ping
+	addresses    Address
+	measurements Measurement
+	client       *mattermost.Client
+	hostIP       string
+
+	mx        sync.Mutex
+	defaults  *models.Scheduler
+	conf      *models.Scheduler
+	jobs      map[string]uuid.UUID
+	periods   map[string]time.Duration // ожидаемый период между проверками адреса
+	running   bool
+	startedAt time.Time
+	lastCheck time.Time // время завершения последней проверки адреса
+}
+
+type SchedulerDeps struct {
+	Repo        repo.Scheduler
+	Ping        Ping
+	Address     Address
+	Measurement Measurement
+	Client      *mattermost.Client
+	// настройки по умолчанию, используются если в базе еще нет настроек
+	Conf *models.Scheduler
 }
 
-func NewSchedulerService(ping Ping, client *mattermost.Client) *SchedulerService {
+func NewSchedulerService(deps *SchedulerDeps) *SchedulerService {
 	cron, err := gocron.NewScheduler()
 	if err != nil {
 		log.Fatalf("failed to create new scheduler. error: %s", err.Error())
 	}
 
 	return &SchedulerService{
-		cron:   cron,
-		ping:   ping,
-		client: client,
+		repo:         deps.Repo,
+		cron:         cron,
+		ping:         deps.Ping,
+		addresses:    deps.Address,
+		measurements: deps.Measurement,
+		client:       deps.Client,
+		defaults:     deps.Conf,
+		conf:         deps.Conf,
+		jobs:         make(map[string]uuid.UUID),
+		periods:      make(map[string]time.Duration),
 	}
 }
 
@@ -33,48 +75,309 @@ type Scheduler interface {
 	Start() error
 	Restart() error
 	Stop() error
+	GetSettings(ctx context.Context) (*models.Scheduler, error)
+	UpdateSettings(ctx context.Context, dto *models.SchedulerDTO) error
+	Status() *models.SchedulerStatus
+	SetDefaults(defaults *models.Scheduler)
+	AddressObserver
+}
+
+func (s *SchedulerService) GetSettings(ctx context.Context) (*models.Scheduler, error) {
+	data, err := s.repo.Get(ctx)
+	if err != nil {
+		if !errors.Is(err, models.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get scheduler settings. error: %w", err)
+		}
+
+		data = &models.Scheduler{}
+		s.mx.Lock()
+		*data = *s.defaults
+		s.mx.Unlock()
+		if err := s.repo.Create(ctx, data); err != nil {
+			return nil, fmt.Errorf("failed to create scheduler settings. error: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// SetDefaults заменяет настройки из файла конфигурации. Они используются, пока настройки не сохранены в базе
+func (s *SchedulerService) SetDefaults(defaults *models.Scheduler) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.defaults = defaults
+}
+
+func (s *SchedulerService) UpdateSettings(ctx context.Context, dto *models.SchedulerDTO) error {
+	data, err := s.GetSettings(ctx)
+	if err != nil {
+		return err
+	}
+
+	if dto.Interval != nil {
+		data.Interval = *dto.Interval
+	}
+	if dto.MaxCount != nil {
+		data.MaxCount = *dto.MaxCount
+	}
+	if dto.CycleTimeout != nil {
+		data.CycleTimeout = *dto.CycleTimeout
+	}
+	if dto.StartDelay != nil {
+		data.StartDelay = *dto.StartDelay
+	}
+	if dto.QuietStart != nil {
+		data.QuietStart = *dto.QuietStart
+	}
+	if dto.QuietEnd != nil {
+		data.QuietEnd = *dto.QuietEnd
+	}
+	if data.Interval < models.MinCheckInterval || data.MaxCount < 1 || data.CycleTimeout < 0 || data.StartDelay < 0 {
+		return models.ErrInvalidSettings
+	}
+
+	if err := s.repo.Update(ctx, data); err != nil {
+		return fmt.Errorf("failed to update scheduler settings. error: %w", err)
+	}
+	return nil
 }
 
 func (s *SchedulerService) Start() error {
 	// hostIP := utils.GetOutboundIP().String()
-	// поскольку я запускаю бота через docker compose, выполняя команду выше я получаю ip контейнера, а не хоста. Поэтому приходится задавать ip через env
-	hostIP := os.Getenv("HOST_IP")
-	jobStart := time.Now().Add(1 * time.Minute)
+	// поскольку я запускаю бота через docker compose, выполняя команду выше я получаю ip контейнера, а не хоста. Поэтому приходится задавать ip через env
+	s.hostIP = os.Getenv("HOST_IP")
 
-	// job := gocron.DurationJob(conf.Interval)
-	job := gocron.DurationJob(1 * time.Minute)
-	task := gocron.NewTask(s.job, hostIP)
-	jobStartAt := gocron.WithStartAt(gocron.WithStartDateTime(jobStart))
+	conf, err := s.GetSettings(context.Background())
+	if err != nil {
+		return err
+	}
+	s.mx.Lock()
+	s.conf = conf
+	s.startedAt = time.Now()
+	s.lastCheck = time.Time{}
+	s.mx.Unlock()
+	s.ping.SetMaxCount(conf.MaxCount)
+	logger.Info("scheduler settings", logger.AnyAttr("settings", conf))
+
+	// задача для проверки подключения к mattermost
+	job := gocron.DurationJob(conf.Interval)
+	task := gocron.NewTask(s.job)
+	jobStartAt := gocron.WithStartAt(gocron.WithStartDateTime(time.Now().Add(conf.StartDelay + conf.Interval)))
 
-	_, err := s.cron.NewJob(job, task, jobStartAt)
+	_, err = s.cron.NewJob(job, task, jobStartAt)
 	if err != nil {
 		return fmt.Errorf("failed to create new job. error: %w", err)
 	}
 
-	//? запуск крона через интервал
-	s.cron.Start()
+	// ночная очистка устаревших результатов проверок и удаленных адресов
+	cleanup := gocron.DailyJob(1, gocron.NewAtTimes(gocron.NewAtTime(3, 0, 0)))
+	_, err = s.cron.NewJob(cleanup, gocron.NewTask(s.cleanup), gocron.WithName("cleanup"))
+	if err != nil {
+		return fmt.Errorf("failed to create cleanup job. error: %w", err)
+	}
+
+	addresses, err := s.addresses.Get(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get addresses. error: %w", err)
+	}
+	for _, address := range addresses {
+		if err := s.schedule(address); err != nil {
+			logger.Error("failed to schedule address.", logger.StringAttr("ip", address.IP), logger.ErrAttr(err))
+		}
+	}
+
+	// cron запускается один раз, при перезапуске заменяются только задачи. Повторный вызов Start
+	// запускает еще один обработчик задач gocron, который конкурирует с первым
+	s.mx.Lock()
+	if !s.running {
+		s.cron.Start()
+		s.running = true
+	}
+	s.mx.Unlock()
 	return nil
 }
 
 func (s *SchedulerService) Restart() error {
-	if err := s.Stop(); err != nil {
-		return err
+	s.mx.Lock()
+	for _, job := range s.cron.Jobs() {
+		if err := s.cron.RemoveJob(job.ID()); err != nil {
+			logger.Error("failed to remove job.", logger.StringAttr("name", job.Name()), logger.ErrAttr(err))
+		}
 	}
+	s.jobs = make(map[string]uuid.UUID)
+	s.periods = make(map[string]time.Duration)
+	s.mx.Unlock()
+
 	if err := s.Start(); err != nil {
 		return err
 	}
 	return nil
 }
 
+// Stop останавливает проверки. Остановленный gocron повторно не запускается, поэтому он заменяется новым,
+// а пул воркеров создается заново при следующем Start
 func (s *SchedulerService) Stop() error {
-	if err := s.cron.Shutdown(); err != nil {
+	s.mx.Lock()
+	s.running = false
+	current := s.cron
+	s.mx.Unlock()
+
+	if err := current.Shutdown(); err != nil {
 		return fmt.Errorf("failed to shutdown cron scheduler. error: %w", err)
 	}
+	s.ping.Close()
+
+	cron, err := gocron.NewScheduler()
+	if err != nil {
+		return fmt.Errorf("failed to create new scheduler. error: %w", err)
+	}
+	s.mx.Lock()
+	s.cron = cron
+	s.jobs = make(map[string]uuid.UUID)
+	s.periods = make(map[string]time.Duration)
+	s.mx.Unlock()
+	return nil
+}
+
+func (s *SchedulerService) Status() *models.SchedulerStatus {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	// проверки разных адресов идут независимо, поэтому очередная проверка ожидается не позже самого короткого периода
+	var period time.Duration
+	for _, p := range s.periods {
+		if period == 0 || p < period {
+			period = p
+		}
+	}
+
+	return &models.SchedulerStatus{
+		Running:     s.running,
+		Interval:    s.conf.Interval,
+		StartDelay:  s.conf.StartDelay,
+		StartedAt:   s.startedAt,
+		Addresses:   len(s.jobs),
+		CheckPeriod: period,
+		LastCheck:   s.lastCheck,
+	}
+}
+
+func (s *SchedulerService) AddressChanged(ctx context.Context, address *models.Address) {
+	if !address.Enabled {
+		s.unschedule(address.IP)
+		return
+	}
+	if err := s.schedule(address); err != nil {
+		logger.Error("failed to schedule address.", logger.StringAttr("ip", address.IP), logger.ErrAttr(err))
+	}
+}
+
+func (s *SchedulerService) AddressDeleted(ctx context.Context, ip string) {
+	s.unschedule(ip)
+}
+
+// schedule создает задачу для проверки адреса или заменяет существующую
+func (s *SchedulerService) schedule(address *models.Address) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	// некорректный интервал (например, сохраненный до появления проверок) заменяется интервалом по умолчанию
+	interval := address.CheckInterval
+	if interval <= 0 {
+		interval = s.conf.Interval
+	}
+	interval = max(interval, models.MinCheckInterval)
+
+	var job gocron.JobDefinition
+	options := []gocron.JobOption{gocron.WithName(address.IP), gocron.WithTags(address.IP)}
+	jitter := time.Duration(0)
+	period := interval
+
+	if address.Cron != "" {
+		job = gocron.CronJob(address.Cron, false)
+		jitter = time.Duration(rand.Int63n(int64(maxCronJitter)))
+		period = cronPeriod(address.Cron) + maxCronJitter
+	} else {
+		job = gocron.DurationJob(interval)
+		// случайное смещение первого запуска, чтобы проверки не запускались одновременно
+		start := time.Now().Add(s.conf.StartDelay + time.Duration(rand.Int63n(int64(interval))))
+		options = append(options, gocron.WithStartAt(gocron.WithStartDateTime(start)))
+	}
+	task := gocron.NewTask(s.check, address, interval, jitter)
+
+	var (
+		created gocron.Job
+		err     error
+	)
+	if id, ok := s.jobs[address.IP]; ok {
+		created, err = s.cron.Update(id, job, task, options...)
+	} else {
+		created, err = s.cron.NewJob(job, task, options...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create job. error: %w", err)
+	}
+	// после простоя без адресов отсчет ожидания проверки начинается заново, как при запуске
+	if len(s.jobs) == 0 {
+		s.lastCheck = time.Now().Add(s.conf.StartDelay)
+	}
+	s.jobs[address.IP] = created.ID()
+	s.periods[address.IP] = period
+
+	logger.Debug("address scheduled", logger.StringAttr("ip", address.IP), logger.DurationAttr("interval", interval), logger.StringAttr("cron", address.Cron))
 	return nil
 }
 
-func (s *SchedulerService) job(hostIP string) {
-	s.ping.CheckPing(hostIP)
+func (s *SchedulerService) unschedule(ip string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	id, ok := s.jobs[ip]
+	if !ok {
+		return
+	}
+	if err := s.cron.RemoveJob(id); err != nil {
+		logger.Error("failed to remove job.", logger.StringAttr("ip", ip), logger.ErrAttr(err))
+	}
+	delete(s.jobs, ip)
+	delete(s.periods, ip)
+}
+
+func (s *SchedulerService) check(address *models.Address, interval, jitter time.Duration) {
+	s.mx.Lock()
+	conf := s.conf
+	s.mx.Unlock()
+
+	// в период тишины проверка не нужна, для контроля состояния планировщика она считается выполненной
+	if inQuietHours(conf, time.Now()) {
+		s.checked()
+		return
+	}
+	if jitter > 0 {
+		time.Sleep(jitter)
+	}
+
+	timeout := conf.CycleTimeout
+	if timeout == 0 || timeout > interval {
+		timeout = interval
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := s.ping.Check(ctx, address, s.hostIP); err != nil {
+		return
+	}
+	s.checked()
+}
+
+// checked отмечает завершение проверки адреса. По этому времени контроль состояния определяет, что проверки не зависли
+func (s *SchedulerService) checked() {
+	s.mx.Lock()
+	s.lastCheck = time.Now()
+	s.mx.Unlock()
+}
+
+func (s *SchedulerService) job() {
+	logger.Debug("check stats", logger.AnyAttr("stats", s.ping.Stats()))
 
 	if !s.client.IsConnected() {
 		ok := s.client.Reconnect()
@@ -83,3 +386,36 @@ func (s *SchedulerService) job(hostIP string) {
 		}
 	}
 }
+
+func (s *SchedulerService) cleanup() {
+	if err := s.measurements.Cleanup(context.Background()); err != nil {
+		logger.Error("failed to cleanup measurements.", logger.ErrAttr(err))
+	}
+	if err := s.addresses.Purge(context.Background()); err != nil {
+		logger.Error("failed to purge deleted addresses.", logger.ErrAttr(err))
+	}
+}
+
+// cronPeriod возвращает промежуток между ближайшими запусками по cron-расписанию. Расписание уже проверено при сохранении адреса
+func cronPeriod(spec string) time.Duration {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return 0
+	}
+	next := schedule.Next(time.Now())
+	return schedule.Next(next).Sub(next)
+}
+
+// inQuietHours проверяет попадает ли время в период тишины. Период может переходить через полночь
+func inQuietHours(conf *models.Scheduler, now time.Time) bool {
+	if conf.QuietStart == conf.QuietEnd {
+		return false
+	}
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	current := now.Sub(midnight)
+	if conf.QuietStart < conf.QuietEnd {
+		return current >= conf.QuietStart && current < conf.QuietEnd
+	}
+	return current >= conf.QuietStart || current < conf.QuietEnd
+}
@@ -1,6 +1,9 @@
 package services
 
 import (
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
 	"github.com/Alexander272/Pinger/internal/repo"
 	"github.com/Alexander272/Pinger/pkg/mattermost"
 )
@@ -9,34 +12,115 @@ type Services struct {
 	Post
 	Address
 	Statistic
+	Holiday
 	Ping
 	Information
 	Message
 	Scheduler
+	Token
+	User
+	Role
+	Channel
+	Health
+	Heartbeat
+	Events
+	Notifier
+	StatusPage
+	Measurement
+	Graph
+	Command
+	Alert
+	Dialog
+	Transfer
+	Audit
 }
 
 type Deps struct {
-	Repo      *repo.Repository
-	Client    *mattermost.Client
-	ChannelID string
+	Repo       *repo.Repository
+	Client     *mattermost.Client
+	ChannelID  string
+	Scheduler  *models.Scheduler
+	Admins     []string
+	Heartbeat  *models.Heartbeat
+	StatusPage *models.StatusPageConf
+	// срок хранения результатов проверок
+	Retention time.Duration
+	Command   *models.SlashCommand
+	Alerts    *models.AlertsConf
+	Dialogs   *models.DialogConf
+	Channels  *models.ChannelsConf
+	Transfer  *models.TransferConf
+	// параметры проверки новых адресов по умолчанию
+	Defaults *models.AddressDefaults
+	// срок хранения удаленных адресов
+	DeletedRetention time.Duration
 }
 
 func NewServices(deps *Deps) *Services {
 	post := NewPostService(deps.Client.Http, deps.ChannelID)
-	addresses := NewAddressService(deps.Repo.Address)
-	statistic := NewStatisticService(deps.Repo.Statistic)
-	ping := NewPingService(&PingDeps{Address: addresses, Stats: statistic, Post: post})
+	addresses := NewAddressService(&AddressDeps{Repo: deps.Repo.Address, Defaults: deps.Defaults, Retention: deps.DeletedRetention})
+	holiday := NewHolidayService(deps.Repo.Holiday)
+	token := NewTokenService(deps.Repo.Token)
+	user := NewUserService(deps.Client.Http, deps.Admins)
+	role := NewRoleService(deps.Repo.Role, user)
+	channel := NewChannelService(deps.Client.Http, deps.Channels)
+	statistic := NewStatisticService(&StatisticDeps{Repo: deps.Repo.Statistic, Address: addresses, Holidays: holiday})
+	measurement := NewMeasurementService(deps.Repo.Measurement, deps.Retention)
+	graph := NewGraphService(&GraphDeps{Measurement: measurement, Stats: statistic, Address: addresses})
+	events := NewEventService()
+	audit := NewAuditService(&AuditDeps{Repo: deps.Repo.Audit, Address: addresses, User: user})
+	addresses.SetAudit(audit)
+	alert := NewAlertService(&AlertDeps{Address: addresses, Stats: statistic, Post: post, Role: role, Audit: audit, Conf: deps.Alerts})
+	notifier := NewNotifierService(events, post, alert)
+	ping := NewPingService(&PingDeps{
+		Address: addresses, Stats: statistic, Measurement: measurement, Post: post, Events: events, Holidays: holiday, MaxCount: deps.Scheduler.MaxCount,
+	})
+	dialog := NewDialogService(&DialogDeps{Address: addresses, Post: post, Role: role, Audit: audit, Conf: deps.Dialogs})
+	transfer := NewTransferService(&TransferDeps{Address: addresses, Role: role, Post: post, Audit: audit, Conf: deps.Transfer})
 	information := NewInformationService(post)
-	message := NewMessageService(&MessageDeps{Address: addresses, Stats: statistic, Post: post})
-	scheduler := NewSchedulerService(ping, deps.Client)
+	command := NewCommandService(deps.Client.Http, deps.Command)
+	scheduler := NewSchedulerService(&SchedulerDeps{
+		Repo: deps.Repo.Scheduler, Ping: ping, Address: addresses, Measurement: measurement, Client: deps.Client, Conf: deps.Scheduler,
+	})
+	health := NewHealthService(&HealthDeps{
+		Repo: deps.Repo.Health, Client: deps.Client, Scheduler: scheduler, Ping: ping, MissedInterval: deps.Heartbeat.MissedIntervals,
+	})
+	heartbeat := NewHeartbeatService(&HeartbeatDeps{Health: health, Post: post, Conf: deps.Heartbeat})
+	statusPage := NewStatusPageService(&StatusPageDeps{
+		Repo: deps.Repo.StatusPage, Stats: statistic, Address: addresses, Ping: ping, Holidays: holiday, Conf: deps.StatusPage,
+	})
+	addresses.Subscribe(scheduler)
+	addresses.Subscribe(ping)
+	addresses.Subscribe(alert)
+	message := NewMessageService(&MessageDeps{Address: addresses, Stats: statistic, Post: post, Scheduler: scheduler, Holiday: holiday,
+		Token: token, Role: role, StatusPage: statusPage, Graph: graph, Dialog: dialog, Transfer: transfer,
+		Audit: audit,
+	})
 
 	return &Services{
 		Post:        post,
 		Address:     addresses,
 		Statistic:   statistic,
+		Holiday:     holiday,
 		Ping:        ping,
 		Information: information,
 		Message:     message,
 		Scheduler:   scheduler,
+		Token:       token,
+		User:        user,
+		Role:        role,
+		Channel:     channel,
+		Health:      health,
+		Heartbeat:   heartbeat,
+		Events:      events,
+		Notifier:    notifier,
+		StatusPage:  statusPage,
+		Measurement: measurement,
+		Graph:       graph,
+		Command:     command,
+		Alert:       alert,
+		Dialog:      dialog,
+		Transfer:    transfer,
+		Audit:       audit,
 	}
 }
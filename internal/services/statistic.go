@@ -4,18 +4,29 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Alexander272/Pinger/internal/models"
 	"github.com/Alexander272/Pinger/internal/repo"
 )
 
 type StatisticService struct {
-	repo repo.Statistic
+	repo      repo.Statistic
+	addresses Address
+	holidays  models.HolidayChecker
 }
 
-func NewStatisticService(repo repo.Statistic) *StatisticService {
+type StatisticDeps struct {
+	Repo     repo.Statistic
+	Address  Address
+	Holidays models.HolidayChecker
+}
+
+func NewStatisticService(deps *StatisticDeps) *StatisticService {
 	return &StatisticService{
-		repo: repo,
+		repo:      deps.Repo,
+		addresses: deps.Address,
+		holidays:  deps.Holidays,
 	}
 }
 
@@ -23,15 +34,36 @@ type Statistic interface {
 	Get(ctx context.Context, req *models.GetStatisticDTO) ([]*models.Statistic, error)
 	GetByIP(ctx context.Context, req *models.GetStatisticByIPDTO) ([]*models.Statistic, error)
 	GetUnavailable(ctx context.Context, req *models.GetUnavailableDTO) ([]*models.Statistic, error)
+	GetOutages(ctx context.Context, req *models.GetOutagesDTO) ([]*models.Statistic, error)
 	Create(ctx context.Context, dto *models.StatisticDTO) error
 	Update(ctx context.Context, dto *models.StatisticDTO) error
 }
 
+// Get возвращает суммарное время недоступности по каждому IP. Учитывается только время, попадающее в расписание проверок адреса
 func (s *StatisticService) Get(ctx context.Context, req *models.GetStatisticDTO) ([]*models.Statistic, error) {
-	data, err := s.repo.Get(ctx, req)
+	rows, err := s.repo.Get(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get statistic. error: %w", err)
 	}
+
+	schedules, err := s.schedules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []*models.Statistic{}
+	var last *models.Statistic
+	for _, row := range rows {
+		if last == nil || last.IP != row.IP || last.Name != row.Name {
+			last = &models.Statistic{IP: row.IP, Name: row.Name}
+			data = append(data, last)
+		}
+		last.Time += s.activeDuration(schedules[row.IP], row)
+	}
+	for i := range data {
+		data[i].Time = data[i].Time.Round(time.Second)
+	}
+	localize(data)
 	return data, nil
 }
 
@@ -40,6 +72,15 @@ func (s *StatisticService) GetByIP(ctx context.Context, req *models.GetStatistic
 	if err != nil {
 		return nil, fmt.Errorf("failed to get statistic by ip. error: %w", err)
 	}
+
+	address, err := findAddress(ctx, s.addresses, req.IP)
+	if err != nil && !errors.Is(err, models.ErrNoRows) {
+		return nil, err
+	}
+	for _, d := range data {
+		d.Time = s.activeDuration(address, d).Round(time.Second)
+	}
+	localize(data)
 	return data, nil
 }
 
@@ -48,6 +89,20 @@ func (s *StatisticService) GetUnavailable(ctx context.Context, req *models.GetUn
 	if err != nil {
 		return nil, fmt.Errorf("failed to get unavailable ip. error: %w", err)
 	}
+	localize(data)
+	return data, nil
+}
+
+// GetOutages возвращает простои адресов, пересекающиеся с периодом, без учета расписания
+func (s *StatisticService) GetOutages(ctx context.Context, req *models.GetOutagesDTO) ([]*models.Statistic, error) {
+	if len(req.IPs) == 0 {
+		return []*models.Statistic{}, nil
+	}
+	data, err := s.repo.GetOutages(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outages. error: %w", err)
+	}
+	localize(data)
 	return data, nil
 }
 
@@ -72,3 +127,68 @@ func (s *StatisticService) Update(ctx context.Context, dto *models.StatisticDTO)
 	}
 	return nil
 }
+
+// schedules возвращает расписания адресов, включая удаленные, чтобы их простои считались так же, как до удаления
+func (s *StatisticService) schedules(ctx context.Context) (map[string]*models.Address, error) {
+	addresses, err := s.addresses.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	deleted, err := s.addresses.GetDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := make(map[string]*models.Address, len(addresses)+len(deleted))
+	for _, a := range append(deleted, addresses...) {
+		schedules[a.IP] = a
+	}
+	return schedules, nil
+}
+
+// findAddress ищет адрес среди действующих, затем среди удаленных
+func findAddress(ctx context.Context, addresses Address, ip string) (*models.Address, error) {
+	address, err := addresses.GetByIP(ctx, ip)
+	if !errors.Is(err, models.ErrNoRows) {
+		return address, err
+	}
+
+	deleted, err := addresses.GetDeleted(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range deleted {
+		if a.IP == ip {
+			return a, nil
+		}
+	}
+	return nil, models.ErrNoRows
+}
+
+// activeDuration возвращает время простоя, попадающее в расписание адреса.
+// Если адрес удален окончательно, учитывается все время простоя
+func (s *StatisticService) activeDuration(address *models.Address, row *models.Statistic) time.Duration {
+	if address == nil {
+		return row.Time
+	}
+	// время в таблице хранится без часового пояса (в часовом поясе сервера)
+	start := inLocal(row.TimeStart)
+	end := inLocal(row.TimeEnd)
+	return address.ActiveDuration(start, end, s.holidays)
+}
+
+// localize проставляет часовой пояс сервера, чтобы клиенты API получали корректное время
+func localize(data []*models.Statistic) {
+	for _, d := range data {
+		if !d.TimeStart.IsZero() {
+			d.TimeStart = inLocal(d.TimeStart)
+		}
+		if !d.TimeEnd.IsZero() {
+			d.TimeEnd = inLocal(d.TimeEnd)
+		}
+	}
+}
+
+func inLocal(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+}
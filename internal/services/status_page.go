@@ -0,0 +1,372 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/repo"
+)
+
+// решенные инциденты показываются на странице еще неделю
+const resolvedIncidentsPeriod = 7 * 24 * time.Hour
+
+type StatusPageService struct {
+	repo      repo.StatusPage
+	stats     Statistic
+	addresses Address
+	ping      Ping
+	holidays  models.HolidayChecker
+	conf      *models.StatusPageConf
+
+	mx       sync.Mutex
+	cached   *models.StatusPage
+	cachedAt time.Time
+}
+
+type StatusPageDeps struct {
+	Repo     repo.StatusPage
+	Stats    Statistic
+	Address  Address
+	Ping     Ping
+	Holidays models.HolidayChecker
+	Conf     *models.StatusPageConf
+}
+
+func NewStatusPageService(deps *StatusPageDeps) *StatusPageService {
+	return &StatusPageService{
+		repo:      deps.Repo,
+		stats:     deps.Stats,
+		addresses: deps.Address,
+		ping:      deps.Ping,
+		holidays:  deps.Holidays,
+		conf:      deps.Conf,
+	}
+}
+
+type StatusPage interface {
+	GetComponents(ctx context.Context) ([]*models.StatusComponent, error)
+	CreateComponent(ctx context.Context, dto *models.StatusComponentDTO) error
+	DeleteComponent(ctx context.Context, name string) error
+	GetIncidents(ctx context.Context, req *models.GetIncidentsDTO) ([]*models.Incident, error)
+	OpenIncident(ctx context.Context, dto *models.IncidentDTO) (int, error)
+	UpdateIncident(ctx context.Context, dto *models.IncidentUpdateDTO) error
+	Page(ctx context.Context) (*models.StatusPage, error)
+}
+
+func (s *StatusPageService) GetComponents(ctx context.Context) ([]*models.StatusComponent, error) {
+	data, err := s.repo.GetComponents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status components. error: %w", err)
+	}
+	return data, nil
+}
+
+// CreateComponent добавляет на страницу адрес или группу адресов под понятным названием
+func (s *StatusPageService) CreateComponent(ctx context.Context, dto *models.StatusComponentDTO) error {
+	if dto.Name == "" || (dto.IP == "") == (dto.Group == "") {
+		return models.ErrInvalidComponent
+	}
+	if dto.IP != "" {
+		if net.ParseIP(dto.IP) == nil {
+			return models.ErrInvalidComponent
+		}
+		if _, err := s.addresses.GetByIP(ctx, dto.IP); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.CreateComponent(ctx, dto); err != nil {
+		if errors.Is(err, models.ErrExist) {
+			return err
+		}
+		return fmt.Errorf("failed to create status component. error: %w", err)
+	}
+	s.reset()
+	return nil
+}
+
+func (s *StatusPageService) DeleteComponent(ctx context.Context, name string) error {
+	if err := s.repo.DeleteComponent(ctx, name); err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			return err
+		}
+		return fmt.Errorf("failed to delete status component. error: %w", err)
+	}
+	s.reset()
+	return nil
+}
+
+func (s *StatusPageService) GetIncidents(ctx context.Context, req *models.GetIncidentsDTO) ([]*models.Incident, error) {
+	data, err := s.repo.GetIncidents(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incidents. error: %w", err)
+	}
+	return data, nil
+}
+
+func (s *StatusPageService) OpenIncident(ctx context.Context, dto *models.IncidentDTO) (int, error) {
+	if dto.Status == "" {
+		dto.Status = models.IncidentInvestigating
+	}
+	if dto.Title == "" || !slices.Contains(models.IncidentStatuses, dto.Status) || dto.Status == models.IncidentResolved {
+		return 0, models.ErrInvalidIncident
+	}
+
+	if len(dto.Components) > 0 {
+		components, err := s.GetComponents(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, name := range dto.Components {
+			if !slices.ContainsFunc(components, func(c *models.StatusComponent) bool { return c.Name == name }) {
+				return 0, fmt.Errorf("%w: unknown component %q", models.ErrInvalidComponent, name)
+			}
+		}
+	}
+
+	id, err := s.repo.CreateIncident(ctx, dto)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create incident. error: %w", err)
+	}
+	s.reset()
+	return id, nil
+}
+
+func (s *StatusPageService) UpdateIncident(ctx context.Context, dto *models.IncidentUpdateDTO) error {
+	if !slices.Contains(models.IncidentStatuses, dto.Status) {
+		return models.ErrInvalidIncident
+	}
+
+	if err := s.repo.UpdateIncident(ctx, dto); err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			return err
+		}
+		return fmt.Errorf("failed to update incident. error: %w", err)
+	}
+	s.reset()
+	return nil
+}
+
+// Page собирает данные публичной страницы. Результат кэшируется, т.к. страница открыта для всех
+func (s *StatusPageService) Page(ctx context.Context) (*models.StatusPage, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.cached != nil && time.Since(s.cachedAt) < s.conf.CacheTTL {
+		return s.cached, nil
+	}
+
+	page, err := s.build(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.cached = page
+	s.cachedAt = time.Now()
+	return page, nil
+}
+
+func (s *StatusPageService) reset() {
+	s.mx.Lock()
+	s.cached = nil
+	s.mx.Unlock()
+}
+
+func (s *StatusPageService) build(ctx context.Context) (*models.StatusPage, error) {
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1-s.conf.Days)
+
+	components, err := s.GetComponents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	addresses, err := s.addresses.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[string][]*models.Address, len(components))
+	ips := []string{}
+	for _, c := range components {
+		for _, a := range addresses {
+			if (c.IP != "" && a.IP == c.IP) || (c.Group != "" && slices.Contains(a.Groups, c.Group)) {
+				members[c.Name] = append(members[c.Name], a)
+				ips = append(ips, a.IP)
+			}
+		}
+	}
+
+	outages, err := s.stats.GetOutages(ctx, &models.GetOutagesDTO{IPs: ips, PeriodStart: start, PeriodEnd: now})
+	if err != nil {
+		return nil, err
+	}
+	byIP := make(map[string][]*models.Statistic)
+	for _, o := range outages {
+		byIP[o.IP] = append(byIP[o.IP], o)
+	}
+
+	page := &models.StatusPage{
+		Title:      s.conf.Title,
+		Status:     models.ComponentOperational,
+		Components: make([]*models.PublicComponent, 0, len(components)),
+		Incidents:  []*models.PublicIncident{},
+		Updated:    now,
+	}
+	failing := make(map[string]time.Time)
+
+	for _, c := range components {
+		component := &models.PublicComponent{Name: c.Name, Days: make([]*models.UptimeDay, 0, s.conf.Days)}
+		var since time.Time
+		component.Status, since = s.componentStatus(members[c.Name])
+		if !since.IsZero() {
+			failing[c.Name] = since
+		}
+		page.Status = worseStatus(page.Status, component.Status)
+
+		var totalActive, totalDown time.Duration
+		for day := start; day.Before(now); day = day.AddDate(0, 0, 1) {
+			end := day.AddDate(0, 0, 1)
+			if end.After(now) {
+				end = now
+			}
+
+			var active, down time.Duration
+			for _, a := range members[c.Name] {
+				from := day
+				if a.Created.After(from) {
+					from = a.Created
+				}
+				active += a.ActiveDuration(from, end, s.holidays)
+				for _, o := range byIP[a.IP] {
+					down += a.ActiveDuration(maxTime(o.TimeStart, from), minTime(o.TimeEnd, end), s.holidays)
+				}
+			}
+
+			component.Days = append(component.Days, &models.UptimeDay{Date: day, Uptime: uptime(active, down), Downtime: down.Round(time.Second)})
+			totalActive += active
+			totalDown += down
+		}
+		component.Uptime = uptime(totalActive, totalDown)
+		page.Components = append(page.Components, component)
+	}
+
+	incidents, err := s.GetIncidents(ctx, &models.GetIncidentsDTO{Since: now.Add(-resolvedIncidentsPeriod)})
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range incidents {
+		incident := &models.PublicIncident{
+			Title:      i.Title,
+			Status:     i.Status,
+			Components: i.Components,
+			Started:    i.Created,
+			Updates:    i.Updates,
+		}
+		if i.Resolved.Valid {
+			incident.Resolved = &i.Resolved.Time
+		} else {
+			// для компонентов с открытым инцидентом автоматический инцидент не нужен
+			for _, name := range i.Components {
+				delete(failing, name)
+			}
+		}
+		page.Incidents = append(page.Incidents, incident)
+	}
+
+	for _, c := range components {
+		since, ok := failing[c.Name]
+		if !ok {
+			continue
+		}
+		page.Incidents = append(page.Incidents, &models.PublicIncident{
+			Title:      fmt.Sprintf("Недоступен сервис «%s»", c.Name),
+			Status:     models.IncidentInvestigating,
+			Components: []string{c.Name},
+			Automatic:  true,
+			Started:    since,
+			Updates:    []*models.IncidentUpdate{},
+		})
+	}
+	slices.SortStableFunc(page.Incidents, func(a, b *models.PublicIncident) int { return b.Started.Compare(a.Started) })
+
+	return page, nil
+}
+
+// componentStatus возвращает состояние компонента по последним проверкам его адресов
+// и время начала недоступности, если недоступен хотя бы один адрес
+func (s *StatusPageService) componentStatus(addresses []*models.Address) (string, time.Time) {
+	var checked, failed, slow int
+	var since time.Time
+	for _, a := range addresses {
+		state, ok := s.ping.GetState(a.IP)
+		if !ok {
+			continue
+		}
+		checked++
+		if state.IsFailed {
+			failed++
+			if since.IsZero() || state.ChangedAt.Before(since) {
+				since = state.ChangedAt
+			}
+		} else if state.IsLong {
+			slow++
+		}
+	}
+
+	switch {
+	case checked == 0:
+		return models.ComponentUnknown, since
+	case failed == checked:
+		return models.ComponentOutage, since
+	case failed > 0:
+		return models.ComponentPartial, since
+	case slow > 0:
+		return models.ComponentDegraded, since
+	}
+	return models.ComponentOperational, since
+}
+
+var componentSeverity = map[string]int{
+	models.ComponentOperational: 0,
+	models.ComponentDegraded:    1,
+	models.ComponentPartial:     2,
+	models.ComponentOutage:      3,
+}
+
+// worseStatus возвращает более тяжелое из состояний, неизвестное состояние не учитывается
+func worseStatus(a, b string) string {
+	if _, ok := componentSeverity[b]; !ok {
+		return a
+	}
+	if componentSeverity[b] > componentSeverity[a] {
+		return b
+	}
+	return a
+}
+
+func uptime(active, down time.Duration) *float64 {
+	if active <= 0 {
+		return nil
+	}
+	value := 100 * (1 - float64(min(down, active))/float64(active))
+	return &value
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/repo"
+	"github.com/Alexander272/Pinger/pkg/logger"
+)
+
+// префикс позволяет отличить токен пингера от других секретов (например при поиске утечек в логах)
+const tokenPrefix = "pgr_"
+
+type TokenService struct {
+	repo repo.Token
+}
+
+func NewTokenService(repo repo.Token) *TokenService {
+	return &TokenService{
+		repo: repo,
+	}
+}
+
+type Token interface {
+	GetAll(context.Context) ([]*models.Token, error)
+	Issue(ctx context.Context, dto *models.TokenDTO) (string, error)
+	Authenticate(ctx context.Context, token, scope string) (*models.Token, error)
+	Revoke(ctx context.Context, name string) error
+}
+
+func (s *TokenService) GetAll(ctx context.Context) ([]*models.Token, error) {
+	data, err := s.repo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api tokens. error: %w", err)
+	}
+	return data, nil
+}
+
+// Issue создает новый токен и возвращает его. В базе хранится только хеш, поэтому получить токен повторно нельзя
+func (s *TokenService) Issue(ctx context.Context, dto *models.TokenDTO) (string, error) {
+	if dto.Scope == "" {
+		dto.Scope = models.ScopeRead
+	}
+	if dto.Scope != models.ScopeRead && dto.Scope != models.ScopeAdmin {
+		return "", models.ErrTokenInvalidScope
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate api token. error: %w", err)
+	}
+	token := tokenPrefix + hex.EncodeToString(secret)
+	dto.Hash = hashToken(token)
+
+	if err := s.repo.Create(ctx, dto); err != nil {
+		if errors.Is(err, models.ErrExist) {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to create api token. error: %w", err)
+	}
+	return token, nil
+}
+
+// Authenticate проверяет токен и его область действия
+func (s *TokenService) Authenticate(ctx context.Context, token, scope string) (*models.Token, error) {
+	if token == "" {
+		return nil, models.ErrTokenMissing
+	}
+
+	data, err := s.repo.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			return nil, models.ErrTokenInvalid
+		}
+		return nil, fmt.Errorf("failed to get api token. error: %w", err)
+	}
+	if !data.Allows(scope) {
+		return nil, models.ErrTokenScope
+	}
+
+	if err := s.repo.UpdateLastUsed(ctx, data.ID); err != nil {
+		logger.Error("failed to update token last usage.", logger.ErrAttr(err))
+	}
+	return data, nil
+}
+
+func (s *TokenService) Revoke(ctx context.Context, name string) error {
+	if err := s.repo.Revoke(ctx, name); err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			return err
+		}
+		return fmt.Errorf("failed to revoke api token. error: %w", err)
+	}
+	return nil
+}
+
+// токены генерируются случайно и имеют высокую энтропию, поэтому соль и медленный хеш не нужны
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,562 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/pkg/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// время, в течение которого можно подтвердить импорт
+const importTTL = 30 * time.Minute
+
+// TransferService выгружает список адресов в файл и загружает его обратно.
+// Импорт в чате выполняется в два шага: сначала показываются изменения, затем они применяются после подтверждения
+type TransferService struct {
+	addresses Address
+	roles     Role
+	post      Post
+	audit     Audit
+	conf      *models.TransferConf
+
+	mx      sync.Mutex
+	pending map[string]*pendingImport
+}
+
+type pendingImport struct {
+	plan    *models.ImportPlan
+	expires time.Time
+}
+
+type TransferDeps struct {
+	Address Address
+	Role    Role
+	Post    Post
+	Audit   Audit
+	Conf    *models.TransferConf
+}
+
+func NewTransferService(deps *TransferDeps) *TransferService {
+	return &TransferService{
+		addresses: deps.Address,
+		roles:     deps.Role,
+		post:      deps.Post,
+		audit:     deps.Audit,
+		conf:      deps.Conf,
+		pending:   make(map[string]*pendingImport),
+	}
+}
+
+type Transfer interface {
+	Export(ctx context.Context, format string) ([]byte, error)
+	Plan(ctx context.Context, data []byte, format string, prune bool) (*models.ImportPlan, error)
+	Apply(ctx context.Context, plan *models.ImportPlan) error
+	Prepare(plan *models.ImportPlan) string
+	Confirm(ctx context.Context, id, userID, channelID string) (*models.ImportPlan, error)
+	Cancel(id string) error
+	Actions(id string) []*models.Action
+	Handle(ctx context.Context, req *models.ActionRequest) (string, error)
+}
+
+// Export выгружает все адреса в указанном формате
+func (s *TransferService) Export(ctx context.Context, format string) ([]byte, error) {
+	data, err := s.addresses.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*models.AddressRecord, 0, len(data))
+	for _, a := range data {
+		records = append(records, models.NewAddressRecord(a))
+	}
+
+	switch format {
+	case models.FormatCSV:
+		buf := &bytes.Buffer{}
+		w := csv.NewWriter(buf)
+		w.Write(models.AddressColumns)
+		for _, r := range records {
+			w.Write(r.Values())
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, fmt.Errorf("failed to write csv. error: %w", err)
+		}
+		return buf.Bytes(), nil
+
+	case models.FormatJSON:
+		res, err := json.MarshalIndent(records, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal json. error: %w", err)
+		}
+		return res, nil
+
+	case models.FormatYAML:
+		res, err := yaml.Marshal(records)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal yaml. error: %w", err)
+		}
+		return res, nil
+	}
+	return nil, models.ErrUnknownFormat
+}
+
+// Plan проверяет файл и сравнивает его с текущим списком адресов. Пустой формат определяется по содержимому.
+// Адреса, которых нет в файле, удаляются только при prune. Колонки, которых нет в файле, не изменяются
+func (s *TransferService) Plan(ctx context.Context, data []byte, format string, prune bool) (*models.ImportPlan, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if format == "" {
+		format = detectFormat(data)
+	}
+	plan := &models.ImportPlan{
+		Create: []*models.AddressDTO{},
+		Update: []*models.AddressChange{},
+		Delete: []string{},
+		Errors: []*models.ImportError{},
+	}
+
+	rows, err := decodeRecords(data, format)
+	if err != nil {
+		if errors.Is(err, models.ErrUnknownFormat) {
+			return nil, err
+		}
+		plan.Errors = append(plan.Errors, &models.ImportError{Message: err.Error()})
+		return plan, nil
+	}
+
+	current, err := s.addresses.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]*models.Address, len(current))
+	for _, a := range current {
+		existing[a.IP] = a
+	}
+
+	seen := make(map[string]int, len(rows))
+	for i, values := range rows {
+		row := i + 1
+		ip := strings.TrimSpace(values["ip"])
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			plan.Errors = append(plan.Errors, &models.ImportError{Row: row, IP: ip, Message: "некорректный IP адрес"})
+			continue
+		}
+		ip = addr.String()
+		if first, ok := seen[ip]; ok {
+			plan.Errors = append(plan.Errors, &models.ImportError{Row: row, IP: ip, Message: fmt.Sprintf("адрес уже указан в строке %d", first)})
+			continue
+		}
+		seen[ip] = row
+
+		dto, fields := parseAddressRecord(values)
+		if len(fields) > 0 {
+			plan.Errors = append(plan.Errors, &models.ImportError{Row: row, IP: ip, Message: formatFieldErrors(fields)})
+			continue
+		}
+		dto.IP = ip
+
+		data, ok := existing[ip]
+		if !ok {
+			plan.Create = append(plan.Create, dto)
+			continue
+		}
+		changes := diffAddress(data, dto.Apply(data))
+		if len(changes) == 0 {
+			plan.Unchanged++
+			continue
+		}
+		if data.Managed {
+			plan.Errors = append(plan.Errors, &models.ImportError{Row: row, IP: ip, Message: "адрес задан в файле конфигурации, изменить его можно только там"})
+			continue
+		}
+		dto.Fill(data)
+		plan.Update = append(plan.Update, &models.AddressChange{IP: ip, Fields: changes, Address: dto})
+	}
+
+	// адреса из файла конфигурации не удаляются
+	if prune {
+		for _, a := range current {
+			if _, ok := seen[a.IP]; !ok && !a.Managed {
+				plan.Delete = append(plan.Delete, a.IP)
+			}
+		}
+	}
+	return plan, nil
+}
+
+// Apply применяет план импорта, если в файле не было ошибок
+func (s *TransferService) Apply(ctx context.Context, plan *models.ImportPlan) error {
+	if len(plan.Errors) > 0 {
+		return models.ErrInvalidImport
+	}
+	return s.addresses.Import(ctx, plan)
+}
+
+// Prepare сохраняет план до подтверждения и возвращает его идентификатор
+func (s *TransferService) Prepare(plan *models.ImportPlan) string {
+	buf := make([]byte, 4)
+	rand.Read(buf)
+	id := hex.EncodeToString(buf)
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.cleanup()
+	s.pending[id] = &pendingImport{plan: plan, expires: time.Now().Add(importTTL)}
+	return id
+}
+
+// Confirm применяет сохраненный план. Импорт с удалением адресов доступен только администраторам
+func (s *TransferService) Confirm(ctx context.Context, id, userID, channelID string) (*models.ImportPlan, error) {
+	s.mx.Lock()
+	s.cleanup()
+	pending, ok := s.pending[id]
+	s.mx.Unlock()
+	if !ok {
+		return nil, models.ErrImportNotFound
+	}
+
+	required := models.RoleOperator
+	if len(pending.plan.Delete) > 0 {
+		required = models.RoleAdmin
+	}
+	if !s.roles.Allowed(ctx, userID, channelID, required) {
+		return nil, models.ErrPermissionDenied
+	}
+
+	// план применяется один раз, даже если применить его не удалось
+	s.mx.Lock()
+	_, ok = s.pending[id]
+	delete(s.pending, id)
+	s.mx.Unlock()
+	if !ok {
+		return nil, models.ErrImportNotFound
+	}
+
+	ips := pending.plan.IPs()
+	before, err := s.audit.Snapshot(ctx, ips)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Apply(ctx, pending.plan); err != nil {
+		return nil, err
+	}
+	// действие уточняется для каждого адреса при записи: новые записываются как добавленные, пропавшие - как удаленные
+	change := &models.Post{UserID: userID, ChannelID: channelID, Message: "import apply " + id}
+	if err := s.audit.Record(ctx, change, models.AuditUpdate, ips, before); err != nil {
+		logger.Error("failed to record audit.", logger.StringAttr("id", id), logger.ErrAttr(err))
+	}
+	logger.Info("addresses imported", logger.StringAttr("id", id), logger.StringAttr("user", userID),
+		logger.IntAttr("create", len(pending.plan.Create)), logger.IntAttr("update", len(pending.plan.Update)),
+		logger.IntAttr("delete", len(pending.plan.Delete)),
+	)
+	return pending.plan, nil
+}
+
+func (s *TransferService) Cancel(id string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if _, ok := s.pending[id]; !ok {
+		return models.ErrImportNotFound
+	}
+	delete(s.pending, id)
+	return nil
+}
+
+// Actions возвращает кнопки подтверждения импорта
+func (s *TransferService) Actions(id string) []*models.Action {
+	if s.conf.URL == "" {
+		return nil
+	}
+
+	action := func(action, name, style string) *models.Action {
+		return &models.Action{
+			ID:      strings.ReplaceAll(action, "_", ""),
+			Name:    name,
+			Style:   style,
+			URL:     s.conf.URL,
+			Context: map[string]any{"action": action, "id": id, "sign": s.sign(action, id)},
+		}
+	}
+	return []*models.Action{
+		action(models.ActionImportApply, "Применить", "primary"),
+		action(models.ActionImportCancel, "Отменить", "default"),
+	}
+}
+
+// Handle обрабатывает нажатие кнопки подтверждения и возвращает текст, который увидит только нажавший пользователь
+func (s *TransferService) Handle(ctx context.Context, req *models.ActionRequest) (string, error) {
+	action, _ := req.Context["action"].(string)
+	id, _ := req.Context["id"].(string)
+	sign, _ := req.Context["sign"].(string)
+	if id == "" || !hmac.Equal([]byte(sign), []byte(s.sign(action, id))) {
+		return "", models.ErrInvalidAction
+	}
+	if !s.roles.Allowed(ctx, req.UserID, req.ChannelID, models.RoleOperator) {
+		logger.Warn("import action denied", logger.StringAttr("action", action), logger.StringAttr("user", req.UserName))
+		return "Недостаточно прав. Импорт доступен операторам и администраторам.", nil
+	}
+
+	var note string
+	switch action {
+	case models.ActionImportApply:
+		plan, err := s.Confirm(ctx, id, req.UserID, req.ChannelID)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrImportNotFound):
+				return "Импорт уже применен, отменен или устарел.", nil
+			case errors.Is(err, models.ErrPermissionDenied):
+				return "Недостаточно прав. Импорт с удалением адресов доступен только администраторам.", nil
+			case errors.Is(err, models.ErrExist):
+				return "Список адресов изменился после проверки файла. Загрузите файл снова.", nil
+			}
+			return "", err
+		}
+		note = fmt.Sprintf("@%s применил импорт: %s", req.UserName, ImportSummary(plan))
+
+	case models.ActionImportCancel:
+		if err := s.Cancel(id); err != nil {
+			return "Импорт уже применен, отменен или устарел.", nil
+		}
+		note = fmt.Sprintf("@%s отменил импорт", req.UserName)
+
+	default:
+		return "", models.ErrInvalidAction
+	}
+
+	post, err := s.post.Get(req.PostID)
+	if err != nil {
+		return "", err
+	}
+	message := fmt.Sprintf("%s\n_%s (%s)_", post.Message, note, time.Now().Format("02.01.2006 15:04"))
+	if err := s.post.Patch(req.PostID, message); err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+// cleanup удаляет устаревшие планы, вызывается под блокировкой
+func (s *TransferService) cleanup() {
+	now := time.Now()
+	for id, p := range s.pending {
+		if now.After(p.expires) {
+			delete(s.pending, id)
+		}
+	}
+}
+
+func (s *TransferService) sign(action, id string) string {
+	mac := hmac.New(sha256.New, []byte(s.conf.Secret))
+	mac.Write([]byte(action + ":" + id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ImportSummary количество изменений импорта одной строкой
+func ImportSummary(plan *models.ImportPlan) string {
+	return fmt.Sprintf("добавлено %d, изменено %d, удалено %d, без изменений %d",
+		len(plan.Create), len(plan.Update), len(plan.Delete), plan.Unchanged)
+}
+
+// detectFormat определяет формат файла по содержимому: JSON начинается со списка, YAML - с элемента списка
+func detectFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("[")) || bytes.HasPrefix(trimmed, []byte("{")):
+		return models.FormatJSON
+	case bytes.HasPrefix(trimmed, []byte("-")):
+		return models.FormatYAML
+	}
+	return models.FormatCSV
+}
+
+// decodeRecords разбирает файл в список записей колонка - значение
+func decodeRecords(data []byte, format string) ([]map[string]string, error) {
+	switch format {
+	case models.FormatCSV:
+		return decodeCSV(data)
+
+	case models.FormatJSON:
+		items := []map[string]any{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&items); err != nil {
+			return nil, fmt.Errorf("не удалось разобрать JSON, ожидается список адресов: %s", err.Error())
+		}
+		return recordValues(items), nil
+
+	case models.FormatYAML:
+		items := []map[string]any{}
+		if err := yaml.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("не удалось разобрать YAML, ожидается список адресов: %s", err.Error())
+		}
+		return recordValues(items), nil
+	}
+	return nil, models.ErrUnknownFormat
+}
+
+// decodeCSV разбирает CSV с заголовком. Разделитель - запятая или точка с запятой (так сохраняет Excel)
+func decodeCSV(data []byte) ([]map[string]string, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	header, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Contains(header, []byte(";")) && !bytes.Contains(header, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.TrimLeadingSpace = true
+
+	lines, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("не удалось разобрать CSV: %s", err.Error())
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("файл пустой")
+	}
+
+	columns := make([]string, 0, len(lines[0]))
+	for _, c := range lines[0] {
+		columns = append(columns, strings.ToLower(strings.TrimSpace(c)))
+	}
+	if !slices.Contains(columns, "ip") {
+		return nil, errors.New("в первой строке должен быть заголовок с колонкой ip")
+	}
+
+	rows := make([]map[string]string, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		values := make(map[string]string, len(columns))
+		for i, c := range columns {
+			values[c] = line[i]
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+func recordValues(items []map[string]any) []map[string]string {
+	rows := make([]map[string]string, 0, len(items))
+	for _, item := range items {
+		values := make(map[string]string, len(item))
+		for key, value := range item {
+			values[strings.ToLower(key)] = recordValue(value)
+		}
+		rows = append(rows, values)
+	}
+	return rows
+}
+
+// recordValue приводит значение из JSON или YAML к строке. Список групп может быть массивом
+func recordValue(value any) string {
+	if list, ok := value.([]any); ok {
+		parts := make([]string, 0, len(list))
+		for _, v := range list {
+			parts = append(parts, formValue(v))
+		}
+		return strings.Join(parts, ",")
+	}
+	return formValue(value)
+}
+
+// parseAddressRecord разбирает запись файла так же, как форму адреса.
+// Колонки, которых нет в файле, и пустые флаги не изменяют адрес
+func parseAddressRecord(values map[string]string) (*models.AddressDTO, map[string]string) {
+	dto, fields := parseAddressForm(values)
+
+	for name := range values {
+		if !slices.Contains(models.AddressColumns, name) {
+			fields[name] = "Неизвестная колонка."
+		}
+	}
+	absent := func(name string) bool {
+		_, ok := values[name]
+		return !ok
+	}
+	if absent("name") {
+		dto.Name = nil
+	}
+	if absent("groups") {
+		dto.Groups = nil
+	}
+	if absent("period") {
+		dto.Windows = nil
+	} else if dto.Windows == nil {
+		dto.Windows = []*models.Window{}
+	}
+	if absent("timezone") {
+		dto.TimeZone = nil
+	}
+	if absent("schedule") {
+		dto.Cron = nil
+	}
+
+	for _, name := range []string{"holidays", "enabled"} {
+		value := strings.TrimSpace(values[name])
+		if value != "" {
+			if _, err := strconv.ParseBool(value); err != nil {
+				fields[name] = "Ожидается true или false."
+			}
+			continue
+		}
+		if name == "holidays" {
+			dto.SkipHolidays = nil
+		} else {
+			dto.Enabled = nil
+		}
+	}
+	return dto, fields
+}
+
+// formatFieldErrors объединяет ошибки полей в одну строку в порядке колонок
+func formatFieldErrors(fields map[string]string) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	// неизвестные колонки выводятся последними
+	rank := func(name string) int {
+		if i := slices.Index(models.AddressColumns, name); i >= 0 {
+			return i
+		}
+		return len(models.AddressColumns)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if rank(names[i]) == rank(names[j]) {
+			return names[i] < names[j]
+		}
+		return rank(names[i]) < rank(names[j])
+	})
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, name+": "+strings.TrimSuffix(fields[name], "."))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// diffAddress сравнивает адрес до и после импорта по колонкам файла
+func diffAddress(old, new *models.Address) []*models.FieldChange {
+	before, after := models.NewAddressRecord(old).Values(), models.NewAddressRecord(new).Values()
+	changes := []*models.FieldChange{}
+	for i, name := range models.AddressColumns {
+		if before[i] != after[i] {
+			changes = append(changes, &models.FieldChange{Name: name, Old: before[i], New: after[i]})
+		}
+	}
+	return changes
+}
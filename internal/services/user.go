@@ -0,0 +1,105 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+type UserService struct {
+	client *model.Client4
+	admins map[string]struct{}
+
+	mx    sync.RWMutex
+	cache map[string]*models.User
+}
+
+func NewUserService(client *model.Client4, admins []string) *UserService {
+	service := &UserService{
+		client: client,
+		admins: make(map[string]struct{}, len(admins)),
+		cache:  make(map[string]*models.User),
+	}
+	for _, admin := range admins {
+		service.admins[strings.TrimPrefix(strings.TrimSpace(admin), "@")] = struct{}{}
+	}
+	return service
+}
+
+type User interface {
+	Get(userID string) (*models.User, error)
+	GetByUsername(username string) (*models.User, error)
+	IsAdmin(userID string) bool
+	IsChannelAdmin(channelID, userID string) bool
+}
+
+func (s *UserService) Get(userID string) (*models.User, error) {
+	s.mx.RLock()
+	user, ok := s.cache[userID]
+	s.mx.RUnlock()
+	if ok {
+		return user, nil
+	}
+
+	data, _, err := s.client.GetUser(userID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user. error: %w", err)
+	}
+	user = &models.User{ID: data.Id, Username: data.Username, Roles: strings.Fields(data.Roles)}
+
+	s.mx.Lock()
+	s.cache[userID] = user
+	s.mx.Unlock()
+	return user, nil
+}
+
+// GetByUsername ищет пользователя по имени, имя можно указывать с @
+func (s *UserService) GetByUsername(username string) (*models.User, error) {
+	data, resp, err := s.client.GetUserByUsername(strings.TrimPrefix(username, "@"), "")
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil, models.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to get user by username. error: %w", err)
+	}
+	user := &models.User{ID: data.Id, Username: data.Username, Roles: strings.Fields(data.Roles)}
+
+	s.mx.Lock()
+	s.cache[user.ID] = user
+	s.mx.Unlock()
+	return user, nil
+}
+
+// IsAdmin проверяет указан ли пользователь (id или имя) в списке администраторов бота
+func (s *UserService) IsAdmin(userID string) bool {
+	if userID == "" {
+		return false
+	}
+	if _, ok := s.admins[userID]; ok {
+		return true
+	}
+
+	user, err := s.Get(userID)
+	if err != nil {
+		return false
+	}
+	_, ok := s.admins[user.Username]
+	return ok
+}
+
+// IsChannelAdmin проверяет является ли пользователь администратором канала
+func (s *UserService) IsChannelAdmin(channelID, userID string) bool {
+	if channelID == "" || userID == "" {
+		return false
+	}
+
+	member, _, err := s.client.GetChannelMember(channelID, userID, "")
+	if err != nil {
+		return false
+	}
+	return member.SchemeAdmin || strings.Contains(member.Roles, model.ChannelAdminRoleId)
+}
@@ -0,0 +1,152 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Alexander272/Pinger/internal/models"
+)
+
+var roleAccess = map[string]string{
+	models.RoleViewer:   "всем",
+	models.RoleOperator: "операторам и администраторам",
+	models.RoleAdmin:    "только администраторам",
+}
+
+var flagValues = map[FlagType]string{
+	FlagString:  " <значение>",
+	FlagInt:     " <число>",
+	FlagBool:    " <true|false>",
+	FlagMillis:  " <мс>",
+	FlagSeconds: " <сек>",
+}
+
+// help выводит список команд или подробную справку по команде, если она указана
+func (r *Router) help(post *models.Post) error {
+	name := post.Input.Arg("command")
+	if name == "" {
+		r.services.Post.Reply(post, r.overview())
+		return nil
+	}
+
+	words := strings.Fields(strings.ToLower(name))
+	cmd := r.find(words[0])
+	if cmd == nil {
+		r.services.Post.Reply(post, fmt.Sprintf("#### Ошибка.\nНеизвестная команда «%s».%s", words[0], hint(words[0], r.names())))
+		return nil
+	}
+	for _, word := range words[1:] {
+		action := cmd.action(word)
+		if action == nil {
+			break
+		}
+		cmd = action
+	}
+
+	r.services.Post.Reply(post, cmd.help())
+	return nil
+}
+
+func (r *Router) overview() string {
+	lines := []string{
+		"### Доступные команды:",
+		"Команды можно писать в канал с ботом, в личные сообщения боту или передавать slash-команде: `/pinger <команда>`, тогда ответ увидите только вы.",
+		"| Команда | Описание |",
+		"|:--|:--|",
+	}
+	for _, cmd := range r.commands {
+		lines = append(lines, fmt.Sprintf("|%s|%s|", quoteNames(cmd.names()), cmd.Usage))
+	}
+	lines = append(lines, "Подробнее о команде: `help <команда>`, например `help add`.")
+	return strings.Join(lines, "\n")
+}
+
+// help формирует справку по команде из ее описания
+func (c *Command) help() string {
+	lines := []string{
+		"##### " + capitalize(c.Usage),
+		"`" + c.syntax() + "`",
+	}
+	if len(c.Aliases) > 0 {
+		lines = append(lines, "Другие названия: "+quoteNames(c.Aliases))
+	}
+	lines = append(lines, c.Description...)
+	lines = append(lines, c.flagsHelp()...)
+
+	if len(c.Actions) > 0 {
+		lines = append(lines, "Действия:")
+		for _, action := range c.Actions {
+			lines = append(lines, fmt.Sprintf("`%s` - %s", action.syntax(), action.Usage))
+			lines = append(lines, action.Description...)
+			lines = append(lines, action.flagsHelp()...)
+		}
+	}
+
+	access := roleAccess[c.role(&models.CommandInput{})]
+	if len(c.Actions) > 0 && c.Role == "" {
+		access += ", действия - " + roleAccess[c.Actions[0].role(&models.CommandInput{})]
+	}
+	lines = append(lines, "Доступно: "+access)
+
+	if len(c.Examples) > 0 {
+		lines = append(lines, "Пример:", "```")
+		lines = append(lines, c.Examples...)
+		lines = append(lines, "```")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// syntax формирует строку вызова команды, например "add [ip] [флаги]"
+func (c *Command) syntax() string {
+	parts := []string{c.FullName()}
+	for _, arg := range c.Args {
+		name := arg.Name
+		if arg.Rest {
+			name += "..."
+		}
+		if arg.Required {
+			parts = append(parts, "<"+name+">")
+		} else {
+			parts = append(parts, "["+name+"]")
+		}
+	}
+	if len(c.Flags) > 0 {
+		parts = append(parts, "[флаги]")
+	}
+	return strings.Join(parts, " ")
+}
+
+func (c *Command) flagsHelp() []string {
+	if len(c.Flags) == 0 {
+		return nil
+	}
+
+	lines := []string{"```"}
+	for _, flag := range c.Flags {
+		name := "--" + flag.Name
+		if flag.Short != "" {
+			name = "-" + flag.Short + ", " + name
+		}
+		line := fmt.Sprintf("%s%s - %s", name, flagValues[flag.Type], flag.Usage)
+		if flag.Role != "" {
+			line += fmt.Sprintf(" (доступно %s)", roleAccess[flag.Role])
+		}
+		lines = append(lines, line)
+	}
+	return append(lines, "```")
+}
+
+func capitalize(value string) string {
+	r, size := utf8.DecodeRuneInString(value)
+	return string(unicode.ToUpper(r)) + value[size:]
+}
+
+func quoteNames(names []string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, "`"+name+"`")
+	}
+	return strings.Join(quoted, ", ")
+}
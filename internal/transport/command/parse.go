@@ -0,0 +1,205 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/google/shlex"
+)
+
+// parseError ошибка в тексте команды, ее текст показывается пользователю
+type parseError struct {
+	message string
+	// команда, справку по которой стоит посмотреть
+	command *Command
+}
+
+func (e *parseError) Error() string {
+	return e.message
+}
+
+func newParseError(command *Command, format string, args ...any) error {
+	return &parseError{message: fmt.Sprintf(format, args...), command: command}
+}
+
+// parse находит команду по первым словам сообщения и разбирает ее аргументы и флаги.
+// Первая строка сообщения - команда, остальные строки передаются обработчику как есть
+func (r *Router) parse(message string) (*Command, *models.CommandInput, error) {
+	line, body, _ := strings.Cut(message, "\n")
+	tokens, err := shlex.Split(line)
+	if err != nil {
+		return nil, nil, newParseError(nil, "Не удалось разобрать команду, проверьте парные кавычки.")
+	}
+	if len(tokens) == 0 {
+		tokens = []string{"help"}
+	}
+
+	name := strings.ToLower(tokens[0])
+	cmd := r.find(name)
+	if cmd == nil {
+		return nil, nil, newParseError(nil, "Неизвестная команда «%s».%s", tokens[0], hint(name, r.names()))
+	}
+	tokens = tokens[1:]
+
+	for len(cmd.Actions) > 0 && len(tokens) > 0 && !isFlag(tokens[0]) {
+		action := cmd.action(strings.ToLower(tokens[0]))
+		if action == nil {
+			if len(cmd.Args) > 0 {
+				break
+			}
+			names := []string{}
+			for _, a := range cmd.Actions {
+				names = append(names, a.names()...)
+			}
+			return nil, nil, newParseError(cmd, "Неизвестное действие «%s» команды `%s`.%s", tokens[0], cmd.FullName(), hint(strings.ToLower(tokens[0]), names))
+		}
+		cmd = action
+		tokens = tokens[1:]
+	}
+
+	input, err := parseArgs(cmd, tokens)
+	if err != nil {
+		return nil, nil, err
+	}
+	input.Body = body
+	return cmd, input, nil
+}
+
+func parseArgs(cmd *Command, tokens []string) (*models.CommandInput, error) {
+	input := &models.CommandInput{
+		Name:  cmd.FullName(),
+		Args:  make(map[string]string, len(cmd.Args)),
+		Flags: make(map[string]any, len(cmd.Flags)),
+	}
+	if cmd.parent != nil {
+		input.Action = cmd.Name
+	}
+
+	positional := []string{}
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if !isFlag(token) {
+			positional = append(positional, token)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(token, "=")
+		flag := cmd.flag(name)
+		if flag == nil {
+			return nil, newParseError(cmd, "Неизвестный флаг «%s» команды `%s`.%s", name, cmd.FullName(), hint(name, cmd.flagNames()))
+		}
+		if flag.Type == FlagSwitch {
+			if hasValue {
+				return nil, newParseError(cmd, "Флаг «%s» указывается без значения.", name)
+			}
+			input.Flags[flag.Name] = true
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(tokens) {
+				return nil, newParseError(cmd, "Не задано значение флага «%s».", name)
+			}
+			i++
+			value = tokens[i]
+		}
+
+		parsed, err := flag.parse(value)
+		if err != nil {
+			return nil, newParseError(cmd, "Некорректное значение флага «%s»: %s.", name, err.Error())
+		}
+		input.Flags[flag.Name] = parsed
+	}
+
+	for i, arg := range cmd.Args {
+		if i >= len(positional) {
+			if arg.Required {
+				return nil, newParseError(cmd, "Не указан аргумент <%s>.", arg.Name)
+			}
+			continue
+		}
+
+		value := positional[i]
+		if arg.Rest {
+			value = strings.Join(positional[i:], " ")
+		}
+		if arg.Validate != nil {
+			if err := arg.Validate(value); err != nil {
+				return nil, newParseError(cmd, "Некорректное значение аргумента <%s>: %s.", arg.Name, err.Error())
+			}
+		}
+		input.Args[arg.Name] = value
+	}
+	if len(positional) > len(cmd.Args) && (len(cmd.Args) == 0 || !cmd.Args[len(cmd.Args)-1].Rest) {
+		return nil, newParseError(cmd, "Лишний аргумент «%s».", positional[len(cmd.Args)])
+	}
+
+	return input, nil
+}
+
+func (f *Flag) parse(value string) (any, error) {
+	var parsed any = value
+
+	switch f.Type {
+	case FlagInt:
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errors.New("ожидается целое число")
+		}
+		parsed = number
+	case FlagBool:
+		switch strings.ToLower(value) {
+		case "true", "1", "yes", "да":
+			parsed = true
+		case "false", "0", "no", "нет":
+			parsed = false
+		default:
+			return nil, errors.New("ожидается true или false")
+		}
+	case FlagMillis, FlagSeconds:
+		unit := time.Second
+		if f.Type == FlagMillis {
+			unit = time.Millisecond
+		}
+		// NaN, бесконечность и слишком большие значения при переводе в time.Duration становятся отрицательными
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) || number < 0 {
+			return nil, errors.New("ожидается неотрицательное число")
+		}
+		if number > float64(math.MaxInt64/int64(unit)) {
+			return nil, errors.New("слишком большое значение")
+		}
+		parsed = time.Duration(number * float64(unit))
+	}
+
+	if f.Validate != nil {
+		if err := f.Validate(value); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+func (c *Command) flagNames() []string {
+	names := []string{}
+	for _, flag := range c.Flags {
+		names = append(names, "--"+flag.Name)
+		if flag.Short != "" {
+			names = append(names, "-"+flag.Short)
+		}
+	}
+	return names
+}
+
+// isFlag отличает флаги от аргументов. Отрицательные числа считаются аргументами
+func isFlag(token string) bool {
+	if len(token) < 2 || token[0] != '-' {
+		return false
+	}
+	_, err := strconv.ParseFloat(token, 64)
+	return err != nil
+}
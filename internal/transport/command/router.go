@@ -0,0 +1,527 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/Alexander272/Pinger/pkg/logger"
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+// Router разбирает команду из текста сообщения, проверяет права пользователя и вызывает обработчик.
+// Используется и для сообщений в каналах, и для slash-команд
+type Router struct {
+	commands []*Command
+	services *services.Services
+}
+
+func NewRouter(services *services.Services) *Router {
+	r := &Router{services: services}
+	r.commands = r.registry()
+
+	for _, cmd := range r.commands {
+		for _, action := range cmd.Actions {
+			action.parent = cmd
+		}
+	}
+	return r
+}
+
+// registry описывает команды бота. Просмотр доступен всем, изменение адресов - операторам,
+// удаление и настройки бота - администраторам
+func (r *Router) registry() []*Command {
+	services := r.services
+	ip := &Arg{Name: "ip", Usage: "IP адрес", Required: true, Validate: validIP}
+	// адреса для изменения: один адрес, подсеть, диапазон или список через запятую
+	addresses := &Arg{Name: "ip", Usage: "IP адрес, подсеть, диапазон или список адресов", Required: true, Validate: validAddresses}
+	rangeHelp := fmt.Sprintf("Вместо адреса можно указать подсеть (10.0.0.0/24), диапазон (10.0.0.1-10.0.0.20) или список через запятую, "+
+		"но не больше %d адресов. Изменения применяются ко всем адресам сразу, в ответе - результат по каждому адресу.", models.MaxBulkAddresses)
+
+	return []*Command{
+		{
+			Name:    "list",
+			Aliases: []string{"список"},
+			Usage:   "Список IP-адресов",
+			Flags: []*Flag{
+				{Name: "all", Short: "a", Type: FlagSwitch, Usage: "вывести полную информацию о IP-адресах"},
+			},
+			Handler: services.Message.List,
+		},
+		{
+			Name:        "add",
+			Aliases:     []string{"добавить"},
+			Usage:       "Добавление нового IP-адреса в список",
+			Description: []string{"Без параметров открывает форму, в которой можно заполнить все параметры.", rangeHelp},
+			Args:        []*Arg{{Name: "ip", Usage: addresses.Usage, Validate: validAddresses}},
+			Flags:       addressFlags(),
+			Examples: []string{
+				"добавить 8.8.8.8 -n \"Google\"",
+				"add 8.8.8.8 -r 100 -N 3 -p \"10:00-20:25\"",
+				"add 10.0.0.1 -e 10 -g \"офис,роутеры\"",
+				"add 10.0.0.3 -p \"пн-пт 09:00-13:00,14:00-18:00; сб 10:00-14:00\" -z Asia/Yekaterinburg -H true",
+				"add 10.0.0.4 -p \"22:00-06:00\"",
+				"add 10.0.0.2 -s \"*/5 * * * *\"",
+				"add 10.1.0.0/24 -g офис",
+			},
+			Role:    models.RoleOperator,
+			Handler: services.Message.Create,
+		},
+		{
+			Name:        "update",
+			Aliases:     []string{"обновить", "изменить"},
+			Usage:       "Изменение параметров IP-адреса",
+			Description: []string{"Флаги аналогичны добавлению. Если указан только адрес, открывается форма с текущими значениями.", rangeHelp},
+			Args:        []*Arg{addresses},
+			Flags:       addressFlags(),
+			Examples: []string{
+				"изменить 8.8.8.8 -n \"Google\"",
+				"update 8.8.8.8 -r 100 -N 3 -p \"10:00-20:25\"",
+				"update 10.0.0.1-10.0.0.20 -H true",
+			},
+			Role:    models.RoleOperator,
+			Handler: services.Message.Update,
+		},
+		{
+			Name:        "disable",
+			Aliases:     []string{"dis", "отключить"},
+			Usage:       "Отключение IP-адреса",
+			Args:        []*Arg{addresses},
+			Description: []string{rangeHelp},
+			Examples:    []string{"отключить 8.8.8.8", "disable 8.8.8.8", "dis 10.0.0.1,10.0.0.5"},
+			Role:        models.RoleOperator,
+			Handler:     func(p *models.Post) error { return services.Message.ToggleActive(p, false) },
+		},
+		{
+			Name:        "enable",
+			Aliases:     []string{"en", "включить"},
+			Usage:       "Включение IP-адреса",
+			Args:        []*Arg{addresses},
+			Description: []string{rangeHelp},
+			Examples:    []string{"включить 8.8.8.8", "enable 8.8.8.8", "en 10.1.0.0/28"},
+			Role:        models.RoleOperator,
+			Handler:     func(p *models.Post) error { return services.Message.ToggleActive(p, true) },
+		},
+		{
+			Name:        "delete",
+			Aliases:     []string{"del", "удалить"},
+			Usage:       "Удаление IP-адреса из списка",
+			Args:        []*Arg{addresses},
+			Description: []string{rangeHelp, "Удаленный адрес можно восстановить командой `restore` или отменить удаление командой `undo`."},
+			Examples:    []string{"удалить 8.8.8.8", "delete 8.8.8.8", "del 10.0.0.1-10.0.0.20"},
+			Role:        models.RoleAdmin,
+			Handler:     services.Message.Delete,
+		},
+		{
+			Name:        "restore",
+			Aliases:     []string{"восстановить"},
+			Usage:       "Восстановление удаленного IP-адреса",
+			Description: []string{"Адрес восстанавливается с прежними параметрами. Без адреса выводится список удаленных адресов, которые еще можно восстановить."},
+			Args:        []*Arg{{Name: "ip", Usage: "IP адрес", Validate: validIP}},
+			Examples:    []string{"restore", "восстановить 8.8.8.8"},
+			Role:        models.RoleOperator,
+			Handler:     services.Message.Restore,
+		},
+		{
+			Name:    "undo",
+			Aliases: []string{"отменить"},
+			Usage:   "Отмена вашей последней команды, изменившей адреса",
+			Description: []string{
+				"Отменяет add, update, enable, disable, delete или restore: добавленные адреса удаляются, удаленные восстанавливаются, измененным возвращаются прежние параметры. " +
+					"Адреса, которые изменились после команды, не трогаются. Повторный вызов отменяет предыдущую команду.",
+			},
+			Examples: []string{"undo"},
+			Role:     models.RoleOperator,
+			Handler:  services.Message.Undo,
+		},
+		{
+			Name:     "export",
+			Aliases:  []string{"экспорт"},
+			Usage:    "Выгрузка списка IP-адресов в файл",
+			Flags:    []*Flag{formatFlag("(по умолчанию csv)")},
+			Examples: []string{"export", "экспорт -f yaml"},
+			Handler:  services.Message.Export,
+		},
+		{
+			Name:    "import",
+			Aliases: []string{"импорт"},
+			Usage:   "Загрузка списка IP-адресов из файла",
+			Description: []string{
+				"Приложите файл к сообщению или добавьте его содержимое в строках после команды. Формат файла такой же, как при выгрузке: " +
+					"колонки совпадают с флагами `add`, время пинга, интервал и таймаут в миллисекундах, интервал проверок в секундах.",
+				"Сначала показываются изменения, импорт выполняется после подтверждения. Колонки и адреса, которых нет в файле, не изменяются.",
+			},
+			Flags: []*Flag{
+				formatFlag("(по умолчанию определяется по содержимому)"),
+				{Name: "prune", Short: "p", Type: FlagSwitch, Usage: "удалить адреса, которых нет в файле", Role: models.RoleAdmin},
+			},
+			Actions: []*Command{
+				{
+					Name: "apply", Aliases: []string{"применить"}, Usage: "применить проверенный импорт",
+					Args:    []*Arg{{Name: "id", Required: true}},
+					Handler: services.Message.Import,
+				},
+				{
+					Name: "cancel", Aliases: []string{"отменить"}, Usage: "отменить импорт",
+					Args:    []*Arg{{Name: "id", Required: true}},
+					Handler: services.Message.Import,
+				},
+			},
+			Examples: []string{
+				"import -f csv",
+				"ip,name,groups,rtt",
+				"10.0.0.1,Роутер,офис,100",
+				"10.0.0.2,Принтер,офис,",
+			},
+			Role:    models.RoleOperator,
+			Handler: services.Message.Import,
+		},
+		{
+			Name:    "audit",
+			Aliases: []string{"журнал"},
+			Usage:   "Журнал изменений IP-адресов",
+			Description: []string{
+				"Показывает, кто и какой командой добавил, изменил, включил, отключил, удалил или восстановил адрес. По умолчанию выводятся изменения за последние 7 дней.",
+			},
+			Args: []*Arg{{Name: "ip", Usage: "IP адрес", Validate: validIP}},
+			Flags: []*Flag{
+				{Name: "period", Short: "p", Usage: "диапазон дат (формат: <день>[.<месяц>[.<год>]]-<день>[.<месяц>[.<год>]])"},
+			},
+			Examples: []string{"audit 8.8.8.8", "журнал -p \"01.03-15.03\""},
+			Handler:  services.Message.Audit,
+		},
+		{
+			Name:        "stats",
+			Aliases:     []string{"statistics", "стат", "статистика"},
+			Usage:       "Статистика недоступности",
+			Description: []string{"По умолчанию выводится статистика с 1 по последнее число текущего месяца. Если указан IP-адрес, выводятся все простои адреса."},
+			Args:        []*Arg{{Name: "ip", Usage: "IP адрес", Validate: validIP}},
+			Flags: []*Flag{
+				{Name: "period", Short: "p", Usage: "диапазон дат (формат: <день>[.<месяц>[.<год>]]-<день>[.<месяц>[.<год>]])"},
+			},
+			Examples: []string{"стат -p \"01.11-1.12\"", "stats 8.8.8.8"},
+			Handler:  services.Message.Statistics,
+		},
+		{
+			Name:        "graph",
+			Aliases:     []string{"график"},
+			Usage:       "График задержки и потерь пакетов",
+			Description: []string{"Периоды недоступности выделены цветом. По умолчанию строится за последние сутки."},
+			Args:        []*Arg{ip},
+			Flags: []*Flag{
+				{Name: "period", Short: "p", Usage: "период: длительность (например 6h, 7d) или даты в формате ДД.ММ.ГГГГ-ДД.ММ.ГГГГ"},
+			},
+			Examples: []string{"график 8.8.8.8 -p 7d", "graph 8.8.8.8 -p \"01.02.2025-10.02.2025\""},
+			Handler:  services.Message.Graph,
+		},
+		{
+			Name:    "unavailable",
+			Aliases: []string{"недоступные"},
+			Usage:   "Список недоступных в данный момент IP-адресов",
+			Handler: services.Message.Unavailable,
+		},
+		{
+			Name:        "scheduler",
+			Aliases:     []string{"планировщик"},
+			Usage:       "Настройки планировщика",
+			Description: []string{"Без флагов выводит текущие настройки, с флагами настройки изменяются и планировщик перезапускается."},
+			Flags: []*Flag{
+				{Name: "interval", Short: "i", Type: FlagSeconds, Usage: "интервал проверок по умолчанию", Role: models.RoleAdmin},
+				{Name: "count", Short: "c", Type: FlagInt, Usage: "количество одновременных пингов", Role: models.RoleAdmin},
+				{Name: "timeout", Short: "t", Type: FlagSeconds, Usage: "максимальное время проверки", Role: models.RoleAdmin},
+				{Name: "delay", Short: "d", Type: FlagSeconds, Usage: "задержка перед запуском проверок", Role: models.RoleAdmin},
+				{
+					Name: "quiet", Short: "q", Usage: "период тишины, когда проверки не выполняются (формат: <часы>:<минуты>-<часы>:<минуты>, пустая строка - отключить)",
+					Role: models.RoleAdmin, Validate: validQuietPeriod,
+				},
+			},
+			Examples: []string{"планировщик -c 50", "scheduler -i 60 -q \"23:00-06:00\""},
+			Handler:  services.Message.Scheduler,
+		},
+		{
+			Name:    "holidays",
+			Aliases: []string{"праздники"},
+			Usage:   "Праздничные дни",
+			Description: []string{
+				"Без действия выводит список праздничных дней текущего года.",
+			},
+			Actions: []*Command{
+				{
+					Name: "add", Aliases: []string{"добавить"}, Usage: "добавить праздничный день (дата в формате ДД.ММ.ГГГГ)",
+					Args:    []*Arg{{Name: "дата", Required: true}, {Name: "название", Rest: true}},
+					Role:    models.RoleAdmin,
+					Handler: services.Message.Holidays,
+				},
+				{
+					Name: "del", Aliases: []string{"удалить"}, Usage: "удалить праздничный день",
+					Args:    []*Arg{{Name: "дата", Required: true}},
+					Role:    models.RoleAdmin,
+					Handler: services.Message.Holidays,
+				},
+				{
+					Name: "import", Aliases: []string{"импорт"},
+					Usage:   "импорт из приложенного файла (.ics или список строк `<дата> [название]`) или из строк после команды",
+					Role:    models.RoleAdmin,
+					Handler: services.Message.Holidays,
+				},
+			},
+			Examples: []string{
+				"праздники add 01.05.2025 \"Праздник Весны и Труда\"",
+				"holidays import",
+				"08.03.2025 Международный женский день",
+				"09.05.2025 День Победы",
+			},
+			Handler: services.Message.Holidays,
+		},
+		{
+			Name:        "tokens",
+			Aliases:     []string{"token", "токены", "токен"},
+			Usage:       "Токены API",
+			Description: []string{"Без действия выводит список действующих токенов. Токен передается в заголовке `Authorization: Bearer <токен>`."},
+			Actions: []*Command{
+				{
+					Name: "issue", Aliases: []string{"add", "выдать"},
+					Usage:   "выдать токен (по умолчанию только чтение), токен придет в личные сообщения",
+					Args:    []*Arg{{Name: "название", Required: true}, {Name: "read|admin"}},
+					Handler: services.Message.Tokens,
+				},
+				{
+					Name: "revoke", Aliases: []string{"del", "отозвать"}, Usage: "отозвать токен",
+					Args:    []*Arg{{Name: "название", Required: true}},
+					Handler: services.Message.Tokens,
+				},
+			},
+			Role:    models.RoleAdmin,
+			Handler: services.Message.Tokens,
+		},
+		{
+			Name:        "status",
+			Aliases:     []string{"статус"},
+			Usage:       "Страница статуса",
+			Description: []string{"Без действия выводит список компонентов публичной страницы статуса."},
+			Actions: []*Command{
+				{
+					Name: "add", Aliases: []string{"добавить"}, Usage: "добавить компонент, нужно указать адрес или группу",
+					Args: []*Arg{{Name: "название", Required: true}},
+					Flags: []*Flag{
+						{Name: "ip", Short: "i", Usage: "IP адрес компонента", Validate: validIP},
+						{Name: "group", Short: "g", Usage: "группа адресов компонента"},
+					},
+					Role:    models.RoleAdmin,
+					Handler: services.Message.StatusPage,
+				},
+				{
+					Name: "del", Aliases: []string{"удалить"}, Usage: "удалить компонент",
+					Args:    []*Arg{{Name: "название", Required: true}},
+					Role:    models.RoleAdmin,
+					Handler: services.Message.StatusPage,
+				},
+			},
+			Handler: services.Message.StatusPage,
+		},
+		{
+			Name:        "incident",
+			Aliases:     []string{"incidents", "инцидент", "инциденты"},
+			Usage:       "Инциденты страницы статуса",
+			Description: []string{"Без действия выводит список открытых инцидентов."},
+			Actions: []*Command{
+				{
+					Name: "open", Aliases: []string{"открыть"}, Usage: "открыть инцидент",
+					Args: []*Arg{{Name: "заголовок", Required: true}},
+					Flags: []*Flag{
+						{Name: "components", Short: "c", Usage: "компоненты через запятую"},
+						{Name: "message", Short: "m", Usage: "текст первого обновления"},
+						{Name: "status", Short: "s", Usage: "статус (investigating, identified, monitoring)"},
+					},
+					Role:    models.RoleOperator,
+					Handler: services.Message.Incidents,
+				},
+				{
+					Name: "update", Aliases: []string{"обновить"},
+					Usage:   "добавить обновление (статусы: investigating, identified, monitoring, resolved)",
+					Args:    []*Arg{{Name: "номер", Required: true}, {Name: "статус", Required: true}, {Name: "текст", Required: true, Rest: true}},
+					Role:    models.RoleOperator,
+					Handler: services.Message.Incidents,
+				},
+				{
+					Name: "resolve", Aliases: []string{"решить"}, Usage: "закрыть инцидент",
+					Args:    []*Arg{{Name: "номер", Required: true}, {Name: "текст", Rest: true}},
+					Role:    models.RoleOperator,
+					Handler: services.Message.Incidents,
+				},
+			},
+			Examples: []string{
+				"инцидент open \"Не работает почта\" -c \"Почта\" -m \"Выясняем причину\"",
+				"incident update 3 identified \"Отказал сервер, идет замена\"",
+			},
+			Handler: services.Message.Incidents,
+		},
+		{
+			Name:    "roles",
+			Aliases: []string{"роли"},
+			Usage:   "Роли пользователей",
+			Description: []string{
+				"Просматривать данные могут все, добавлять, изменять, включать и отключать адреса - операторы (`operator`),",
+				"удалять адреса и менять настройки бота - администраторы (`admin`).",
+				"Администраторы из конфига и системные администраторы Mattermost всегда администраторы бота, администраторы канала - операторы.",
+				"Без действия выводит список выданных ролей.",
+			},
+			Actions: []*Command{
+				{
+					Name: "grant", Aliases: []string{"выдать"}, Usage: "выдать роль",
+					Args:    []*Arg{{Name: "@пользователь", Required: true}, {Name: "роль", Required: true, Validate: validRole}},
+					Handler: services.Message.Roles,
+				},
+				{
+					Name: "revoke", Aliases: []string{"отозвать"}, Usage: "отозвать роль",
+					Args:    []*Arg{{Name: "@пользователь", Required: true}},
+					Handler: services.Message.Roles,
+				},
+			},
+			Examples: []string{"роли выдать @ivanov operator", "roles revoke @ivanov"},
+			Role:     models.RoleAdmin,
+			Handler:  services.Message.Roles,
+		},
+		{
+			Name:    "about",
+			Aliases: []string{"информация"},
+			Usage:   "Информация о боте",
+			Handler: services.Information.AboutMe,
+		},
+		{
+			Name:     "help",
+			Aliases:  []string{"man", "помощь", "мануал"},
+			Usage:    "Список команд или справка по команде",
+			Args:     []*Arg{{Name: "command", Rest: true}},
+			Examples: []string{"help", "помощь add", "help holidays add"},
+			Handler:  r.help,
+		},
+	}
+}
+
+// addressFlags параметры адреса, общие для добавления и изменения
+func addressFlags() []*Flag {
+	return []*Flag{
+		{Name: "name", Short: "n", Usage: "название IP-адреса"},
+		{Name: "groups", Short: "g", Usage: "группы IP-адреса через запятую (пустая строка - без групп)"},
+		{Name: "rtt", Short: "r", Type: FlagMillis, Usage: "допустимое время пинга"},
+		{Name: "notification", Short: "N", Type: FlagInt, Usage: "количество уведомлений"},
+		{
+			Name: "period", Short: "p", Validate: validWindows,
+			Usage: "время в течении которого отправляются запросы (формат: [<дни недели>] <часы>:<минуты>-<часы>:<минуты>[,...][; ...], пустая строка - всегда)",
+		},
+		{Name: "timezone", Short: "z", Validate: validTimeZone, Usage: "часовой пояс периода, например Europe/Moscow (по умолчанию часовой пояс сервера)"},
+		{Name: "holidays", Short: "H", Type: FlagBool, Usage: "не проверять в праздничные дни"},
+		{Name: "interval", Short: "i", Type: FlagMillis, Usage: "время ожидания между отправкой каждого пакета"},
+		{Name: "timeout", Short: "t", Type: FlagMillis, Usage: "таймаут до завершения ping"},
+		{Name: "count", Short: "c", Type: FlagInt, Usage: "количество пакетов"},
+		{Name: "every", Short: "e", Type: FlagSeconds, Validate: validEvery, Usage: "интервал между проверками (0 - интервал по умолчанию)"},
+		{Name: "schedule", Short: "s", Validate: validCron, Usage: "расписание проверок в формате cron, используется вместо интервала (пустая строка - отключить)"},
+	}
+}
+
+// formatFlag формат файла адресов, usage уточняет значение по умолчанию
+func formatFlag(usage string) *Flag {
+	return &Flag{Name: "format", Short: "f", Usage: "формат файла: csv, yaml или json " + usage, Validate: validFormat}
+}
+
+func (r *Router) find(name string) *Command {
+	for _, cmd := range r.commands {
+		if cmd.is(name) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+func (r *Router) names() []string {
+	names := []string{}
+	for _, cmd := range r.commands {
+		names = append(names, cmd.names()...)
+	}
+	return names
+}
+
+// Handle выполняет команду из сообщения. Сообщения из каналов, в которых бот не принимает команды, пропускаются
+func (r *Router) Handle(post *models.Post) error {
+	post.Message = strings.TrimSpace(post.Message)
+
+	access := r.services.Channel.Access(post.ChannelID, post.ChannelType)
+	if access == models.ChannelAccessNone {
+		logger.Debug("command ignored", logger.StringAttr("channel", post.ChannelID), logger.StringAttr("user", post.UserID))
+		// slash-команду пользователь вызвал явно, поэтому ему нужно объяснить почему ничего не произошло
+		if post.ResponseURL != "" {
+			r.services.Post.Reply(post, "#### Ошибка.\nВ этом канале бот не принимает команды.")
+		}
+		return nil
+	}
+
+	// в каналах бот читает всю переписку, поэтому на текст, который не похож на команду, отвечает только
+	// если к нему обратились явно: в личных сообщениях, slash-командой или упоминанием
+	addressed := post.ChannelType == string(model.ChannelTypeDirect) || post.ResponseURL != "" || post.Mention
+	if post.Message == "" && !addressed {
+		return nil
+	}
+
+	cmd, input, err := r.parse(post.Message)
+	if err != nil {
+		var parseErr *parseError
+		if !errors.As(err, &parseErr) {
+			return err
+		}
+		if parseErr.command == nil && !addressed {
+			logger.Debug("message is not a command", logger.StringAttr("channel", post.ChannelID), logger.StringAttr("user", post.UserID))
+			return nil
+		}
+		message := "#### Ошибка.\n" + parseErr.message
+		if parseErr.command != nil {
+			message += fmt.Sprintf("\nПодробнее: `help %s`.", parseErr.command.FullName())
+		} else {
+			message += "\nСписок команд: `help`."
+		}
+		r.services.Post.Reply(post, message)
+		return nil
+	}
+	post.Input = input
+
+	if !r.allowed(post, access, cmd.role(input)) {
+		return nil
+	}
+	return cmd.Handler(post)
+}
+
+func (r *Router) allowed(post *models.Post, access, required string) bool {
+	if required == models.RoleViewer {
+		return true
+	}
+	if access == models.ChannelAccessRead {
+		logger.Warn("command denied in read-only channel",
+			logger.StringAttr("user", post.UserID),
+			logger.StringAttr("channel", post.ChannelID),
+			logger.StringAttr("message", post.Message),
+		)
+		r.services.Post.Reply(post, "#### Ошибка.\nВ этом канале доступны только команды просмотра.")
+		return false
+	}
+
+	role := r.services.Role.Resolve(context.Background(), post.UserID, post.ChannelID)
+	if models.RoleAllows(role, required) {
+		return true
+	}
+
+	logger.Warn("command denied",
+		logger.StringAttr("user", post.UserID),
+		logger.StringAttr("channel", post.ChannelID),
+		logger.StringAttr("role", role),
+		logger.StringAttr("required", required),
+		logger.StringAttr("message", post.Message),
+	)
+	message := fmt.Sprintf("#### Ошибка.\nНедостаточно прав. Команда доступна роли «%s» и выше, ваша роль - «%s».",
+		models.RoleTitles[required], models.RoleTitles[role],
+	)
+	r.services.Post.Reply(post, message)
+	return false
+}
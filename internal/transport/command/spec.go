@@ -0,0 +1,117 @@
+package command
+
+import (
+	"github.com/Alexander272/Pinger/internal/models"
+)
+
+// FlagType определяет, как разбирается значение флага
+type FlagType int
+
+const (
+	FlagString FlagType = iota
+	FlagInt
+	FlagBool
+	// флаг без значения
+	FlagSwitch
+	// количество миллисекунд, значение приводится к time.Duration
+	FlagMillis
+	// количество секунд, значение приводится к time.Duration
+	FlagSeconds
+)
+
+type Flag struct {
+	// длинное название без "--", под ним значение попадает в CommandInput
+	Name string
+	// короткое название без "-"
+	Short string
+	Type  FlagType
+	Usage string
+	// роль, необходимая, если флаг указан (например изменение настроек планировщика)
+	Role string
+	// Validate дополнительно проверяет значение, текст ошибки показывается пользователю
+	Validate func(string) error
+}
+
+type Arg struct {
+	Name     string
+	Usage    string
+	Required bool
+	// забрать все оставшиеся аргументы через пробел (название, текст)
+	Rest     bool
+	Validate func(string) error
+}
+
+// Command описание команды бота. По нему разбирается сообщение и строится справка
+type Command struct {
+	Name    string
+	Aliases []string
+	// краткое описание для общего списка команд
+	Usage string
+	// дополнительные строки справки по команде
+	Description []string
+	Args        []*Arg
+	Flags       []*Flag
+	// действия команды, например add и del у holidays
+	Actions  []*Command
+	Examples []string
+	// роль, необходимая для выполнения, по умолчанию доступно всем
+	Role    string
+	Handler func(*models.Post) error
+
+	parent *Command
+}
+
+// FullName возвращает название команды вместе с родительской, например "holidays add"
+func (c *Command) FullName() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.FullName() + " " + c.Name
+}
+
+func (c *Command) names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
+func (c *Command) action(name string) *Command {
+	for _, action := range c.Actions {
+		if action.is(name) {
+			return action
+		}
+	}
+	return nil
+}
+
+func (c *Command) is(name string) bool {
+	for _, n := range c.names() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Command) flag(name string) *Flag {
+	for _, flag := range c.Flags {
+		if "--"+flag.Name == name || (flag.Short != "" && "-"+flag.Short == name) {
+			return flag
+		}
+	}
+	return nil
+}
+
+// role возвращает роль, необходимую для выполнения команды с указанными флагами
+func (c *Command) role(input *models.CommandInput) string {
+	role := models.RoleViewer
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.Role != "" {
+			role = models.MaxRole(role, cmd.Role)
+		}
+	}
+	for _, flag := range c.Flags {
+		if flag.Role != "" && input.Has(flag.Name) {
+			role = models.MaxRole(role, flag.Role)
+		}
+	}
+	return role
+}
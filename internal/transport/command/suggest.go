@@ -0,0 +1,59 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// hint возвращает подсказку с похожим вариантом или пустую строку, если похожих нет
+func hint(word string, candidates []string) string {
+	if suggestion := suggest(word, candidates); suggestion != "" {
+		return fmt.Sprintf(" Возможно, вы имели в виду `%s`?", suggestion)
+	}
+	return ""
+}
+
+// suggest выбирает наиболее похожий на слово вариант. Допускается одна опечатка в коротких словах
+// и две в длинных, также подходят варианты, начинающиеся с введенного слова
+func suggest(word string, candidates []string) string {
+	limit := 1
+	if utf8.RuneCountInString(word) > 4 {
+		limit = 2
+	}
+
+	best, bestDistance := "", limit+1
+	for _, candidate := range candidates {
+		d := distance(word, candidate)
+		if d > limit && utf8.RuneCountInString(word) >= 3 && strings.HasPrefix(candidate, word) {
+			d = limit
+		}
+		if d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// distance расстояние Левенштейна между строками
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
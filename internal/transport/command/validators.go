@@ -0,0 +1,89 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/robfig/cron/v3"
+)
+
+func validIP(value string) error {
+	if net.ParseIP(value) == nil {
+		return errors.New("ожидается IP адрес")
+	}
+	return nil
+}
+
+// validAddresses проверяет адрес, подсеть, диапазон или список адресов
+func validAddresses(value string) error {
+	if _, err := models.ExpandAddresses(value); err != nil {
+		if errors.Is(err, models.ErrTooManyAddresses) {
+			return fmt.Errorf("слишком много адресов, не больше %d", models.MaxBulkAddresses)
+		}
+		return errors.New("ожидается IP адрес, подсеть, диапазон или список адресов через запятую")
+	}
+	return nil
+}
+
+func validFormat(value string) error {
+	if !slices.Contains(models.TransferFormats, value) {
+		return errors.New("допустимые форматы: csv, yaml, json")
+	}
+	return nil
+}
+
+// validEvery проверяет интервал проверок адреса в секундах, 0 - интервал по умолчанию
+func validEvery(value string) error {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return errors.New("ожидается число")
+	}
+	if seconds != 0 && seconds < models.MinCheckInterval.Seconds() {
+		return fmt.Errorf("интервал проверок не меньше %g с (0 - интервал по умолчанию)", models.MinCheckInterval.Seconds())
+	}
+	return nil
+}
+
+func validTimeZone(value string) error {
+	if _, err := time.LoadLocation(value); err != nil {
+		return errors.New("неизвестный часовой пояс")
+	}
+	return nil
+}
+
+// validCron проверяет расписание, пустая строка отключает расписание
+func validCron(value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := cron.ParseStandard(value); err != nil {
+		return errors.New("некорректное расписание cron")
+	}
+	return nil
+}
+
+func validWindows(value string) error {
+	if _, err := models.ParseWindows(value); err != nil {
+		return errors.New("ожидается период в формате [<дни недели>] <часы>:<минуты>-<часы>:<минуты>")
+	}
+	return nil
+}
+
+func validQuietPeriod(value string) error {
+	if _, _, err := models.ParseQuietPeriod(value); err != nil {
+		return errors.New("ожидается период в формате <часы>:<минуты>-<часы>:<минуты>")
+	}
+	return nil
+}
+
+func validRole(value string) error {
+	if !models.IsRole(value) {
+		return errors.New("допустимые роли: viewer, operator, admin")
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package actions
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/Alexander272/Pinger/pkg/error_bot"
+	"github.com/Alexander272/Pinger/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+type Handler struct {
+	service  services.Alert
+	dialogs  services.Dialog
+	transfer services.Transfer
+}
+
+func NewHandler(service services.Alert, dialogs services.Dialog, transfer services.Transfer) *Handler {
+	return &Handler{
+		service:  service,
+		dialogs:  dialogs,
+		transfer: transfer,
+	}
+}
+
+func Register(router *gin.RouterGroup, service services.Alert, dialogs services.Dialog, transfer services.Transfer) {
+	h := NewHandler(service, dialogs, transfer)
+
+	router.POST("/actions", h.handle)
+	router.POST("/dialogs", h.submit)
+}
+
+// handle принимает нажатие кнопки под сообщением
+func (h *Handler) handle(c *gin.Context) {
+	req := &model.PostActionIntegrationRequest{}
+	if err := c.BindJSON(req); err != nil {
+		logger.Error("failed to decode post action.", logger.ErrAttr(err))
+		return
+	}
+	action := &models.ActionRequest{
+		UserID:    req.UserId,
+		UserName:  req.UserName,
+		ChannelID: req.ChannelId,
+		PostID:    req.PostId,
+		TriggerID: req.TriggerId,
+		Context:   req.Context,
+	}
+
+	if req.Context["action"] == models.ActionDialog {
+		if err := h.dialogs.OpenFromAction(c, action); err != nil {
+			if errors.Is(err, models.ErrManaged) {
+				c.JSON(http.StatusOK, &model.PostActionIntegrationResponse{EphemeralText: "#### Ошибка.\nАдрес задан в файле конфигурации, изменить его можно только там."})
+				return
+			}
+			logger.Error("failed to open dialog.", logger.ErrAttr(err))
+			c.JSON(http.StatusOK, &model.PostActionIntegrationResponse{EphemeralText: "#### Ошибка.\nНе удалось открыть форму."})
+			return
+		}
+		c.JSON(http.StatusOK, &model.PostActionIntegrationResponse{})
+		return
+	}
+
+	handle := h.service.Handle
+	if req.Context["action"] == models.ActionImportApply || req.Context["action"] == models.ActionImportCancel {
+		handle = h.transfer.Handle
+	}
+
+	text, err := handle(c, action)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidAction) {
+			logger.Warn("invalid post action", logger.StringAttr("ip", c.ClientIP()), logger.AnyAttr("context", req.Context))
+			c.JSON(http.StatusForbidden, &model.PostActionIntegrationResponse{EphemeralText: "#### Ошибка.\nНеизвестное действие."})
+			return
+		}
+		logger.Error("failed to handle post action.", logger.ErrAttr(err))
+		error_bot.Send(c, err.Error(), req)
+		c.JSON(http.StatusOK, &model.PostActionIntegrationResponse{EphemeralText: "#### Ошибка.\nНе удалось выполнить действие."})
+		return
+	}
+
+	c.JSON(http.StatusOK, &model.PostActionIntegrationResponse{EphemeralText: text})
+}
+
+// submit принимает заполненную форму. Ошибки в полях Mattermost показывает рядом с ними
+func (h *Handler) submit(c *gin.Context) {
+	req := &model.SubmitDialogRequest{}
+	if err := c.BindJSON(req); err != nil {
+		logger.Error("failed to decode dialog submission.", logger.ErrAttr(err))
+		return
+	}
+	if req.Cancelled {
+		c.Status(http.StatusOK)
+		return
+	}
+
+	res, err := h.dialogs.Submit(c, &models.DialogSubmission{
+		CallbackID: req.CallbackId,
+		State:      req.State,
+		UserID:     req.UserId,
+		ChannelID:  req.ChannelId,
+		Values:     req.Submission,
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidDialog) {
+			logger.Warn("invalid dialog submission", logger.StringAttr("ip", c.ClientIP()), logger.StringAttr("callback", req.CallbackId))
+			c.JSON(http.StatusOK, &model.SubmitDialogResponse{Error: "Неизвестная форма."})
+			return
+		}
+		logger.Error("failed to submit dialog.", logger.ErrAttr(err))
+		error_bot.Send(c, err.Error(), req)
+		c.JSON(http.StatusOK, &model.SubmitDialogResponse{Error: "Не удалось сохранить адрес."})
+		return
+	}
+	if res == nil {
+		c.Status(http.StatusOK)
+		return
+	}
+	c.JSON(http.StatusOK, &model.SubmitDialogResponse{Error: res.Error, Errors: res.Fields})
+}
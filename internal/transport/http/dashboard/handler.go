@@ -0,0 +1,26 @@
+package dashboard
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// static содержит собранную панель мониторинга, отдельная сборка фронтенда не нужна
+//
+//go:embed static
+var static embed.FS
+
+func Register(router *gin.Engine) {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+
+	router.StaticFS("/dashboard", http.FS(files))
+	router.GET("/", func(c *gin.Context) {
+		c.Redirect(http.StatusFound, "/dashboard/")
+	})
+}
@@ -0,0 +1,217 @@
+'use strict';
+
+const api = '/api/v1';
+const state = {
+	token: localStorage.getItem('pinger-token') || '',
+	addresses: [],
+	states: new Map(),
+	history: new Map(),
+	selected: '',
+	source: null,
+};
+const historySize = 60;
+
+const $ = (id) => document.getElementById(id);
+
+async function request(path) {
+	const resp = await fetch(api + path, { headers: { Authorization: 'Bearer ' + state.token } });
+	const body = await resp.json().catch(() => ({}));
+	if (!resp.ok) {
+		throw new Error(body.message || resp.statusText);
+	}
+	return body.data || [];
+}
+
+function showError(err) {
+	const el = $('error');
+	el.textContent = err.message || String(err);
+	el.hidden = false;
+	setTimeout(() => { el.hidden = true; }, 5000);
+}
+
+// длительности в API передаются в наносекундах
+const ms = (ns) => ns / 1e6;
+
+function formatDuration(ns) {
+	let seconds = Math.floor(ns / 1e9);
+	const days = Math.floor(seconds / 86400);
+	seconds %= 86400;
+	const parts = [];
+	if (days) parts.push(days + ' д');
+	parts.push(String(Math.floor(seconds / 3600)).padStart(2, '0') + ':' +
+		String(Math.floor(seconds % 3600 / 60)).padStart(2, '0') + ':' +
+		String(seconds % 60).padStart(2, '0'));
+	return parts.join(' ');
+}
+
+const formatTime = (value) => value && !value.startsWith('0001') ? new Date(value).toLocaleString('ru-RU') : '-';
+const dateInput = (date) => date.toISOString().slice(0, 10);
+
+function status(address) {
+	if (!address.enabled) return 'disabled';
+	const s = state.states.get(address.ip);
+	if (!s) return 'unknown';
+	if (s.isFailed) return 'down';
+	return s.isLong ? 'slow' : 'up';
+}
+
+function sparkline(points) {
+	const svg = document.createElementNS('http://www.w3.org/2000/svg', 'svg');
+	svg.setAttribute('viewBox', `0 0 ${historySize} 32`);
+	svg.setAttribute('preserveAspectRatio', 'none');
+	if (points.length < 2) return svg;
+
+	const values = points.map((p) => ms(p.avgRtt));
+	const max = Math.max(...values, 1);
+	const offset = historySize - values.length;
+	const line = document.createElementNS('http://www.w3.org/2000/svg', 'polyline');
+	line.setAttribute('points', values.map((v, i) => `${offset + i},${(31 - v / max * 30).toFixed(1)}`).join(' '));
+	svg.appendChild(line);
+	return svg;
+}
+
+function renderGroups() {
+	const select = $('group');
+	const current = select.value;
+	const groups = [...new Set(state.addresses.flatMap((a) => a.groups || []))].sort();
+	select.replaceChildren(new Option('Все группы', ''), ...groups.map((g) => new Option(g, g)));
+	select.value = groups.includes(current) ? current : '';
+}
+
+function renderGrid() {
+	const group = $('group').value;
+	const list = state.addresses.filter((a) => !group || (a.groups || []).includes(group));
+	const counts = { up: 0, slow: 0, down: 0 };
+
+	const cards = list.map((address) => {
+		const s = state.states.get(address.ip);
+		const st = status(address);
+		if (st in counts) counts[st]++;
+
+		const card = document.createElement('div');
+		card.className = `card ${st}` + (address.ip === state.selected ? ' selected' : '');
+		card.title = st;
+		card.onclick = () => selectAddress(address);
+
+		const name = document.createElement('div');
+		name.className = 'name';
+		name.textContent = address.name || address.ip;
+		const ip = document.createElement('div');
+		ip.className = 'ip';
+		ip.textContent = address.ip;
+		const groups = document.createElement('div');
+		groups.className = 'groups';
+		groups.textContent = (address.groups || []).join(', ');
+		const metrics = document.createElement('div');
+		metrics.className = 'metrics';
+		metrics.textContent = s ? `${ms(s.avgRtt).toFixed(1)} мс, потери ${s.packetLoss.toFixed(0)}%` : 'нет данных';
+
+		card.append(name, ip, groups, metrics, sparkline(state.history.get(address.ip) || []));
+		return card;
+	});
+
+	$('grid').replaceChildren(...cards);
+	$('summary').textContent = `доступно: ${counts.up}, медленно: ${counts.slow}, недоступно: ${counts.down}`;
+}
+
+function row(...cells) {
+	const tr = document.createElement('tr');
+	for (const cell of cells) {
+		const td = document.createElement('td');
+		td.textContent = cell;
+		tr.appendChild(td);
+	}
+	return tr;
+}
+
+async function loadIncidents() {
+	const data = await request('/statistics/unavailable');
+	$('incidents').tBodies[0].replaceChildren(...data.map((d) =>
+		row(d.name ? `${d.name} (${d.ip})` : d.ip, formatTime(d.timeStart), formatDuration(Date.now() * 1e6 - new Date(d.timeStart).getTime() * 1e6)),
+	));
+}
+
+async function loadDetails() {
+	if (!state.selected) return;
+	const query = new URLSearchParams({ ip: state.selected, start: $('start').value, end: $('end').value });
+	const data = await request('/statistics?' + query);
+	$('history').tBodies[0].replaceChildren(...data.map((d) => row(formatTime(d.timeStart), formatTime(d.timeEnd), formatDuration(d.time))));
+}
+
+async function selectAddress(address) {
+	state.selected = address.ip;
+	$('details').hidden = false;
+	$('details-title').textContent = `История: ${address.name || address.ip}`;
+	renderGrid();
+	await loadDetails().catch(showError);
+}
+
+async function load() {
+	const [addresses, states] = await Promise.all([request('/addresses'), request('/checks')]);
+	state.addresses = addresses;
+	state.states = new Map(states.map((s) => [s.ip, s]));
+
+	const histories = await Promise.all(addresses.map((a) => request(`/checks/${a.ip}/history`).catch(() => [])));
+	addresses.forEach((a, i) => state.history.set(a.ip, histories[i]));
+
+	renderGroups();
+	renderGrid();
+	await loadIncidents();
+}
+
+function connect() {
+	if (state.source) state.source.close();
+
+	const source = new EventSource(`${api}/events?access_token=${encodeURIComponent(state.token)}`);
+	const indicator = $('connection');
+	source.onopen = () => { indicator.textContent = 'онлайн'; indicator.classList.add('online'); };
+	source.onerror = () => { indicator.textContent = 'нет соединения'; indicator.classList.remove('online'); };
+
+	source.addEventListener('check', (e) => {
+		const event = JSON.parse(e.data);
+		state.states.set(event.ip, event.state);
+
+		const points = state.history.get(event.ip) || [];
+		points.push({ time: event.state.checkedAt, avgRtt: event.state.avgRtt, packetLoss: event.state.packetLoss, isFailed: event.state.isFailed });
+		state.history.set(event.ip, points.slice(-historySize));
+		renderGrid();
+	});
+	for (const type of ['down', 'up']) {
+		source.addEventListener(type, () => loadIncidents().catch(showError));
+	}
+	state.source = source;
+}
+
+async function start() {
+	if (!state.token) {
+		$('token').focus();
+		return;
+	}
+	try {
+		await load();
+		connect();
+	} catch (err) {
+		showError(err);
+	}
+}
+
+$('auth').onsubmit = (e) => {
+	e.preventDefault();
+	state.token = $('token').value.trim();
+	localStorage.setItem('pinger-token', state.token);
+	$('token').value = '';
+	start();
+};
+$('group').onchange = renderGrid;
+$('period').onsubmit = (e) => {
+	e.preventDefault();
+	loadDetails().catch(showError);
+};
+
+const now = new Date();
+$('start').value = dateInput(new Date(now.getFullYear(), now.getMonth(), 1, 12));
+$('end').value = dateInput(new Date(now.getFullYear(), now.getMonth() + 1, 0, 12));
+
+// список адресов и инциденты обновляются реже, события проверок приходят через поток
+setInterval(() => state.token && load().catch(showError), 60000);
+start();
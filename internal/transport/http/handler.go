@@ -0,0 +1,86 @@
+package transport
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/config"
+	"github.com/Alexander272/Pinger/internal/metrics"
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/Alexander272/Pinger/internal/transport/command"
+	"github.com/Alexander272/Pinger/internal/transport/http/actions"
+	"github.com/Alexander272/Pinger/internal/transport/http/dashboard"
+	"github.com/Alexander272/Pinger/internal/transport/http/health"
+	"github.com/Alexander272/Pinger/internal/transport/http/middleware"
+	"github.com/Alexander272/Pinger/internal/transport/http/slash"
+	"github.com/Alexander272/Pinger/internal/transport/http/status"
+	httpV1 "github.com/Alexander272/Pinger/internal/transport/http/v1"
+	"github.com/Alexander272/Pinger/pkg/limiter"
+	"github.com/Alexander272/Pinger/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	services *services.Services
+	commands *command.Router
+}
+
+func NewHandler(services *services.Services, commands *command.Router) *Handler {
+	return &Handler{
+		services: services,
+		commands: commands,
+	}
+}
+
+func (h *Handler) Init(conf *config.Config) http.Handler {
+	if conf.Environment != "dev" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router := gin.New()
+	router.Use(
+		gin.Recovery(),
+		limiter.Limit(conf.Limiter.RPS, conf.Limiter.Burst, conf.Limiter.TTL),
+	)
+
+	// Init router
+	router.GET("/api/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	// метрики содержат адреса, названия и группы, поэтому доступны только с токеном (Prometheus: authorization.credentials)
+	router.GET("/metrics", middleware.Authorize(h.services.Token), gin.WrapH(metrics.Handler()))
+	health.Register(&router.RouterGroup, h.services.Health)
+	dashboard.Register(router)
+	if conf.StatusPage.Enabled {
+		status.Register(&router.RouterGroup, h.services.StatusPage)
+	}
+
+	h.initAPI(router)
+
+	return streaming(router)
+}
+
+// streaming снимает ограничение времени записи для потоковых ответов (Server-Sent Events),
+// иначе сервер закрывал бы соединение по WriteTimeout
+func streaming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+				logger.Error("failed to reset write deadline.", logger.ErrAttr(err))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *Handler) initAPI(router *gin.Engine) {
+	handlerV1 := httpV1.NewHandler(&httpV1.Deps{Services: h.services})
+	api := router.Group("/api")
+	{
+		handlerV1.Init(api)
+		mattermost := api.Group("/mattermost")
+		slash.Register(mattermost, h.services.Command, h.commands)
+		actions.Register(mattermost, h.services.Alert, h.services.Dialog, h.services.Transfer)
+	}
+}
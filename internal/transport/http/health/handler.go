@@ -0,0 +1,42 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service services.Health
+}
+
+func NewHandler(service services.Health) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func Register(router *gin.RouterGroup, service services.Health) {
+	h := NewHandler(service)
+
+	router.GET("/healthz", h.liveness)
+	router.GET("/readyz", h.readiness)
+}
+
+func (h *Handler) liveness(c *gin.Context) {
+	h.respond(c, h.service.Liveness(c))
+}
+
+func (h *Handler) readiness(c *gin.Context) {
+	h.respond(c, h.service.Readiness(c))
+}
+
+func (h *Handler) respond(c *gin.Context, data *models.Health) {
+	status := http.StatusOK
+	if data.Status != models.StatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, data)
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/models/response"
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const TokenKey = "api_token"
+
+// Authorize проверяет токен из заголовка Authorization. Для чтения (GET, HEAD) достаточно токена
+// с областью read, для изменения данных нужен токен с областью admin
+func Authorize(tokens services.Token) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := models.ScopeAdmin
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			scope = models.ScopeRead
+		}
+
+		token, err := tokens.Authenticate(c, bearerToken(c), scope)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrTokenMissing):
+				c.Header("WWW-Authenticate", "Bearer")
+				response.NewErrorResponse(c, http.StatusUnauthorized, err.Error(), "Не указан токен доступа")
+			case errors.Is(err, models.ErrTokenInvalid):
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+				response.NewErrorResponse(c, http.StatusUnauthorized, err.Error(), "Токен доступа недействителен")
+			case errors.Is(err, models.ErrTokenScope):
+				response.NewErrorResponse(c, http.StatusForbidden, err.Error(), "Недостаточно прав для выполнения запроса")
+			default:
+				response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Не удалось проверить токен доступа")
+			}
+			return
+		}
+
+		c.Set(TokenKey, token)
+		c.Set(services.AuditActorKey, &models.Post{UserName: token.Name, Message: c.Request.Method + " " + c.Request.URL.Path})
+		c.Next()
+	}
+}
+
+// bearerToken возвращает токен из заголовка. Браузерный EventSource не умеет передавать заголовки,
+// поэтому для запросов на чтение токен можно передать в параметре access_token
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	scheme, token, ok := strings.Cut(header, " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	if c.Request.Method == http.MethodGet {
+		return c.Query("access_token")
+	}
+	return ""
+}
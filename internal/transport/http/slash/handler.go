@@ -0,0 +1,71 @@
+package slash
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/Alexander272/Pinger/internal/transport/command"
+	"github.com/Alexander272/Pinger/pkg/error_bot"
+	"github.com/Alexander272/Pinger/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/mattermost/mattermost-server/v6/model"
+)
+
+type Handler struct {
+	service services.Command
+	router  *command.Router
+}
+
+func NewHandler(service services.Command, router *command.Router) *Handler {
+	return &Handler{
+		service: service,
+		router:  router,
+	}
+}
+
+func Register(router *gin.RouterGroup, service services.Command, commands *command.Router) {
+	h := NewHandler(service, commands)
+
+	router.POST("/command", h.handle)
+}
+
+// handle принимает slash-команду от Mattermost. Ответ сразу пустой, а результат команды
+// отправляется через response_url, т.к. Mattermost ждет ответа всего несколько секунд
+func (h *Handler) handle(c *gin.Context) {
+	if !h.service.Verify(c.PostForm("token")) {
+		logger.Warn("slash command with invalid token", logger.StringAttr("ip", c.ClientIP()))
+		c.JSON(http.StatusUnauthorized, &model.CommandResponse{
+			ResponseType: model.CommandResponseTypeEphemeral,
+			Text:         "#### Ошибка.\nНеверный токен команды.",
+		})
+		return
+	}
+
+	post := &models.Post{
+		ChannelID:   c.PostForm("channel_id"),
+		UserID:      c.PostForm("user_id"),
+		Message:     c.PostForm("text"),
+		ResponseURL: c.PostForm("response_url"),
+		TriggerID:   c.PostForm("trigger_id"),
+	}
+	logger.Info("slash command", logger.StringAttr("user", c.PostForm("user_name")), logger.StringAttr("text", post.Message))
+
+	go func() {
+		// gin.Recovery не перехватывает панику в этой горутине, без recover она остановит весь бот
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error("panic while handling slash command", logger.AnyAttr("panic", r), logger.StringAttr("stack", string(debug.Stack())))
+				error_bot.Send(&gin.Context{}, fmt.Sprintf("panic: %v", r), post)
+			}
+		}()
+
+		if err := h.router.Handle(post); err != nil {
+			error_bot.Send(&gin.Context{}, err.Error(), post)
+		}
+	}()
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,115 @@
+package status
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/Alexander272/Pinger/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var componentLabels = map[string]string{
+	models.ComponentOperational: "Работает",
+	models.ComponentDegraded:    "Снижена производительность",
+	models.ComponentPartial:     "Частичный сбой",
+	models.ComponentOutage:      "Не работает",
+	models.ComponentUnknown:     "Нет данных",
+}
+
+var pageLabels = map[string]string{
+	models.ComponentOperational: "Все системы работают",
+	models.ComponentDegraded:    "Снижена производительность",
+	models.ComponentPartial:     "Частичный сбой",
+	models.ComponentOutage:      "Серьезный сбой",
+	models.ComponentUnknown:     "Нет данных",
+}
+
+var incidentLabels = map[string]string{
+	models.IncidentInvestigating: "Выясняем причину",
+	models.IncidentIdentified:    "Причина найдена",
+	models.IncidentMonitoring:    "Наблюдаем",
+	models.IncidentResolved:      "Решено",
+}
+
+type Handler struct {
+	service services.StatusPage
+	page    *template.Template
+}
+
+func NewHandler(service services.StatusPage) *Handler {
+	funcs := template.FuncMap{
+		"componentLabel": func(status string) string { return componentLabels[status] },
+		"pageLabel":      func(status string) string { return pageLabels[status] },
+		"incidentLabel":  func(status string) string { return incidentLabels[status] },
+		"uptime":         formatUptime,
+		"dayClass":       dayClass,
+		"date":           func(t time.Time) string { return t.Local().Format("02.01.2006") },
+		"datetime":       func(t time.Time) string { return t.Local().Format("02.01.2006 15:04") },
+		"duration":       func(d time.Duration) string { return d.Round(time.Minute).String() },
+	}
+
+	return &Handler{
+		service: service,
+		page:    template.Must(template.New("status.html").Funcs(funcs).ParseFS(templates, "templates/status.html")),
+	}
+}
+
+// Register подключает публичную страницу статуса. Страница доступна без токена и не содержит IP адресов
+func Register(router *gin.RouterGroup, service services.StatusPage) {
+	h := NewHandler(service)
+
+	router.GET("/status", h.html)
+	router.GET("/status.json", h.json)
+}
+
+func (h *Handler) html(c *gin.Context) {
+	data, err := h.service.Page(c)
+	if err != nil {
+		logger.Error("failed to build status page.", logger.ErrAttr(err))
+		c.String(http.StatusInternalServerError, "Не удалось получить статус сервисов")
+		return
+	}
+
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := h.page.Execute(c.Writer, data); err != nil {
+		logger.Error("failed to render status page.", logger.ErrAttr(err))
+	}
+}
+
+func (h *Handler) json(c *gin.Context) {
+	data, err := h.service.Page(c)
+	if err != nil {
+		logger.Error("failed to build status page.", logger.ErrAttr(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"message": "Не удалось получить статус сервисов"})
+		return
+	}
+	c.JSON(http.StatusOK, data)
+}
+
+func formatUptime(value *float64) string {
+	if value == nil {
+		return "нет данных"
+	}
+	return fmt.Sprintf("%.2f%%", *value)
+}
+
+func dayClass(day *models.UptimeDay) string {
+	switch {
+	case day.Uptime == nil:
+		return "none"
+	case *day.Uptime >= 99.9:
+		return "up"
+	case *day.Uptime >= 95:
+		return "degraded"
+	}
+	return "down"
+}
@@ -0,0 +1,290 @@
+package addresses
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/models/response"
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service  services.Address
+	transfer services.Transfer
+}
+
+func NewHandler(service services.Address, transfer services.Transfer) *Handler {
+	return &Handler{
+		service:  service,
+		transfer: transfer,
+	}
+}
+
+func Register(api *gin.RouterGroup, service services.Address, transfer services.Transfer) {
+	h := NewHandler(service, transfer)
+
+	addresses := api.Group("/addresses")
+	{
+		addresses.GET("", h.getAll)
+		addresses.GET("/export", h.export)
+		addresses.POST("/import", h.importFile)
+		addresses.GET("/:ip", h.getByIP)
+		addresses.POST("", h.create)
+		addresses.PUT("/:ip", h.update)
+		addresses.PUT("/:ip/enable", h.enable)
+		addresses.PUT("/:ip/disable", h.disable)
+		addresses.DELETE("/:ip", h.delete)
+	}
+}
+
+func (h *Handler) getAll(c *gin.Context) {
+	data, err := h.service.GetAll(c)
+	if err != nil {
+		response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Произошла ошибка: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, response.DataResponse{Data: data, Count: len(data)})
+}
+
+func (h *Handler) getByIP(c *gin.Context) {
+	ip := c.Param("ip")
+	if net.ParseIP(ip) == nil {
+		response.NewErrorResponse(c, http.StatusBadRequest, "invalid ip", "Некорректный IP адрес")
+		return
+	}
+
+	data, err := h.service.GetByIP(c, ip)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			response.NewErrorResponse(c, http.StatusNotFound, err.Error(), "IP адрес не найден")
+			return
+		}
+		response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Произошла ошибка: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, response.DataResponse{Data: data})
+}
+
+func (h *Handler) create(c *gin.Context) {
+	dto := &models.AddressDTO{}
+	if err := c.BindJSON(dto); err != nil {
+		response.NewErrorResponse(c, http.StatusBadRequest, err.Error(), "Отправлены некорректные данные")
+		return
+	}
+	if net.ParseIP(dto.IP) == nil {
+		response.NewErrorResponse(c, http.StatusBadRequest, "invalid ip", "Некорректный IP адрес")
+		return
+	}
+
+	if err := h.service.Create(c, dto); err != nil {
+		if errors.Is(err, models.ErrExist) {
+			response.NewErrorResponse(c, http.StatusConflict, err.Error(), "IP адрес уже добавлен")
+			return
+		}
+		if errors.Is(err, models.ErrInvalidAddress) {
+			response.NewErrorResponse(c, http.StatusBadRequest, err.Error(), "Некорректные параметры адреса")
+			return
+		}
+		response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Произошла ошибка: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusCreated, response.IdResponse{Message: "IP адрес добавлен"})
+}
+
+func (h *Handler) update(c *gin.Context) {
+	ip := c.Param("ip")
+	if net.ParseIP(ip) == nil {
+		response.NewErrorResponse(c, http.StatusBadRequest, "invalid ip", "Некорректный IP адрес")
+		return
+	}
+
+	dto := &models.AddressDTO{}
+	if err := c.BindJSON(dto); err != nil {
+		response.NewErrorResponse(c, http.StatusBadRequest, err.Error(), "Отправлены некорректные данные")
+		return
+	}
+	dto.IP = ip
+
+	data, err := h.service.GetByIP(c, ip)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			response.NewErrorResponse(c, http.StatusNotFound, err.Error(), "IP адрес не найден")
+			return
+		}
+		response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Произошла ошибка: "+err.Error())
+		return
+	}
+	dto.Fill(data)
+
+	if err := h.service.Update(c, dto); err != nil {
+		if errors.Is(err, models.ErrManaged) {
+			response.NewErrorResponse(c, http.StatusConflict, err.Error(), "IP адрес задан в файле конфигурации")
+			return
+		}
+		if errors.Is(err, models.ErrInvalidAddress) {
+			response.NewErrorResponse(c, http.StatusBadRequest, err.Error(), "Некорректные параметры адреса")
+			return
+		}
+		response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Произошла ошибка: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, response.IdResponse{Message: "IP адрес обновлен"})
+}
+
+func (h *Handler) enable(c *gin.Context) {
+	h.toggle(c, true)
+}
+
+func (h *Handler) disable(c *gin.Context) {
+	h.toggle(c, false)
+}
+
+func (h *Handler) toggle(c *gin.Context, enabled bool) {
+	ip := c.Param("ip")
+	if net.ParseIP(ip) == nil {
+		response.NewErrorResponse(c, http.StatusBadRequest, "invalid ip", "Некорректный IP адрес")
+		return
+	}
+
+	if err := h.service.ToggleActive(c, ip, enabled); err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			response.NewErrorResponse(c, http.StatusNotFound, err.Error(), "IP адрес не найден")
+			return
+		}
+		if errors.Is(err, models.ErrManaged) {
+			response.NewErrorResponse(c, http.StatusConflict, err.Error(), "IP адрес задан в файле конфигурации")
+			return
+		}
+		if errors.Is(err, models.ErrInvalidAddress) {
+			response.NewErrorResponse(c, http.StatusBadRequest, err.Error(), "Некорректные параметры адреса")
+			return
+		}
+		response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Произошла ошибка: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, response.IdResponse{Message: "IP адрес обновлен"})
+}
+
+func (h *Handler) delete(c *gin.Context) {
+	ip := c.Param("ip")
+	if net.ParseIP(ip) == nil {
+		response.NewErrorResponse(c, http.StatusBadRequest, "invalid ip", "Некорректный IP адрес")
+		return
+	}
+
+	if err := h.service.Delete(c, ip); err != nil {
+		if errors.Is(err, models.ErrNoRows) {
+			response.NewErrorResponse(c, http.StatusNotFound, err.Error(), "IP адрес не найден")
+			return
+		}
+		if errors.Is(err, models.ErrManaged) {
+			response.NewErrorResponse(c, http.StatusConflict, err.Error(), "IP адрес задан в файле конфигурации")
+			return
+		}
+		response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Произошла ошибка: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, response.IdResponse{Message: "IP адрес удален"})
+}
+
+// maxImportSize наибольший размер файла импорта вместе с оберткой формы
+const maxImportSize = 10 << 20
+
+var contentTypes = map[string]string{
+	models.FormatCSV:  "text/csv; charset=utf-8",
+	models.FormatYAML: "application/yaml; charset=utf-8",
+	models.FormatJSON: "application/json; charset=utf-8",
+}
+
+// export выгружает список адресов в файл, формат задается параметром format (по умолчанию csv)
+func (h *Handler) export(c *gin.Context) {
+	format := c.DefaultQuery("format", models.FormatCSV)
+	if _, ok := contentTypes[format]; !ok {
+		response.NewErrorResponse(c, http.StatusBadRequest, models.ErrUnknownFormat.Error(), "Допустимые форматы: csv, yaml, json")
+		return
+	}
+
+	data, err := h.transfer.Export(c, format)
+	if err != nil {
+		response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Произошла ошибка: "+err.Error())
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="addresses-%s.%s"`, time.Now().Format("2006-01-02"), format))
+	c.Data(http.StatusOK, contentTypes[format], data)
+}
+
+// importFile загружает список адресов. Файл передается телом запроса или полем file формы.
+// Параметры: format - формат файла (по умолчанию определяется по содержимому), prune - удалить адреса, которых нет в файле,
+// dry_run - только показать изменения
+func (h *Handler) importFile(c *gin.Context) {
+	format := c.Query("format")
+	if _, ok := contentTypes[format]; format != "" && !ok {
+		response.NewErrorResponse(c, http.StatusBadRequest, models.ErrUnknownFormat.Error(), "Допустимые форматы: csv, yaml, json")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	var data []byte
+	var err error
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		var file *multipart.FileHeader
+		if err = c.Request.ParseMultipartForm(maxImportSize); err == nil {
+			file, err = c.FormFile("file")
+		}
+		if err == nil {
+			data, err = readFile(file)
+		}
+	} else {
+		data, err = io.ReadAll(c.Request.Body)
+	}
+	if tooLarge := (*http.MaxBytesError)(nil); errors.As(err, &tooLarge) {
+		response.NewErrorResponse(c, http.StatusRequestEntityTooLarge, err.Error(), fmt.Sprintf("Размер файла больше %d МБ", maxImportSize>>20))
+		return
+	}
+	if err != nil || len(data) == 0 {
+		response.NewErrorResponse(c, http.StatusBadRequest, "empty file", "Не передан файл со списком адресов")
+		return
+	}
+
+	plan, err := h.transfer.Plan(c, data, format, c.Query("prune") == "true")
+	if err != nil {
+		response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Произошла ошибка: "+err.Error())
+		return
+	}
+	if len(plan.Errors) > 0 {
+		c.AbortWithStatusJSON(http.StatusBadRequest, response.DataResponse{Data: plan, Count: len(plan.Errors)})
+		return
+	}
+	if c.Query("dry_run") == "true" {
+		c.JSON(http.StatusOK, response.DataResponse{Data: plan})
+		return
+	}
+
+	if err := h.transfer.Apply(c, plan); err != nil {
+		if errors.Is(err, models.ErrExist) {
+			response.NewErrorResponse(c, http.StatusConflict, err.Error(), "Список адресов изменился во время импорта, повторите запрос")
+			return
+		}
+		response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Произошла ошибка: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, response.DataResponse{Data: plan})
+}
+
+func readFile(header *multipart.FileHeader) ([]byte, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
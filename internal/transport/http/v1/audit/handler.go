@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/models/response"
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Handler struct {
+	service services.Audit
+}
+
+func NewHandler(service services.Audit) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func Register(api *gin.RouterGroup, service services.Audit) {
+	h := NewHandler(service)
+
+	api.GET("/audit", h.get)
+}
+
+// get возвращает журнал изменений адресов, начиная с последних. Параметры запроса:
+// ip - изменения одного адреса, user - изменения пользователя (id в Mattermost),
+// start, end - даты в формате ГГГГ-ММ-ДД (дата окончания входит в период), limit - количество записей (по умолчанию 100)
+func (h *Handler) get(c *gin.Context) {
+	filter := &models.AuditFilter{
+		IP:     c.Query("ip"),
+		UserID: c.Query("user"),
+		Limit:  defaultLimit,
+	}
+	if filter.IP != "" && net.ParseIP(filter.IP) == nil {
+		response.NewErrorResponse(c, http.StatusBadRequest, "invalid ip", "Некорректный IP адрес")
+		return
+	}
+
+	if start := c.Query("start"); start != "" {
+		date, err := time.ParseInLocation(time.DateOnly, start, time.Local)
+		if err != nil {
+			response.NewErrorResponse(c, http.StatusBadRequest, err.Error(), "Некорректная дата начала периода")
+			return
+		}
+		filter.PeriodStart = date
+	}
+	if end := c.Query("end"); end != "" {
+		date, err := time.ParseInLocation(time.DateOnly, end, time.Local)
+		if err != nil {
+			response.NewErrorResponse(c, http.StatusBadRequest, err.Error(), "Некорректная дата окончания периода")
+			return
+		}
+		filter.PeriodEnd = date.AddDate(0, 0, 1)
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxLimit {
+			response.NewErrorResponse(c, http.StatusBadRequest, "invalid limit", "Количество записей должно быть от 1 до "+strconv.Itoa(maxLimit))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	data, err := h.service.Get(c, filter)
+	if err != nil {
+		response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Произошла ошибка: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, response.DataResponse{Data: data, Count: len(data)})
+}
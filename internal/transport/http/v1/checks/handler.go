@@ -0,0 +1,54 @@
+package checks
+
+import (
+	"net/http"
+
+	"github.com/Alexander272/Pinger/internal/models/response"
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service services.Ping
+}
+
+func NewHandler(service services.Ping) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func Register(api *gin.RouterGroup, service services.Ping) {
+	h := NewHandler(service)
+
+	checks := api.Group("/checks")
+	{
+		checks.GET("", h.getStates)
+		checks.GET("/stats", h.getStats)
+		checks.GET("/:ip", h.getState)
+		checks.GET("/:ip/history", h.getHistory)
+	}
+}
+
+func (h *Handler) getStates(c *gin.Context) {
+	data := h.service.States()
+	c.JSON(http.StatusOK, response.DataResponse{Data: data, Count: len(data)})
+}
+
+func (h *Handler) getState(c *gin.Context) {
+	data, ok := h.service.GetState(c.Param("ip"))
+	if !ok {
+		response.NewErrorResponse(c, http.StatusNotFound, "state not found", "Адрес еще не проверялся")
+		return
+	}
+	c.JSON(http.StatusOK, response.DataResponse{Data: data})
+}
+
+func (h *Handler) getStats(c *gin.Context) {
+	c.JSON(http.StatusOK, response.DataResponse{Data: h.service.Stats()})
+}
+
+func (h *Handler) getHistory(c *gin.Context) {
+	data := h.service.History(c.Param("ip"))
+	c.JSON(http.StatusOK, response.DataResponse{Data: data, Count: len(data)})
+}
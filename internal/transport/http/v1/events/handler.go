@@ -0,0 +1,87 @@
+package events
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// размер буфера клиента, при медленном чтении лишние события отбрасываются
+	bufferSize = 256
+	// период отправки комментария, чтобы прокси не закрывали неактивное соединение
+	keepAlive = 30 * time.Second
+)
+
+type Handler struct {
+	service services.Events
+}
+
+func NewHandler(service services.Events) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func Register(api *gin.RouterGroup, service services.Events) {
+	h := NewHandler(service)
+
+	api.GET("/events", h.stream)
+}
+
+// stream отдает события проверок в формате Server-Sent Events.
+// Фильтры: ip, group и type, значения можно перечислять через запятую или повторять параметр.
+// Клиент должен передавать заголовок Accept: text/event-stream (EventSource делает это сам)
+func (h *Handler) stream(c *gin.Context) {
+	filter := &models.EventFilter{
+		IPs:    queryList(c, "ip"),
+		Groups: queryList(c, "group"),
+		Types:  queryList(c, "type"),
+	}
+
+	sub := h.service.Subscribe(filter, bufferSize)
+	defer sub.Close()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-sub.C:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-ticker.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		}
+	})
+}
+
+func queryList(c *gin.Context, key string) []string {
+	list := []string{}
+	for _, value := range c.QueryArray(key) {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
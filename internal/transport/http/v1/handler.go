@@ -0,0 +1,36 @@
+package v1
+
+import (
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/Alexander272/Pinger/internal/transport/http/middleware"
+	"github.com/Alexander272/Pinger/internal/transport/http/v1/addresses"
+	"github.com/Alexander272/Pinger/internal/transport/http/v1/audit"
+	"github.com/Alexander272/Pinger/internal/transport/http/v1/checks"
+	"github.com/Alexander272/Pinger/internal/transport/http/v1/events"
+	"github.com/Alexander272/Pinger/internal/transport/http/v1/statistics"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	services *services.Services
+}
+
+type Deps struct {
+	Services *services.Services
+}
+
+func NewHandler(deps *Deps) *Handler {
+	return &Handler{
+		services: deps.Services,
+	}
+}
+
+func (h *Handler) Init(group *gin.RouterGroup) {
+	v1 := group.Group("/v1", middleware.Authorize(h.services.Token))
+
+	addresses.Register(v1, h.services.Address, h.services.Transfer)
+	statistics.Register(v1, h.services.Statistic)
+	checks.Register(v1, h.services.Ping)
+	events.Register(v1, h.services.Events)
+	audit.Register(v1, h.services.Audit)
+}
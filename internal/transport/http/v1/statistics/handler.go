@@ -0,0 +1,87 @@
+package statistics
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Alexander272/Pinger/internal/models"
+	"github.com/Alexander272/Pinger/internal/models/response"
+	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service services.Statistic
+}
+
+func NewHandler(service services.Statistic) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+func Register(api *gin.RouterGroup, service services.Statistic) {
+	h := NewHandler(service)
+
+	statistics := api.Group("/statistics")
+	{
+		statistics.GET("", h.get)
+		statistics.GET("/unavailable", h.getUnavailable)
+	}
+}
+
+// get возвращает статистику за период (по умолчанию текущий месяц). Параметры запроса:
+// start, end - даты в формате ГГГГ-ММ-ДД, ip - статистика по одному адресу
+func (h *Handler) get(c *gin.Context) {
+	now := time.Now()
+	period := &models.GetStatisticDTO{
+		PeriodStart: time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()),
+		PeriodEnd:   time.Date(now.Year(), now.Month()+1, 0, 0, 0, 0, 0, now.Location()),
+	}
+
+	if start := c.Query("start"); start != "" {
+		date, err := time.ParseInLocation(time.DateOnly, start, now.Location())
+		if err != nil {
+			response.NewErrorResponse(c, http.StatusBadRequest, err.Error(), "Некорректная дата начала периода")
+			return
+		}
+		period.PeriodStart = date
+	}
+	if end := c.Query("end"); end != "" {
+		date, err := time.ParseInLocation(time.DateOnly, end, now.Location())
+		if err != nil {
+			response.NewErrorResponse(c, http.StatusBadRequest, err.Error(), "Некорректная дата окончания периода")
+			return
+		}
+		period.PeriodEnd = date
+	}
+
+	var (
+		data []*models.Statistic
+		err  error
+	)
+	if ip := c.Query("ip"); ip != "" {
+		if net.ParseIP(ip) == nil {
+			response.NewErrorResponse(c, http.StatusBadRequest, "invalid ip", "Некорректный IP адрес")
+			return
+		}
+		data, err = h.service.GetByIP(c, &models.GetStatisticByIPDTO{IP: ip, PeriodStart: period.PeriodStart, PeriodEnd: period.PeriodEnd})
+	} else {
+		data, err = h.service.Get(c, period)
+	}
+	if err != nil {
+		response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Произошла ошибка: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, response.DataResponse{Data: data, Count: len(data)})
+}
+
+func (h *Handler) getUnavailable(c *gin.Context) {
+	data, err := h.service.GetUnavailable(c, &models.GetUnavailableDTO{})
+	if err != nil {
+		response.NewErrorResponse(c, http.StatusInternalServerError, err.Error(), "Произошла ошибка: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, response.DataResponse{Data: data, Count: len(data)})
+}
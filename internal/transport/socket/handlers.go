@@ -1,11 +1,13 @@
 package socket
 
 import (
-	"regexp"
+	"fmt"
+	"runtime/debug"
 	"strings"
+	"unicode"
 
 	"github.com/Alexander272/Pinger/internal/models"
-	"github.com/Alexander272/Pinger/internal/services"
+	"github.com/Alexander272/Pinger/internal/transport/command"
 	"github.com/Alexander272/Pinger/pkg/error_bot"
 	"github.com/Alexander272/Pinger/pkg/logger"
 	"github.com/gin-gonic/gin"
@@ -14,22 +16,22 @@ import (
 )
 
 type Handler struct {
-	socket   *model.WebSocketClient
-	user     *model.User
-	services *services.Services
+	socket *model.WebSocketClient
+	user   *model.User
+	router *command.Router
 }
 
 type Deps struct {
-	Socket   *model.WebSocketClient
-	User     *model.User
-	Services *services.Services
+	Socket *model.WebSocketClient
+	User   *model.User
+	Router *command.Router
 }
 
 func NewHandler(deps *Deps) *Handler {
 	return &Handler{
-		socket:   deps.Socket,
-		user:     deps.User,
-		services: deps.Services,
+		socket: deps.Socket,
+		user:   deps.User,
+		router: deps.Router,
 	}
 }
 
@@ -55,11 +57,19 @@ func (h *Handler) handleEvent(event *model.WebSocketEvent) {
 	// logger.Debug("event", logger.StringAttr("type", event.EventType()))
 	// logger.Debug("event", logger.AnyAttr("data", event))
 
+	// событие обрабатывается в отдельной горутине, паника в ней без recover остановит весь бот
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("panic while handling event", logger.AnyAttr("panic", r), logger.StringAttr("stack", string(debug.Stack())))
+			error_bot.Send(&gin.Context{}, fmt.Sprintf("panic: %v", r), event.GetData())
+		}
+	}()
+
 	if event.EventType() != model.WebsocketEventPosted {
 		return
 	}
-	//TODO можно попробовать по этому параметру отсеивать лишние каналы (чтобы бот не читал сообщения в каналах)
-	// channelType := event.GetData()["channel_type"].(string)
+	// по типу канала роутер отличает личные сообщения боту от каналов
+	channelType, _ := event.GetData()["channel_type"].(string)
 
 	// Since this event is a post, unmarshal it to (*model.Post)
 	post := &model.Post{}
@@ -75,35 +85,15 @@ func (h *Handler) handleEvent(event *model.WebSocketEvent) {
 		return
 	}
 	post.Message = strings.TrimSpace(post.Message)
-
-	matches := [...]struct {
-		pattern string
-		handler func(*models.Post) error
-	}{
-		{"^about|^обо мне", h.services.Information.AboutMe},
-		{"^list|^список", h.services.Message.List},
-		{"^add|^добавить", h.services.Message.Create},
-		{"^update|^обновить", h.services.Message.Update},
-		{"^dis|^отключить", func(p *models.Post) error { return h.services.Message.ToggleActive(p, false) }},
-		{"^en|^включить", func(p *models.Post) error { return h.services.Message.ToggleActive(p, true) }},
-		{"^del|^удалить", h.services.Message.Delete},
-		{"^stats|^statistics|^стат", h.services.Message.Statistics},
-		{"^unavailable|^недоступные", h.services.Message.Unavailable},
-		{"help|man|помощь|мануал", h.services.Information.Help},
+	// упоминание бота в начале сообщения - явное обращение к нему, например «@pinger add 10.0.0.1»
+	mention := false
+	if rest, ok := strings.CutPrefix(post.Message, "@"+h.user.Username); ok && (rest == "" || unicode.IsSpace(rune(rest[0]))) {
+		post.Message = strings.TrimSpace(rest)
+		mention = true
 	}
 
-	for _, match := range matches {
-		if ok, _ := regexp.MatchString(match.pattern, post.Message); ok {
-			if err := match.handler(&models.Post{ChannelID: post.ChannelId, Message: post.Message}); err != nil {
-				error_bot.Send(&gin.Context{}, err.Error(), post)
-			}
-			return
-		}
-	}
-
-	if ok, _ := regexp.MatchString("^panic", post.Message); ok {
-		panic("panic")
+	err = h.router.Handle(&models.Post{ChannelID: post.ChannelId, ChannelType: channelType, UserID: post.UserId, Message: post.Message, FileIDs: post.FileIds, Mention: mention})
+	if err != nil {
+		error_bot.Send(&gin.Context{}, err.Error(), post)
 	}
-
-	h.services.Information.Help(&models.Post{ChannelID: post.ChannelId, Message: post.Message})
 }
@@ -0,0 +1,200 @@
+package charts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wcharczuk/go-chart/v2"
+	"github.com/wcharczuk/go-chart/v2/drawing"
+)
+
+var ErrNoData = errors.New("no data to render")
+
+// Point is a single measurement. Rtt is ignored for failed points.
+type Point struct {
+	Time   time.Time
+	Rtt    time.Duration
+	Loss   float64 // percent
+	Failed bool
+}
+
+// Band is a period shaded on the chart, e.g. an outage.
+type Band struct {
+	Start time.Time
+	End   time.Time
+}
+
+type RttChart struct {
+	Title  string
+	Start  time.Time
+	End    time.Time
+	Points []Point
+	Bands  []Band
+
+	// series names shown in the legend
+	RttLabel  string
+	LossLabel string
+	BandLabel string
+}
+
+var (
+	rttColor  = drawing.Color{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff}
+	lossColor = drawing.Color{R: 0xe0, G: 0x7b, B: 0x24, A: 0xff}
+	bandColor = drawing.Color{R: 0xd1, G: 0x3c, B: 0x3c, A: 0x40}
+)
+
+// RenderRtt draws round trip time on the left axis and packet loss on the right one
+// with bands shaded behind them and returns the chart as PNG.
+func RenderRtt(c *RttChart) ([]byte, error) {
+	if len(c.Points) == 0 {
+		return nil, ErrNoData
+	}
+
+	series := []chart.Series{}
+
+	if bands := bandSeries(c); bands != nil {
+		series = append(series, bands)
+	}
+
+	loss := chart.TimeSeries{
+		YAxis: chart.YAxisSecondary,
+		Style: chart.Style{StrokeColor: lossColor, StrokeWidth: 1},
+	}
+	// failed checks have no round trip time, so the line is split into segments instead of bridging the gap
+	var rtt *chart.TimeSeries
+	for _, p := range c.Points {
+		loss.XValues = append(loss.XValues, p.Time)
+		loss.YValues = append(loss.YValues, p.Loss)
+
+		if p.Failed {
+			if rtt != nil {
+				series = append(series, *rtt)
+				rtt = nil
+			}
+			continue
+		}
+		if rtt == nil {
+			rtt = &chart.TimeSeries{Style: chart.Style{StrokeColor: rttColor, StrokeWidth: 1.5}}
+		}
+		rtt.XValues = append(rtt.XValues, p.Time)
+		rtt.YValues = append(rtt.YValues, float64(p.Rtt)/float64(time.Millisecond))
+	}
+	if rtt != nil {
+		series = append(series, *rtt)
+	}
+	series = append(series, loss)
+
+	format := "15:04"
+	if c.End.Sub(c.Start) > 24*time.Hour {
+		format = "02.01 15:04"
+	}
+
+	graph := chart.Chart{
+		Title:  c.Title,
+		Width:  1200,
+		Height: 500,
+		Background: chart.Style{
+			Padding: chart.Box{Top: 70, Left: 20, Right: 20, Bottom: 20},
+		},
+		XAxis: chart.XAxis{
+			ValueFormatter: chart.TimeValueFormatterWithFormat(format),
+			Range:          &chart.ContinuousRange{Min: chart.TimeToFloat64(c.Start), Max: chart.TimeToFloat64(c.End)},
+		},
+		YAxis: chart.YAxis{
+			Name:           "мс",
+			ValueFormatter: func(v interface{}) string { return fmt.Sprintf("%.0f", v) },
+		},
+		YAxisSecondary: chart.YAxis{
+			Name:           "%",
+			Range:          &chart.ContinuousRange{Min: 0, Max: 100},
+			ValueFormatter: func(v interface{}) string { return fmt.Sprintf("%.0f", v) },
+		},
+		Series: series,
+	}
+	graph.Elements = []chart.Renderable{legend([]legendItem{
+		{Label: c.RttLabel, Color: rttColor},
+		{Label: c.LossLabel, Color: lossColor},
+		{Label: c.BandLabel, Color: bandColor},
+	})}
+
+	var buf bytes.Buffer
+	if err := graph.Render(chart.PNG, &buf); err != nil {
+		return nil, fmt.Errorf("failed to render chart. error: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// bandSeries draws bands as a filled step series on the secondary axis, so they stay behind the lines
+func bandSeries(c *RttChart) chart.Series {
+	if len(c.Bands) == 0 {
+		return nil
+	}
+
+	s := chart.TimeSeries{
+		YAxis: chart.YAxisSecondary,
+		Style: chart.Style{StrokeWidth: 0, StrokeColor: drawing.ColorTransparent, FillColor: bandColor},
+	}
+	for _, b := range c.Bands {
+		start, end := b.Start, b.End
+		if start.Before(c.Start) {
+			start = c.Start
+		}
+		if end.After(c.End) {
+			end = c.End
+		}
+		if !end.After(start) {
+			continue
+		}
+		s.XValues = append(s.XValues, start, start, end, end)
+		s.YValues = append(s.YValues, 0, 100, 100, 0)
+	}
+	if len(s.XValues) == 0 {
+		return nil
+	}
+	return s
+}
+
+type legendItem struct {
+	Label string
+	Color drawing.Color
+}
+
+// legend draws a single row of colored squares with labels between the title and the canvas
+func legend(items []legendItem) chart.Renderable {
+	return func(r chart.Renderer, canvas chart.Box, defaults chart.Style) {
+		const size, gap = 10, 20
+
+		r.SetFont(defaults.GetFont())
+		r.SetFontSize(9)
+		r.SetFontColor(chart.DefaultTextColor)
+
+		x, y := canvas.Left, canvas.Top-25
+		for _, item := range items {
+			if item.Label == "" {
+				continue
+			}
+			// translucent bands are shown the way they look on a white background
+			color := blend(item.Color)
+
+			r.SetFillColor(color)
+			r.SetStrokeColor(color)
+			r.MoveTo(x, y-size)
+			r.LineTo(x+size, y-size)
+			r.LineTo(x+size, y)
+			r.LineTo(x, y)
+			r.Close()
+			r.Fill()
+
+			r.Text(item.Label, x+size+5, y)
+			x += size + 5 + r.MeasureText(item.Label).Width() + gap
+		}
+	}
+}
+
+// blend mixes a translucent color with a white background
+func blend(c drawing.Color) drawing.Color {
+	mix := func(v uint8) uint8 { return uint8((int(v)*int(c.A) + 0xff*(0xff-int(c.A))) / 0xff) }
+	return drawing.Color{R: mix(c.R), G: mix(c.G), B: mix(c.B), A: 0xff}
+}
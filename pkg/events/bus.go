@@ -0,0 +1,71 @@
+package events
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Bus рассылает события всем подписчикам. Публикация не блокируется: если буфер подписчика заполнен,
+// событие для него отбрасывается и учитывается в Dropped
+type Bus[T any] struct {
+	mx   sync.RWMutex
+	subs map[*Subscription[T]]struct{}
+}
+
+func NewBus[T any]() *Bus[T] {
+	return &Bus[T]{
+		subs: make(map[*Subscription[T]]struct{}),
+	}
+}
+
+type Subscription[T any] struct {
+	C <-chan T
+
+	ch      chan T
+	filter  func(T) bool
+	bus     *Bus[T]
+	once    sync.Once
+	dropped atomic.Int64
+}
+
+// Subscribe создает подписку с буфером size. filter может быть nil, тогда подписчик получает все события
+func (b *Bus[T]) Subscribe(size int, filter func(T) bool) *Subscription[T] {
+	ch := make(chan T, size)
+	sub := &Subscription[T]{C: ch, ch: ch, filter: filter, bus: b}
+
+	b.mx.Lock()
+	b.subs[sub] = struct{}{}
+	b.mx.Unlock()
+	return sub
+}
+
+func (b *Bus[T]) Publish(event T) {
+	b.mx.RLock()
+	defer b.mx.RUnlock()
+
+	for sub := range b.subs {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// Close отписывает подписчика и закрывает канал событий
+func (s *Subscription[T]) Close() {
+	s.once.Do(func() {
+		s.bus.mx.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mx.Unlock()
+		close(s.ch)
+	})
+}
+
+// Dropped возвращает количество событий, не доставленных из-за переполнения буфера
+func (s *Subscription[T]) Dropped() int64 {
+	return s.dropped.Load()
+}
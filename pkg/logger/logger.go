@@ -45,6 +45,8 @@ var (
 	DebugContext = slog.DebugContext
 	Info         = slog.Info
 	InfoContext  = slog.InfoContext
+	Warn         = slog.Warn
+	WarnContext  = slog.WarnContext
 	Error        = slog.Error
 	ErrorContext = slog.ErrorContext
 )
@@ -69,6 +71,19 @@ func ErrAttr(err error) Attr {
 	return slog.String("error", err.Error())
 }
 
+// level is shared by all loggers created with NewLogger, so it can be changed at runtime
+var level = new(slog.LevelVar)
+
+// SetLevel changes the log level of loggers created with NewLogger.
+func SetLevel(value string) error {
+	var l Level
+	if err := l.UnmarshalText([]byte(value)); err != nil {
+		return err
+	}
+	level.Set(l)
+	return nil
+}
+
 const (
 	defaultLevel      = LevelInfo
 	defaultAddSource  = true
@@ -88,9 +103,10 @@ func NewLogger(opts ...LoggerOption) *Logger {
 		opt(config)
 	}
 
+	level.Set(config.Level)
 	options := &HandlerOptions{
 		AddSource: config.AddSource,
-		Level:     config.Level,
+		Level:     level,
 	}
 
 	var h Handler = NewTextHandler(os.Stdout, options)
@@ -0,0 +1,112 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var ErrClosed = errors.New("pool is closed")
+
+type Pool struct {
+	tasks chan func()
+	size  int
+
+	queued   atomic.Int64
+	inFlight atomic.Int64
+
+	mx     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// New creates a pool with a fixed number of workers.
+// queueSize limits the number of tasks waiting for a free worker.
+func New(size, queueSize int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &Pool{
+		tasks: make(chan func(), queueSize),
+		size:  size,
+	}
+
+	p.wg.Add(size)
+	for i := 0; i < size; i++ {
+		go p.worker()
+	}
+	return p
+}
+
+func (p *Pool) worker() {
+	defer p.wg.Done()
+
+	for task := range p.tasks {
+		p.queued.Add(-1)
+		p.inFlight.Add(1)
+		task()
+		p.inFlight.Add(-1)
+	}
+}
+
+// Submit puts the task into the queue. It blocks while the queue is full
+// and returns the context error if the context is done before the task is queued.
+func (p *Pool) Submit(ctx context.Context, task func()) error {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+
+	p.queued.Add(1)
+	select {
+	case p.tasks <- task:
+		return nil
+	case <-ctx.Done():
+		p.queued.Add(-1)
+		return ctx.Err()
+	}
+}
+
+// Size returns the number of workers.
+func (p *Pool) Size() int {
+	return p.size
+}
+
+// QueueDepth returns the number of tasks waiting for a free worker.
+func (p *Pool) QueueDepth() int64 {
+	return p.queued.Load()
+}
+
+// InFlight returns the number of tasks being executed right now.
+func (p *Pool) InFlight() int64 {
+	return p.inFlight.Load()
+}
+
+// Closed reports whether the pool no longer accepts tasks.
+func (p *Pool) Closed() bool {
+	p.mx.RLock()
+	defer p.mx.RUnlock()
+	return p.closed
+}
+
+// Close stops accepting new tasks and waits until the queued ones are finished.
+// Submit calls that are waiting for a place in the queue finish before the pool is closed.
+func (p *Pool) Close() {
+	p.mx.Lock()
+	if p.closed {
+		p.mx.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.tasks)
+	p.mx.Unlock()
+
+	p.wg.Wait()
+}